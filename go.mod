@@ -3,13 +3,13 @@ module github.com/zhenhua1/go-sapiclient
 go 1.17
 
 require (
+	github.com/fsnotify/fsnotify v1.6.0
 	github.com/go-resty/resty/v2 v2.10.0
 	github.com/spf13/viper v1.17.0
 	github.com/syyongx/php2go v0.9.8
 )
 
 require (
-	github.com/fsnotify/fsnotify v1.6.0 // indirect
 	github.com/hashicorp/hcl v1.0.0 // indirect
 	github.com/magiconair/properties v1.8.7 // indirect
 	github.com/mitchellh/mapstructure v1.5.0 // indirect
@@ -0,0 +1,191 @@
+package sapiclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	//熔断后默认暂停请求的时间
+	DEFAULT_BREAKER_OPEN_TIMEOUT = 30 * time.Second
+)
+
+// ErrCircuitOpen 服务已熔断，请求未发送，可通过errors.Is(err, ErrCircuitOpen)判断
+var ErrCircuitOpen = errors.New("服务已熔断")
+
+// CircuitOpenError
+// @Description: 服务连续失败达到阈值后熔断，暂停期间的请求不发送
+type CircuitOpenError struct {
+	Service    string
+	RetryAfter time.Duration //距离允许试探请求的时间，0表示试探请求进行中
+}
+
+// Error
+//
+//	@Description: 错误信息
+//	@receiver e
+//	@Author zzh 2026-10-20 04:56:10
+//	@return string
+func (e *CircuitOpenError) Error() string {
+	if e.RetryAfter > 0 {
+		return ErrCircuitOpen.Error() + ": " + e.Service + "，" + strconv.FormatInt(int64(e.RetryAfter/time.Millisecond), 10) + "毫秒后重试"
+	}
+	return ErrCircuitOpen.Error() + ": " + e.Service + "，试探请求进行中"
+}
+
+// Is
+//
+//	@Description: 支持errors.Is(err, ErrCircuitOpen)
+//	@receiver e
+//	@Author zzh 2026-10-20 04:56:40
+//	@param target
+//	@return bool
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// BreakerConfig
+// @Description: 熔断配置，按service分别统计
+type BreakerConfig struct {
+	FailureThreshold int           //连续失败次数达到该值时熔断，0表示不熔断
+	OpenTimeout      time.Duration //熔断后暂停请求的时间，之后允许一个试探请求，0表示DEFAULT_BREAKER_OPEN_TIMEOUT
+}
+
+// circuitState
+// @Description: 单个service的熔断状态
+type circuitState struct {
+	failures  int       //连续失败次数
+	openUntil time.Time //熔断暂停截止时间
+	probing   bool      //是否有试探请求进行中
+}
+
+// circuitBreakers
+// @Description: 按service熔断，客户端与其副本共享
+type circuitBreakers struct {
+	mu          sync.Mutex
+	threshold   int
+	openTimeout time.Duration
+	states      map[string]*circuitState
+}
+
+// newCircuitBreakers
+//
+//	@Description: 按配置创建熔断器，未开启熔断时返回nil
+//	@Author zzh 2026-10-20 04:58:22
+//	@param config
+//	@return *circuitBreakers
+func newCircuitBreakers(config BreakerConfig) *circuitBreakers {
+	if config.FailureThreshold <= 0 {
+		return nil
+	}
+	openTimeout := config.OpenTimeout
+	if openTimeout <= 0 {
+		openTimeout = DEFAULT_BREAKER_OPEN_TIMEOUT
+	}
+	return &circuitBreakers{threshold: config.FailureThreshold, openTimeout: openTimeout, states: make(map[string]*circuitState)}
+}
+
+// allow
+//
+//	@Description: 判断是否允许发送请求。熔断暂停结束后只允许一个试探请求，其结果决定恢复或继续熔断
+//	@receiver b 为nil时不熔断
+//	@Author zzh 2026-10-20 05:00:05
+//	@param service
+//	@return probe 是否为试探请求
+//	@return err *CircuitOpenError
+func (b *circuitBreakers) allow(service string) (probe bool, err error) {
+	if b == nil {
+		return false, nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	state, ok := b.states[service]
+	if !ok || state.failures < b.threshold {
+		return false, nil
+	}
+	if wait := time.Until(state.openUntil); wait > 0 {
+		return false, &CircuitOpenError{Service: service, RetryAfter: wait}
+	}
+	if state.probing {
+		return false, &CircuitOpenError{Service: service}
+	}
+	state.probing = true
+	return true, nil
+}
+
+// record
+//
+//	@Description: 记录请求结果。连接失败与5xx响应计为失败；ctx取消、超过大小限制不计入，其它结果视为成功并清零失败次数
+//	@receiver b 为nil时不熔断
+//	@Author zzh 2026-10-20 05:02:31
+//	@param ctx
+//	@param service
+//	@param probe
+//	@param statusCode
+//	@param err
+func (b *circuitBreakers) record(ctx context.Context, service string, probe bool, statusCode int, err error) {
+	if b == nil {
+		return
+	}
+	if err != nil && (ctx.Err() != nil || errors.Is(err, ErrSizeLimit) || errors.Is(err, ErrDecompressionLimit)) {
+		b.release(service, probe)
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err == nil && statusCode < http.StatusInternalServerError {
+		delete(b.states, service)
+		return
+	}
+	state, ok := b.states[service]
+	if !ok {
+		state = &circuitState{}
+		b.states[service] = state
+	}
+	if probe {
+		state.probing = false
+	}
+	state.failures++
+	if state.failures >= b.threshold {
+		state.openUntil = time.Now().Add(b.openTimeout)
+	}
+}
+
+// release
+//
+//	@Description: 请求未发送时结束试探，不计入统计
+//	@receiver b 为nil时不熔断
+//	@Author zzh 2026-10-20 05:03:20
+//	@param service
+//	@param probe
+func (b *circuitBreakers) release(service string, probe bool) {
+	if b == nil || !probe {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if state, ok := b.states[service]; ok {
+		state.probing = false
+	}
+}
+
+// WithCircuitBreaker
+//
+//	@Description: 同一service连续failureThreshold次连接失败或5xx响应后熔断，openTimeout内的请求直接返回*CircuitOpenError
+//	@Author zzh 2026-10-20 05:04:12
+//	@param failureThreshold
+//	@param openTimeout 0表示DEFAULT_BREAKER_OPEN_TIMEOUT
+//	@return Option
+func WithCircuitBreaker(failureThreshold int, openTimeout time.Duration) Option {
+	return func(c *sApiClient) error {
+		if failureThreshold <= 0 || openTimeout < 0 {
+			return errors.New("熔断失败次数应大于0，暂停时间不能为负数")
+		}
+		c.breakers = newCircuitBreakers(BreakerConfig{FailureThreshold: failureThreshold, OpenTimeout: openTimeout})
+		return nil
+	}
+}
@@ -0,0 +1,93 @@
+package sapiclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterWait(t *testing.T) {
+	limiter := newRateLimiter(RateLimitConfig{RequestsPerSecond: 20, Burst: 1})
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := limiter.wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Fatalf("3 requests at 20/s with burst 1 took %s", elapsed)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := limiter.wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("canceled wait err = %v", err)
+	}
+	if newRateLimiter(RateLimitConfig{}) != nil {
+		t.Fatal("limiter created without requestsPerSecond")
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	var requests, healthy int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Content-Type", "application/json")
+		if atomic.LoadInt32(&healthy) == 0 && r.URL.Path == "/sapi/demo/echo" {
+			w.WriteHeader(http.StatusBadGateway)
+			_, _ = w.Write([]byte(`{"code":502,"msg":"bad gateway"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"code":200,"msg":"success"}`))
+	}))
+	defer server.Close()
+	c, err := newClient(WithCredentials("key", "secret"), WithBaseURL(server.URL), WithCircuitBreaker(2, 100*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err = c.Call("demo", "echo", nil); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("call %d err = %v", i, err)
+		}
+	}
+	if _, err = c.Call("demo", "echo", nil); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("open err = %v, want ErrCircuitOpen", err)
+	}
+	if n := atomic.LoadInt32(&requests); n != 2 {
+		t.Fatalf("requests = %d, want 2", n)
+	}
+	if _, err = c.Call("other", "echo", nil); err != nil {
+		t.Fatalf("other service: %v", err)
+	}
+
+	time.Sleep(120 * time.Millisecond)
+	atomic.StoreInt32(&healthy, 1)
+	if _, err = c.Call("demo", "echo", nil); err != nil {
+		t.Fatalf("probe: %v", err)
+	}
+	if _, err = c.Call("demo", "echo", nil); err != nil {
+		t.Fatalf("after recovery: %v", err)
+	}
+}
+
+func TestCircuitBreakerProbeFailure(t *testing.T) {
+	breakers := newCircuitBreakers(BreakerConfig{FailureThreshold: 1, OpenTimeout: time.Millisecond})
+	ctx := context.Background()
+	breakers.record(ctx, "demo", false, http.StatusInternalServerError, nil)
+	time.Sleep(2 * time.Millisecond)
+	probe, err := breakers.allow("demo")
+	if err != nil || !probe {
+		t.Fatalf("probe = %v, %v", probe, err)
+	}
+	var openErr *CircuitOpenError
+	if _, err = breakers.allow("demo"); !errors.As(err, &openErr) || openErr.RetryAfter != 0 {
+		t.Fatalf("concurrent probe err = %v", err)
+	}
+	breakers.record(ctx, "demo", true, 0, errors.New("connection refused"))
+	if _, err = breakers.allow("demo"); !errors.As(err, &openErr) || openErr.RetryAfter <= 0 {
+		t.Fatalf("reopened err = %v", err)
+	}
+}
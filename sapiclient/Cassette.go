@@ -0,0 +1,448 @@
+package sapiclient
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// CassetteMode
+// @Description: 录制回放模式
+type CassetteMode string
+
+const (
+	//cassette文件不存在时录制真实请求，存在时只回放
+	CASSETTE_RECORD_ONCE CassetteMode = "record_once"
+	//只回放，未匹配到记录时直接返回错误
+	CASSETTE_REPLAY_ONLY CassetteMode = "replay_only"
+	//直接透传到真实服务，不录制也不回放
+	CASSETTE_PASSTHROUGH CassetteMode = "passthrough"
+	//cassette中脱敏后的占位值
+	CASSETTE_REDACTED = "[REDACTED]"
+	//加密请求体在规范化参数中的占位值，iv与密文每次不同，不参与匹配
+	CASSETTE_ENCRYPTED = "[ENCRYPTED]"
+)
+
+// ErrCassetteUnmatched 回放时没有找到匹配的请求记录
+var ErrCassetteUnmatched = errors.New("cassette中没有匹配的请求记录")
+
+// cassetteRedactKeys 录制时需要脱敏的签名相关参数与请求头，以及响应签名相关的响应头
+var cassetteRedactKeys = []string{"sign", "appkey", "nonce", "time", SIGNED_HEADERS_HEADER, CONTENT_DIGEST_HEADER,
+	PAYLOAD_HASH_HEADER, RESPONSE_SIGN_HEADER, RESPONSE_TIME_HEADER}
+
+// Cassette
+// @Description: 录制的请求响应集合
+type Cassette struct {
+	Version      int            `json:"version"`
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Interaction
+// @Description: 一次请求与响应记录
+type Interaction struct {
+	Request  CassetteRequest  `json:"request"`
+	Response CassetteResponse `json:"response"`
+}
+
+// CassetteRequest
+// @Description: 录制的请求信息
+type CassetteRequest struct {
+	Service    string              `json:"service"`
+	Method     string              `json:"method"`
+	HttpMethod string              `json:"httpMethod"`
+	Url        string              `json:"url"`
+	Params     string              `json:"params"` //规范化后的请求参数
+	Headers    map[string][]string `json:"headers"`
+}
+
+// CassetteResponse
+// @Description: 录制的响应信息
+type CassetteResponse struct {
+	StatusCode   int                 `json:"statusCode"`
+	Headers      map[string][]string `json:"headers"`
+	Body         string              `json:"body"`
+	BodyEncoding string              `json:"bodyEncoding,omitempty"` //非utf8内容使用base64保存
+}
+
+// Recorder
+// @Description: 录制回放transport，实现http.RoundTripper
+type Recorder struct {
+	mu        sync.Mutex
+	path      string
+	mode      CassetteMode
+	replay    bool              //是否处于回放状态
+	transport http.RoundTripper //真实请求使用的transport
+	cassette  *Cassette
+	used      map[*Interaction]bool
+}
+
+// NewRecorder
+//
+//	@Description: 创建录制回放transport
+//	@Author zzh 2026-10-19 10:20:14
+//	@param cassettePath cassette文件路径
+//	@param mode 录制回放模式
+//	@param transport 真实请求使用的transport，为nil时使用http.DefaultTransport
+//	@return *Recorder
+//	@return error
+func NewRecorder(cassettePath string, mode CassetteMode, transport http.RoundTripper) (*Recorder, error) {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	r := &Recorder{
+		path:      cassettePath,
+		mode:      mode,
+		transport: transport,
+		cassette:  &Cassette{Version: 1},
+		used:      make(map[*Interaction]bool),
+	}
+	switch mode {
+	case CASSETTE_PASSTHROUGH:
+		return r, nil
+	case CASSETTE_RECORD_ONCE, CASSETTE_REPLAY_ONLY:
+	default:
+		return nil, errors.New("不支持的cassette模式: " + string(mode))
+	}
+	content, err := ioutil.ReadFile(cassettePath)
+	if err != nil {
+		if os.IsNotExist(err) && mode == CASSETTE_RECORD_ONCE {
+			return r, nil
+		}
+		return nil, errors.New("cassette文件读取失败: " + err.Error())
+	}
+	if err = json.Unmarshal(content, r.cassette); err != nil {
+		return nil, errors.New("cassette文件解析失败: " + err.Error())
+	}
+	r.replay = true
+	return r, nil
+}
+
+// RoundTrip
+//
+//	@Description: 根据模式录制、回放或透传请求
+//	@receiver r
+//	@Author zzh 2026-10-19 10:24:51
+//	@param req
+//	@return *http.Response
+//	@return error
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	if r.mode == CASSETTE_PASSTHROUGH {
+		return r.transport.RoundTrip(req)
+	}
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	service, method := servicePathOf(req.URL.Path)
+	params := canonicalParams(req, body)
+	if r.replay {
+		interaction := r.match(service, method, params)
+		if interaction == nil {
+			return nil, fmt.Errorf("%w: %s/%s %s", ErrCassetteUnmatched, service, method, params)
+		}
+		return interaction.Response.toHttpResponse(req)
+	}
+	res, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	resBody, err := ioutil.ReadAll(res.Body)
+	_ = res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(resBody))
+	interaction := &Interaction{
+		Request: CassetteRequest{
+			Service:    service,
+			Method:     method,
+			HttpMethod: req.Method,
+			Url:        redactUrl(req.URL),
+			Params:     params,
+			Headers:    redactHeader(req.Header),
+		},
+		Response: CassetteResponse{
+			StatusCode: res.StatusCode,
+			Headers:    redactHeader(res.Header),
+		},
+	}
+	if utf8.Valid(resBody) {
+		interaction.Response.Body = string(resBody)
+	} else {
+		interaction.Response.Body = base64.StdEncoding.EncodeToString(resBody)
+		interaction.Response.BodyEncoding = "base64"
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	if err = r.save(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// match
+//
+//	@Description: 查找匹配的录制记录，优先返回未使用过的记录
+//	@receiver r
+//	@Author zzh 2026-10-19 10:31:06
+//	@param service
+//	@param method
+//	@param params
+//	@return *Interaction
+func (r *Recorder) match(service, method, params string) *Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var matched *Interaction
+	for _, interaction := range r.cassette.Interactions {
+		if interaction.Request.Service != service || interaction.Request.Method != method || interaction.Request.Params != params {
+			continue
+		}
+		if !r.used[interaction] {
+			r.used[interaction] = true
+			return interaction
+		}
+		matched = interaction
+	}
+	return matched
+}
+
+// isCassetteReplay
+//
+//	@Description: 判断transport是否为回放状态的Recorder，回放的响应不是服务端实时返回的
+//	@Author zzh 2026-10-20 05:24:18
+//	@param transport
+//	@return bool
+func isCassetteReplay(transport http.RoundTripper) bool {
+	recorder, ok := transport.(*Recorder)
+	return ok && recorder.mode != CASSETTE_PASSTHROUGH && recorder.replay
+}
+
+// save
+//
+//	@Description: 将录制内容写入cassette文件
+//	@receiver r
+//	@Author zzh 2026-10-19 10:33:40
+//	@return error
+func (r *Recorder) save() error {
+	content, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(r.path); dir != "" {
+		if err = os.MkdirAll(dir, 0755); err != nil {
+			return errors.New("cassette目录创建失败: " + err.Error())
+		}
+	}
+	if err = ioutil.WriteFile(r.path, content, 0644); err != nil {
+		return errors.New("cassette文件写入失败: " + err.Error())
+	}
+	return nil
+}
+
+// toHttpResponse
+//
+//	@Description: 将录制的响应还原为http响应
+//	@receiver cr
+//	@Author zzh 2026-10-19 10:35:12
+//	@param req
+//	@return *http.Response
+//	@return error
+func (cr CassetteResponse) toHttpResponse(req *http.Request) (*http.Response, error) {
+	body := []byte(cr.Body)
+	if cr.BodyEncoding == "base64" {
+		decoded, err := base64.StdEncoding.DecodeString(cr.Body)
+		if err != nil {
+			return nil, errors.New("cassette响应内容解码失败: " + err.Error())
+		}
+		body = decoded
+	}
+	header := make(http.Header, len(cr.Headers))
+	for key, values := range cr.Headers {
+		header[key] = append([]string(nil), values...)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", cr.StatusCode, http.StatusText(cr.StatusCode)),
+		StatusCode:    cr.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// readRequestBody
+//
+//	@Description: 读取请求体并还原，保证后续仍可发送
+//	@Author zzh 2026-10-19 10:37:25
+//	@param req
+//	@return []byte
+//	@return error
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(body)), nil
+	}
+	return body, nil
+}
+
+// servicePathOf
+//
+//	@Description: 从请求路径中解析服务和服务方法
+//	@Author zzh 2026-10-19 10:39:02
+//	@param urlPath
+//	@return service
+//	@return method
+func servicePathOf(urlPath string) (service, method string) {
+	urlPath = "/" + strings.Trim(urlPath, "/")
+	index := strings.LastIndex(urlPath, "/sapi/")
+	if index < 0 {
+		return "", ""
+	}
+	parts := strings.SplitN(urlPath[index+len("/sapi/"):], "/", 2)
+	if len(parts) != 2 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
+
+// canonicalParams
+//
+//	@Description: 将query和body参数合并为按key排序的规范化字符串，去掉签名相关参数。
+//	multipart请求体按字段解析，文件记录为文件名与内容的sha256，不受随机boundary影响；
+//	加密请求体的iv与密文每次不同，只记录为CASSETTE_ENCRYPTED
+//	@Author zzh 2026-10-19 10:42:18
+//	@param req
+//	@param body
+//	@return string
+func canonicalParams(req *http.Request, body []byte) string {
+	values := url.Values{}
+	for key, items := range req.URL.Query() {
+		values[key] = append(values[key], items...)
+	}
+	mediaType, mediaParams, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	switch {
+	case len(body) > 0 && req.Header.Get(ENCRYPT_VERSION_HEADER) != "":
+		values.Add("", CASSETTE_ENCRYPTED)
+	case mediaType == "multipart/form-data" && multipartParams(values, body, mediaParams["boundary"]) == nil:
+		// 已按字段写入values
+	case len(bytes.TrimSpace(body)) > 0:
+		var data map[string]interface{}
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+		if err := decoder.Decode(&data); err == nil {
+			for key, val := range data {
+				values.Add(key, fmt.Sprintf("%v", val))
+			}
+		} else if form, err := url.ParseQuery(string(body)); err == nil {
+			for key, items := range form {
+				values[key] = append(values[key], items...)
+			}
+		} else {
+			values.Add("", string(body))
+		}
+	}
+	for _, key := range cassetteRedactKeys {
+		values.Del(key)
+	}
+	return values.Encode()
+}
+
+// multipartParams
+//
+//	@Description: 解析multipart请求体写入values，普通字段记录字段值，文件记录为文件名与内容的sha256
+//	@Author zzh 2026-10-20 12:05:30
+//	@param values
+//	@param body
+//	@param boundary
+//	@return error 解析失败时不写入values
+func multipartParams(values url.Values, body []byte, boundary string) error {
+	parsed := url.Values{}
+	reader := multipart.NewReader(bytes.NewReader(body), boundary)
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		content, err := ioutil.ReadAll(part)
+		if err != nil {
+			return err
+		}
+		if part.FileName() == "" {
+			parsed.Add(part.FormName(), string(content))
+			continue
+		}
+		sum := sha256.Sum256(content)
+		parsed.Add(part.FormName(), part.FileName()+":sha256:"+hex.EncodeToString(sum[:]))
+	}
+	for key, items := range parsed {
+		values[key] = append(values[key], items...)
+	}
+	return nil
+}
+
+// redactHeader
+//
+//	@Description: 复制header并对签名相关字段脱敏
+//	@Author zzh 2026-10-19 10:45:30
+//	@param header
+//	@return map[string][]string
+func redactHeader(header http.Header) map[string][]string {
+	redacted := make(map[string][]string, len(header))
+	for key, values := range header {
+		redacted[key] = append([]string(nil), values...)
+		for _, redactKey := range cassetteRedactKeys {
+			if strings.EqualFold(key, redactKey) {
+				redacted[key] = []string{CASSETTE_REDACTED}
+			}
+		}
+	}
+	return redacted
+}
+
+// redactUrl
+//
+//	@Description: 对url中签名相关的query参数脱敏
+//	@Author zzh 2026-10-19 10:47:51
+//	@param u
+//	@return string
+func redactUrl(u *url.URL) string {
+	copied := *u
+	query := copied.Query()
+	for _, key := range cassetteRedactKeys {
+		if query.Get(key) != "" {
+			query.Set(key, CASSETTE_REDACTED)
+		}
+	}
+	copied.RawQuery = query.Encode()
+	return copied.String()
+}
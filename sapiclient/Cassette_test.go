@@ -0,0 +1,245 @@
+package sapiclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func newCassetteServer(requests *int32) *httptest.Server {
+	return httptest.NewServer(cassetteHandler(requests))
+}
+
+// cassetteHandler 返回请求参数中的id
+func cassetteHandler(requests *int32) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		params := map[string]interface{}{}
+		_ = json.NewDecoder(r.Body).Decode(&params)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(&ResponseData{Code: 200, Msg: "success", Data: params["id"]})
+	})
+}
+
+func TestRecorderRecordOnce(t *testing.T) {
+	var requests int32
+	server := newCassetteServer(&requests)
+	cassettePath := filepath.Join(t.TempDir(), "cassettes", "demo.json")
+	recorder, err := NewRecorder(cassettePath, CASSETTE_RECORD_ONCE, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := newClient(WithCredentials("key", "secret"), WithBaseURL(server.URL), WithTransport(recorder))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"a", "b"} {
+		response, callErr := c.Call("demo", "echo", map[string]interface{}{"id": id})
+		if callErr != nil || response.Data != id {
+			t.Fatalf("record %s = %+v, %v", id, response, callErr)
+		}
+	}
+	server.Close()
+
+	content, err := ioutil.ReadFile(cassettePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cassette := &Cassette{}
+	if err = json.Unmarshal(content, cassette); err != nil || len(cassette.Interactions) != 2 {
+		t.Fatalf("cassette = %s, %v", content, err)
+	}
+	request := cassette.Interactions[0].Request
+	if request.Service != "demo" || request.Method != "echo" || request.Params != "id=a" {
+		t.Fatalf("recorded request = %+v", request)
+	}
+	for _, key := range []string{"sign", "appkey", "nonce", "time"} {
+		if values := http.Header(request.Headers).Values(key); len(values) != 1 || values[0] != CASSETTE_REDACTED {
+			t.Fatalf("header %s = %v, want redacted", key, values)
+		}
+	}
+
+	// 文件已存在时只回放，服务已关闭
+	recorder, err = NewRecorder(cassettePath, CASSETTE_RECORD_ONCE, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetTransport(recorder)
+	for _, id := range []string{"b", "a", "a"} {
+		response, callErr := c.Call("demo", "echo", map[string]interface{}{"id": id})
+		if callErr != nil || response.Data != id {
+			t.Fatalf("replay %s = %+v, %v", id, response, callErr)
+		}
+	}
+	if _, err = c.Call("demo", "echo", map[string]interface{}{"id": "c"}); !errors.Is(err, ErrCassetteUnmatched) {
+		t.Fatalf("unmatched err = %v, want ErrCassetteUnmatched", err)
+	}
+	if n := atomic.LoadInt32(&requests); n != 2 {
+		t.Fatalf("requests = %d, want 2", n)
+	}
+}
+
+func TestRecorderReplayOnly(t *testing.T) {
+	cassettePath := filepath.Join(t.TempDir(), "demo.json")
+	if _, err := NewRecorder(cassettePath, CASSETTE_REPLAY_ONLY, nil); err == nil {
+		t.Fatal("replay_only without cassette file succeeded")
+	}
+	cassette := &Cassette{Version: 1, Interactions: []*Interaction{{
+		Request:  CassetteRequest{Service: "demo", Method: "echo", Params: "id=a"},
+		Response: CassetteResponse{StatusCode: http.StatusOK, Headers: map[string][]string{"Content-Type": {"application/json"}}, Body: `{"code":200,"msg":"success","data":"a"}`},
+	}}}
+	content, _ := json.Marshal(cassette)
+	if err := ioutil.WriteFile(cassettePath, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	recorder, err := NewRecorder(cassettePath, CASSETTE_REPLAY_ONLY, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest(http.MethodPost, "http://127.0.0.1:1/sapi/demo/echo", nil)
+	if _, err = recorder.RoundTrip(req); !errors.Is(err, ErrCassetteUnmatched) {
+		t.Fatalf("unmatched err = %v, want ErrCassetteUnmatched", err)
+	}
+	c, err := newClient(WithCredentials("key", "secret"), WithBaseURL("http://127.0.0.1:1/"), WithTransport(recorder))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response, callErr := c.Call("demo", "echo", map[string]interface{}{"id": "a"}); callErr != nil || response.Data != "a" {
+		t.Fatalf("replay = %+v, %v", response, callErr)
+	}
+	if _, err = NewRecorder(cassettePath, CassetteMode("record_all"), nil); err == nil {
+		t.Fatal("unsupported mode accepted")
+	}
+}
+
+func TestRecorderPassthrough(t *testing.T) {
+	var requests int32
+	server := newCassetteServer(&requests)
+	defer server.Close()
+	cassettePath := filepath.Join(t.TempDir(), "demo.json")
+	recorder, err := NewRecorder(cassettePath, CASSETTE_PASSTHROUGH, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := newClient(WithCredentials("key", "secret"), WithBaseURL(server.URL), WithTransport(recorder))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err = c.Call("demo", "echo", map[string]interface{}{"id": "a"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := atomic.LoadInt32(&requests); n != 2 {
+		t.Fatalf("requests = %d, want 2", n)
+	}
+	if _, err = os.Stat(cassettePath); !os.IsNotExist(err) {
+		t.Fatal("passthrough wrote cassette file")
+	}
+}
+
+func TestRecorderRedactsSignatureHeaders(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(ResponseSignMiddleware(nil, "server-key")(cassetteHandler(&requests)))
+	cassettePath := filepath.Join(t.TempDir(), "demo.json")
+	recorder, err := NewRecorder(cassettePath, CASSETTE_RECORD_ONCE, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := newClient(WithCredentials("key", "secret"), WithBaseURL(server.URL), WithTransport(recorder),
+		WithSigner(HMACSHA256Signer{}), WithBodyDigest(), WithResponseVerify("server-key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response, callErr := c.Call("demo", "echo", map[string]interface{}{"id": "a"}); callErr != nil || response.Data != "a" {
+		t.Fatalf("record = %+v, %v", response, callErr)
+	}
+	server.Close()
+	content, err := ioutil.ReadFile(cassettePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cassette := &Cassette{}
+	if err = json.Unmarshal(content, cassette); err != nil || len(cassette.Interactions) != 1 {
+		t.Fatalf("cassette = %s, %v", content, err)
+	}
+	interaction := cassette.Interactions[0]
+	for _, key := range []string{SIGNED_HEADERS_HEADER, CONTENT_DIGEST_HEADER, "sign"} {
+		if values := http.Header(interaction.Request.Headers).Values(key); len(values) != 1 || values[0] != CASSETTE_REDACTED {
+			t.Fatalf("request header %s = %v, want redacted", key, values)
+		}
+	}
+	for _, key := range []string{RESPONSE_SIGN_HEADER, RESPONSE_TIME_HEADER} {
+		if values := http.Header(interaction.Response.Headers).Values(key); len(values) != 1 || values[0] != CASSETTE_REDACTED {
+			t.Fatalf("response header %s = %v, want redacted", key, values)
+		}
+	}
+	// 回放的响应签名已脱敏，不校验
+	if recorder, err = NewRecorder(cassettePath, CASSETTE_REPLAY_ONLY, nil); err != nil {
+		t.Fatal(err)
+	}
+	c.SetTransport(recorder)
+	if response, callErr := c.Call("demo", "echo", map[string]interface{}{"id": "a"}); callErr != nil || response.Data != "a" {
+		t.Fatalf("replay = %+v, %v", response, callErr)
+	}
+}
+
+func TestRecorderNormalizesMultipartAndEncryption(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sapi/doc/upload", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":200,"msg":"success","data":"` + r.URL.Query().Get("title") + `"}`))
+	})
+	mux.Handle("/sapi/demo/echo", EncryptMiddleware(EncryptOptions{SecretStore: StaticSecretStore{"key": "secret"}})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"code":200,"msg":"success","data":"secret-data"}`))
+		})))
+	server := httptest.NewServer(mux)
+	cassettePath := filepath.Join(t.TempDir(), "demo.json")
+	recorder, err := NewRecorder(cassettePath, CASSETTE_RECORD_ONCE, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := newClient(WithCredentials("key", "secret"), WithBaseURL(server.URL), WithTransport(recorder), WithSigner(HMACSHA256Signer{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted := c.Clone().SetEncryption(true, nil)
+	upload := func(content string) (*Response, error) {
+		part := UploadPart{Field: "file", FileName: "a.txt", Reader: strings.NewReader(content), Size: int64(len(content))}
+		return c.Upload(context.Background(), "doc", "upload", map[string]interface{}{"title": "report"}, []UploadPart{part}, UploadOptions{})
+	}
+	// 加密响应的aad包含请求nonce，回放时使用相同的nonce才能解密
+	options := CallOptions{Nonce: "fixed-nonce"}
+	if response, callErr := upload("hello"); callErr != nil || response.Data != "report" {
+		t.Fatalf("record upload = %+v, %v", response, callErr)
+	}
+	if response, callErr := encrypted.CallWithOptions(context.Background(), "demo", "echo", map[string]interface{}{"id": "a"}, options); callErr != nil || response.Data != "secret-data" {
+		t.Fatalf("record encrypted = %+v, %v", response, callErr)
+	}
+	server.Close()
+
+	if recorder, err = NewRecorder(cassettePath, CASSETTE_REPLAY_ONLY, nil); err != nil {
+		t.Fatal(err)
+	}
+	c.SetTransport(recorder)
+	encrypted.SetTransport(recorder)
+	if response, callErr := upload("hello"); callErr != nil || response.Data != "report" {
+		t.Fatalf("replay upload with new boundary = %+v, %v", response, callErr)
+	}
+	if _, err = upload("changed"); !errors.Is(err, ErrCassetteUnmatched) {
+		t.Fatalf("changed file err = %v, want ErrCassetteUnmatched", err)
+	}
+	if response, callErr := encrypted.CallWithOptions(context.Background(), "demo", "echo", map[string]interface{}{"id": "a"}, options); callErr != nil || response.Data != "secret-data" {
+		t.Fatalf("replay encrypted with new iv = %+v, %v", response, callErr)
+	}
+}
@@ -0,0 +1,607 @@
+package sapiclient
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	//分片上传的操作字段
+	CHUNK_FIELD_ACTION = "action"
+	//创建上传，参数为fileName、size、chunkSize、chunks、checksum，data返回{"uploadId":".."}
+	CHUNK_ACTION_INIT = "init"
+	//查询已上传的分片，参数为uploadId，data返回{"uploaded":[0,1,..]}，上传不存在时HTTP状态码为200、code为CHUNK_CODE_NOT_FOUND
+	CHUNK_ACTION_STATUS = "status"
+	//上传分片，multipart请求，字段uploadId、index、checksum在query中发送并参与签名，文件字段为chunk。
+	//分片内容以UNSIGNED-PAYLOAD发送不参与签名，服务端需按checksum校验分片内容
+	CHUNK_ACTION_CHUNK = "chunk"
+	//合并分片，参数为uploadId、checksum，服务端校验整个文件后返回业务结果
+	CHUNK_ACTION_COMPLETE = "complete"
+	//分片上传的文件字段
+	CHUNK_FIELD_FILE = "chunk"
+	//默认分片大小 8MB
+	DEFAULT_CHUNK_SIZE = 8 << 20
+	//默认同时上传的分片数
+	DEFAULT_CHUNK_CONCURRENCY = 3
+	//默认每个分片的重试次数
+	DEFAULT_CHUNK_RETRIES = 3
+	//上传状态文件的默认后缀
+	CHUNK_STATE_SUFFIX = ".sapiupload"
+	//分片上传各操作成功时返回的code
+	CHUNK_CODE_SUCCESS = 200
+	//status查询的上传不存在（已过期或已合并）时返回的code，客户端只在此时重新创建上传
+	CHUNK_CODE_NOT_FOUND = 404
+)
+
+// ErrChunkedUpload 分片上传失败，可通过errors.Is(err, ErrChunkedUpload)判断
+var ErrChunkedUpload = errors.New("分片上传失败")
+
+// ChunkedUploadError
+// @Description: 分片上传失败，Index为出错的分片序号，-1表示不是上传分片时出错
+type ChunkedUploadError struct {
+	Action     string //出错的操作
+	Index      int
+	StatusCode int    //响应状态码，未收到响应时为0
+	Code       int    //服务端返回的code，请求失败时为0
+	Msg        string //服务端返回的msg
+	Err        error  //请求失败时的原始错误
+}
+
+// Error
+//
+//	@Description: 错误信息
+//	@receiver e
+//	@Author zzh 2026-10-20 02:20:10
+//	@return string
+func (e *ChunkedUploadError) Error() string {
+	msg := ErrChunkedUpload.Error() + ": " + e.Action
+	if e.Index >= 0 {
+		msg += " 第" + strconv.Itoa(e.Index) + "片"
+	}
+	if e.Err != nil {
+		return msg + ": " + e.Err.Error()
+	}
+	return msg + ": " + strconv.Itoa(e.Code) + " " + e.Msg
+}
+
+// Is
+//
+//	@Description: 支持errors.Is(err, ErrChunkedUpload)
+//	@receiver e
+//	@Author zzh 2026-10-20 02:20:45
+//	@param target
+//	@return bool
+func (e *ChunkedUploadError) Is(target error) bool {
+	return target == ErrChunkedUpload
+}
+
+// notFound
+//
+//	@Description: 服务端是否明确返回了上传不存在
+//	@receiver e
+//	@Author zzh 2026-10-20 06:08:31
+//	@return bool
+func (e *ChunkedUploadError) notFound() bool {
+	return e.Err == nil && e.StatusCode == http.StatusOK && e.Code == CHUNK_CODE_NOT_FOUND
+}
+
+// temporary
+//
+//	@Description: 是否为可重试的错误：请求失败、5xx或429
+//	@receiver e
+//	@Author zzh 2026-10-20 06:09:02
+//	@return bool
+func (e *ChunkedUploadError) temporary() bool {
+	return (e.Err != nil && e.StatusCode == 0) || e.StatusCode >= http.StatusInternalServerError || e.StatusCode == http.StatusTooManyRequests
+}
+
+// Unwrap
+//
+//	@Description: 返回请求失败时的原始错误
+//	@receiver e
+//	@Author zzh 2026-10-20 02:21:05
+//	@return error
+func (e *ChunkedUploadError) Unwrap() error {
+	return e.Err
+}
+
+// ChunkedUploadOptions
+// @Description: 分片上传选项，零值使用默认值
+type ChunkedUploadOptions struct {
+	ChunkSize   int64                       //分片大小 字节，默认DEFAULT_CHUNK_SIZE，续传时沿用状态文件中的大小
+	Concurrency int                         //同时上传的分片数，默认DEFAULT_CHUNK_CONCURRENCY
+	Retries     int                         //每个分片的重试次数，默认DEFAULT_CHUNK_RETRIES，小于0表示不重试
+	RetryWait   time.Duration               //重试等待时间，默认1秒，之后每次翻倍
+	StateFile   string                      //上传状态文件，默认为<文件路径>.sapiupload
+	FileName    string                      //上传的文件名，默认为文件路径的最后一部分
+	Fields      map[string]interface{}      //创建上传时额外的参数
+	Progress    func(uploaded, total int64) //进度回调，uploaded为已上传的分片大小之和，可能在其它goroutine中调用
+	Call        CallOptions                 //每次调用的选项
+}
+
+// chunkState
+// @Description: 持久化的上传状态，进程重启后据此续传
+type chunkState struct {
+	Service   string   `json:"service"`
+	Method    string   `json:"method"`
+	UploadId  string   `json:"uploadId"`
+	Size      int64    `json:"size"`
+	ModTime   int64    `json:"modTime"` //文件修改时间 纳秒
+	ChunkSize int64    `json:"chunkSize"`
+	Checksum  string   `json:"checksum"` //整个文件的sha256
+	Chunks    []string `json:"chunks"`   //每个分片的sha256
+	Uploaded  []int    `json:"uploaded"` //已上传的分片
+}
+
+// chunkCount
+//
+//	@Description: 分片数量
+//	@receiver s
+//	@Author zzh 2026-10-20 02:23:30
+//	@return int
+func (s *chunkState) chunkCount() int {
+	return len(s.Chunks)
+}
+
+// chunkRange
+//
+//	@Description: 分片在文件中的位置
+//	@receiver s
+//	@Author zzh 2026-10-20 02:24:02
+//	@param index
+//	@return offset
+//	@return size
+func (s *chunkState) chunkRange(index int) (offset, size int64) {
+	offset = int64(index) * s.ChunkSize
+	size = s.ChunkSize
+	if offset+size > s.Size {
+		size = s.Size - offset
+	}
+	return
+}
+
+// loadChunkState
+//
+//	@Description: 读取状态文件，文件不存在、内容无法解析或与当前文件不一致时返回nil
+//	@Author zzh 2026-10-20 02:25:40
+//	@param path
+//	@param service
+//	@param method
+//	@param info
+//	@return *chunkState
+func loadChunkState(path, service, method string, info os.FileInfo) *chunkState {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	state := &chunkState{}
+	if json.Unmarshal(content, state) != nil {
+		return nil
+	}
+	if state.Service != service || state.Method != method || state.UploadId == "" || state.Size != info.Size() ||
+		state.ModTime != info.ModTime().UnixNano() || state.ChunkSize <= 0 || state.Checksum == "" {
+		return nil
+	}
+	if expected := (state.Size + state.ChunkSize - 1) / state.ChunkSize; int64(len(state.Chunks)) != expected {
+		return nil
+	}
+	return state
+}
+
+// save
+//
+//	@Description: 写入状态文件，先写临时文件再重命名，避免进程中断时留下不完整的内容
+//	@receiver s
+//	@Author zzh 2026-10-20 02:27:12
+//	@param path
+//	@return error
+func (s *chunkState) save(path string) error {
+	content, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err = ioutil.WriteFile(tmp, content, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// checksumFile
+//
+//	@Description: 读取一遍文件，计算整个文件与每个分片的sha256
+//	@Author zzh 2026-10-20 02:29:05
+//	@param ctx
+//	@param file
+//	@param chunkSize
+//	@return string
+//	@return []string
+//	@return error
+func checksumFile(ctx context.Context, file *os.File, chunkSize int64) (string, []string, error) {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", nil, err
+	}
+	fileHash := sha256.New()
+	chunks := make([]string, 0)
+	for {
+		if err := ctx.Err(); err != nil {
+			return "", nil, err
+		}
+		chunkHash := sha256.New()
+		n, err := io.Copy(io.MultiWriter(fileHash, chunkHash), io.LimitReader(file, chunkSize))
+		if err != nil {
+			return "", nil, err
+		}
+		if n == 0 {
+			break
+		}
+		chunks = append(chunks, hex.EncodeToString(chunkHash.Sum(nil)))
+		if n < chunkSize {
+			break
+		}
+	}
+	return hex.EncodeToString(fileHash.Sum(nil)), chunks, nil
+}
+
+// chunkedUpload
+// @Description: 一次分片上传
+type chunkedUpload struct {
+	client    *sApiClient
+	service   string
+	method    string
+	file      *os.File
+	fileName  string
+	statePath string
+	options   ChunkedUploadOptions
+	mu        sync.Mutex //保护state.Uploaded与进度
+	state     *chunkState
+	uploaded  int64
+}
+
+// ChunkedUpload
+//
+//	@Description: 分片上传文件，每个分片带有sha256，多个分片同时上传并分别重试，上传状态写入状态文件，
+//	进程重启后使用相同的参数调用即可续传，全部分片上传后调用complete合并并返回其结果。协议见CHUNK_ACTION_*。
+//	分片请求体不参与签名（UNSIGNED-PAYLOAD），内容完整性依赖服务端按分片checksum与complete的整个文件checksum校验
+//	@receiver c
+//	@Author zzh 2026-10-20 02:32:18
+//	@param ctx
+//	@param service
+//	@param method
+//	@param path 文件路径
+//	@param options
+//	@return *ResponseData complete的响应
+//	@return error
+func (c *sApiClient) ChunkedUpload(ctx context.Context, service, method, path string, options ChunkedUploadOptions) (*ResponseData, error) {
+	if options.ChunkSize <= 0 {
+		options.ChunkSize = DEFAULT_CHUNK_SIZE
+	}
+	if options.Concurrency <= 0 {
+		options.Concurrency = DEFAULT_CHUNK_CONCURRENCY
+	}
+	if options.Retries == 0 {
+		options.Retries = DEFAULT_CHUNK_RETRIES
+	} else if options.Retries < 0 {
+		options.Retries = 0
+	}
+	if options.RetryWait <= 0 {
+		options.RetryWait = time.Second
+	}
+	if options.StateFile == "" {
+		options.StateFile = path + CHUNK_STATE_SUFFIX
+	}
+	if options.FileName == "" {
+		options.FileName = filepath.Base(path)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	u := &chunkedUpload{
+		client:    c,
+		service:   service,
+		method:    method,
+		file:      file,
+		fileName:  options.FileName,
+		statePath: options.StateFile,
+		options:   options,
+	}
+	if err = u.resume(ctx, info); err != nil {
+		return nil, err
+	}
+	if err = u.uploadChunks(ctx); err != nil {
+		return nil, err
+	}
+	return u.complete(ctx)
+}
+
+// call
+//
+//	@Description: 调用分片上传的一个操作，code不为CHUNK_CODE_SUCCESS时返回ChunkedUploadError
+//	@receiver u
+//	@Author zzh 2026-10-20 02:34:50
+//	@param ctx
+//	@param action
+//	@param index 分片序号，不是上传分片时为-1
+//	@param fields
+//	@param parts
+//	@return *ResponseData
+//	@return error
+func (u *chunkedUpload) call(ctx context.Context, action string, index int, fields map[string]interface{}, parts []UploadPart) (*ResponseData, error) {
+	fields[CHUNK_FIELD_ACTION] = action
+	var response *Response
+	var err error
+	if len(parts) > 0 {
+		response, err = u.client.Upload(ctx, u.service, u.method, fields, parts, UploadOptions{CallOptions: u.options.Call})
+	} else {
+		response, err = u.client.CallWithOptions(ctx, u.service, u.method, fields, u.options.Call)
+	}
+	if response == nil || response.ResponseData == nil {
+		if err == nil {
+			err = errors.New("响应为空")
+		}
+		statusCode := 0
+		if response != nil {
+			statusCode = response.StatusCode
+		}
+		return nil, &ChunkedUploadError{Action: action, Index: index, StatusCode: statusCode, Err: err}
+	}
+	if response.Code != CHUNK_CODE_SUCCESS {
+		return nil, &ChunkedUploadError{Action: action, Index: index, StatusCode: response.StatusCode, Code: response.Code, Msg: response.Msg}
+	}
+	if err != nil {
+		return nil, &ChunkedUploadError{Action: action, Index: index, StatusCode: response.StatusCode, Err: err}
+	}
+	return response.ResponseData, nil
+}
+
+// resume
+//
+//	@Description: 读取状态文件并向服务端查询已上传的分片，状态文件不可用或服务端明确返回上传不存在（CHUNK_CODE_NOT_FOUND）时重新创建上传，
+//	查询失败、服务端过载或请求被拒绝时不能确定上传是否还存在，返回错误并保留状态文件
+//	@receiver u
+//	@Author zzh 2026-10-20 02:37:12
+//	@param ctx
+//	@param info
+//	@return error
+func (u *chunkedUpload) resume(ctx context.Context, info os.FileInfo) error {
+	if state := loadChunkState(u.statePath, u.service, u.method, info); state != nil {
+		data, err := u.queryStatus(ctx, state.UploadId)
+		var uploadErr *ChunkedUploadError
+		if err != nil && !(errors.As(err, &uploadErr) && uploadErr.notFound()) {
+			return err
+		}
+		if err == nil {
+			var status struct {
+				Uploaded []int `json:"uploaded"`
+			}
+			content, _ := json.Marshal(data.Data)
+			if err = json.Unmarshal(content, &status); err != nil {
+				return &ChunkedUploadError{Action: CHUNK_ACTION_STATUS, Index: -1, Err: err}
+			}
+			// 以服务端的记录为准
+			state.Uploaded = make([]int, 0, len(status.Uploaded))
+			for _, index := range status.Uploaded {
+				if index >= 0 && index < state.chunkCount() {
+					state.Uploaded = append(state.Uploaded, index)
+				}
+			}
+			u.state = state
+			for _, index := range state.Uploaded {
+				_, size := state.chunkRange(index)
+				u.uploaded += size
+			}
+			return state.save(u.statePath)
+		}
+	}
+	checksum, chunks, err := checksumFile(ctx, u.file, u.options.ChunkSize)
+	if err != nil {
+		return err
+	}
+	state := &chunkState{
+		Service:   u.service,
+		Method:    u.method,
+		Size:      info.Size(),
+		ModTime:   info.ModTime().UnixNano(),
+		ChunkSize: u.options.ChunkSize,
+		Checksum:  checksum,
+		Chunks:    chunks,
+		Uploaded:  []int{},
+	}
+	fields := map[string]interface{}{
+		"fileName":  u.fileName,
+		"size":      state.Size,
+		"chunkSize": state.ChunkSize,
+		"chunks":    state.chunkCount(),
+		"checksum":  checksum,
+	}
+	for key, val := range u.options.Fields {
+		if _, ok := fields[key]; !ok {
+			fields[key] = val
+		}
+	}
+	data, err := u.call(ctx, CHUNK_ACTION_INIT, -1, fields, nil)
+	if err != nil {
+		return err
+	}
+	if result, ok := data.Data.(map[string]interface{}); ok {
+		state.UploadId, _ = result["uploadId"].(string)
+	}
+	if state.UploadId == "" {
+		return &ChunkedUploadError{Action: CHUNK_ACTION_INIT, Index: -1, Err: errors.New("响应中没有uploadId")}
+	}
+	u.state = state
+	return state.save(u.statePath)
+}
+
+// queryStatus
+//
+//	@Description: 查询已上传的分片，请求失败、5xx或429时按RetryWait翻倍等待后重试
+//	@receiver u
+//	@Author zzh 2026-10-20 06:10:24
+//	@param ctx
+//	@param uploadId
+//	@return *ResponseData
+//	@return error
+func (u *chunkedUpload) queryStatus(ctx context.Context, uploadId string) (*ResponseData, error) {
+	wait := u.options.RetryWait
+	for attempt := 0; ; attempt++ {
+		data, err := u.call(ctx, CHUNK_ACTION_STATUS, -1, map[string]interface{}{"uploadId": uploadId}, nil)
+		var uploadErr *ChunkedUploadError
+		if err == nil || attempt >= u.options.Retries || !errors.As(err, &uploadErr) || !uploadErr.temporary() {
+			return data, err
+		}
+		select {
+		case <-time.After(wait):
+			wait *= 2
+		case <-ctx.Done():
+			return nil, err
+		}
+	}
+}
+
+// uploadChunks
+//
+//	@Description: 同时上传未上传的分片，任一分片重试后仍失败时取消其它分片并返回该错误
+//	@receiver u
+//	@Author zzh 2026-10-20 02:40:25
+//	@param ctx
+//	@return error
+func (u *chunkedUpload) uploadChunks(ctx context.Context) error {
+	done := make(map[int]bool, len(u.state.Uploaded))
+	for _, index := range u.state.Uploaded {
+		done[index] = true
+	}
+	pending := make(chan int, u.state.chunkCount())
+	for index := 0; index < u.state.chunkCount(); index++ {
+		if !done[index] {
+			pending <- index
+		}
+	}
+	close(pending)
+	u.reportProgress()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	for i := 0; i < u.options.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range pending {
+				if err := u.uploadChunk(ctx, index); err != nil {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// uploadChunk
+//
+//	@Description: 上传一个分片，失败时按RetryWait翻倍等待后重试
+//	@receiver u
+//	@Author zzh 2026-10-20 02:42:40
+//	@param ctx
+//	@param index
+//	@return error
+func (u *chunkedUpload) uploadChunk(ctx context.Context, index int) error {
+	offset, size := u.state.chunkRange(index)
+	wait := u.options.RetryWait
+	var err error
+	for attempt := 0; attempt <= u.options.Retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(wait):
+				wait *= 2
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		fields := map[string]interface{}{"uploadId": u.state.UploadId, "index": index, "checksum": u.state.Chunks[index]}
+		part := UploadPart{Field: CHUNK_FIELD_FILE, FileName: u.fileName, Reader: io.NewSectionReader(u.file, offset, size), Size: size}
+		if _, err = u.call(ctx, CHUNK_ACTION_CHUNK, index, fields, []UploadPart{part}); err == nil {
+			return u.markUploaded(index, size)
+		}
+		if ctx.Err() != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// markUploaded
+//
+//	@Description: 记录已上传的分片并写入状态文件
+//	@receiver u
+//	@Author zzh 2026-10-20 02:44:12
+//	@param index
+//	@param size
+//	@return error
+func (u *chunkedUpload) markUploaded(index int, size int64) error {
+	u.mu.Lock()
+	u.state.Uploaded = append(u.state.Uploaded, index)
+	sort.Ints(u.state.Uploaded)
+	u.uploaded += size
+	err := u.state.save(u.statePath)
+	u.mu.Unlock()
+	u.reportProgress()
+	return err
+}
+
+// reportProgress
+//
+//	@Description: 回调上传进度
+//	@receiver u
+//	@Author zzh 2026-10-20 02:45:01
+func (u *chunkedUpload) reportProgress() {
+	if u.options.Progress == nil {
+		return
+	}
+	u.mu.Lock()
+	uploaded := u.uploaded
+	u.mu.Unlock()
+	u.options.Progress(uploaded, u.state.Size)
+}
+
+// complete
+//
+//	@Description: 合并分片，成功后删除状态文件
+//	@receiver u
+//	@Author zzh 2026-10-20 02:46:20
+//	@param ctx
+//	@return *ResponseData
+//	@return error
+func (u *chunkedUpload) complete(ctx context.Context) (*ResponseData, error) {
+	fields := map[string]interface{}{"uploadId": u.state.UploadId, "checksum": u.state.Checksum}
+	data, err := u.call(ctx, CHUNK_ACTION_COMPLETE, -1, fields, nil)
+	if err != nil {
+		return nil, err
+	}
+	_ = os.Remove(u.statePath)
+	return data, nil
+}
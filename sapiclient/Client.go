@@ -0,0 +1,245 @@
+package sapiclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Client
+// @Description: 客户端接口，NewClient返回的客户端实现了该接口，其他包通过该接口引用客户端，测试中可使用sapimock.Client替换
+type Client interface {
+	// Call 调用服务方法，可被多个goroutine同时调用
+	Call(service, method string, body map[string]interface{}) (*ResponseData, error)
+	// CallContext 调用服务方法，ctx取消或超时时中止请求
+	CallContext(ctx context.Context, service, method string, body map[string]interface{}) (*ResponseData, error)
+	// CallWithOptions 使用单次调用选项调用服务方法，返回包含状态码、响应头与原始响应体的完整响应
+	CallWithOptions(ctx context.Context, service, method string, body map[string]interface{}, options CallOptions) (*Response, error)
+	// CallStream 流式调用服务方法，逐项读取data数组，返回的Stream使用完后需要调用Close
+	CallStream(ctx context.Context, service, method string, body map[string]interface{}, options CallOptions) (*Stream, error)
+	// Upload 以multipart/form-data上传文件，文件内容边读取边发送
+	Upload(ctx context.Context, service, method string, fields map[string]interface{}, parts []UploadPart, options UploadOptions) (*Response, error)
+	// ChunkedUpload 分片上传文件，支持并发、重试与断点续传，返回合并分片的响应
+	ChunkedUpload(ctx context.Context, service, method, path string, options ChunkedUploadOptions) (*ResponseData, error)
+	// Download 下载服务方法返回的内容并边读取边写入w，中断时使用Range续传
+	Download(ctx context.Context, service, method string, body map[string]interface{}, w io.Writer, options DownloadOptions) (*DownloadResult, error)
+	// DownloadFile 下载到文件，进程重启后使用相同的参数调用即可续传
+	DownloadFile(ctx context.Context, service, method string, body map[string]interface{}, path string, options DownloadOptions) (*DownloadResult, error)
+}
+
+// CallOptions
+// @Description: 单次调用选项，零值表示使用客户端配置
+type CallOptions struct {
+	Headers       map[string]string //本次调用额外的请求头
+	RequestMethod string            //覆盖客户端的请求方法
+	Timeout       int               //覆盖客户端的超时时间 秒
+	Nonce         string            //指定随机字符串
+}
+
+// Response
+// @Description: 完整响应
+type Response struct {
+	*ResponseData             //解析后的响应数据，响应无法解析时为nil
+	StatusCode    int         //响应状态码
+	Header        http.Header //响应头
+	Body          []byte      //原始响应体，开启报文加密时为解密后的内容
+}
+
+// NewClient
+//
+//	@Description: 使用选项创建客户端，不读取配置文件与环境变量，所有选项在创建时校验一次
+//	@Author zzh 2026-10-19 20:50:14
+//	@param opts
+//	@return Client
+//	@return error
+func NewClient(opts ...Option) (Client, error) {
+	c, err := newClient(opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err = c.validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// newClient
+//
+//	@Description: 在默认配置上依次应用选项，不校验凭证，供New与NewClient共用
+//	@Author zzh 2026-10-19 20:51:38
+//	@param opts
+//	@return *sApiClient
+//	@return error
+func newClient(opts ...Option) (*sApiClient, error) {
+	c := &sApiClient{
+		sapiServerUrl: S_API_URL,
+		ClientOptions: &ClientOptions{},
+		signer:        LegacyMD5Signer{},
+		clock:         &clockSkew{},
+		sidecars:      &sidecarTransports{},
+	}
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// validate
+//
+//	@Description: 校验客户端配置
+//	@receiver c
+//	@Author zzh 2026-10-19 20:53:02
+//	@return error
+func (c *sApiClient) validate() error {
+	if c.credentials == nil && (c.appKey == "" || c.appSecret == "") {
+		return errors.New("appKey或者appSecret不能为空")
+	}
+	if err := checkServerUrl(c.sapiServerUrl); err != nil {
+		return errors.New("serverUrl " + err.Error())
+	}
+	if c.encrypt && strings.ToUpper(c.requestMethod) == http.MethodGet {
+		return errors.New("报文加密仅支持POST请求")
+	}
+	return nil
+}
+
+// Call
+//
+//	@Description: 调用服务方法，每次调用使用客户端配置的副本，可被多个goroutine同时调用
+//	@receiver c
+//	@Author zzh 2026-10-19 20:55:20
+//	@param service
+//	@param method
+//	@param body
+//	@return *ResponseData
+//	@return error
+func (c *sApiClient) Call(service, method string, body map[string]interface{}) (*ResponseData, error) {
+	return c.CallContext(context.Background(), service, method, body)
+}
+
+// CallContext
+//
+//	@Description: 调用服务方法，ctx取消或超时时中止请求
+//	@receiver c
+//	@Author zzh 2026-10-19 21:30:44
+//	@param ctx
+//	@param service
+//	@param method
+//	@param body
+//	@return *ResponseData
+//	@return error
+func (c *sApiClient) CallContext(ctx context.Context, service, method string, body map[string]interface{}) (*ResponseData, error) {
+	response, err := c.CallWithOptions(ctx, service, method, body, CallOptions{})
+	if response == nil {
+		return nil, err
+	}
+	return response.ResponseData, err
+}
+
+// CallWithOptions
+//
+//	@Description: 使用单次调用选项调用服务方法，收到响应时即使返回错误response也不为nil
+//	@receiver c
+//	@Author zzh 2026-10-19 21:32:18
+//	@param ctx
+//	@param service
+//	@param method
+//	@param body
+//	@param options
+//	@return *Response
+//	@return error
+func (c *sApiClient) CallWithOptions(ctx context.Context, service, method string, body map[string]interface{}, options CallOptions) (*Response, error) {
+	return c.cloneForCall(service, method, options).do(ctx, body)
+}
+
+// cloneForCall
+//
+//	@Description: 复制客户端配置并应用单次调用选项
+//	@receiver c
+//	@Author zzh 2026-10-20 01:29:12
+//	@param service
+//	@param method
+//	@param options
+//	@return *sApiClient
+func (c *sApiClient) cloneForCall(service, method string, options CallOptions) *sApiClient {
+	clone := c.Clone().SetService(service).SetMethod(method)
+	if options.RequestMethod != "" {
+		clone.SetRequestMethod(options.RequestMethod)
+	}
+	if options.Timeout > 0 {
+		clone.SetTimeOut(options.Timeout)
+	}
+	if options.Nonce != "" {
+		clone.ClientOptions.Nonce = options.Nonce
+	}
+	if len(options.Headers) > 0 {
+		if clone.ClientOptions.Headers == nil {
+			clone.ClientOptions.Headers = make(map[string]string, len(options.Headers))
+		}
+		for key, val := range options.Headers {
+			clone.ClientOptions.Headers[key] = val
+		}
+	}
+	return clone
+}
+
+// Clone
+//
+//	@Description: 复制客户端配置，副本与原客户端共享transport、凭证来源与时间偏差，
+//	之后对副本的设置不影响原客户端，配置热加载也只作用于原客户端
+//	@receiver c
+//	@Author zzh 2026-10-19 20:58:41
+//	@return *sApiClient
+func (c *sApiClient) Clone() *sApiClient {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	clone := &sApiClient{
+		appKey:         c.appKey,
+		appSecret:      c.appSecret,
+		sapiServerUrl:  c.sapiServerUrl,
+		sapiServerIp:   c.sapiServerIp,
+		requestMethod:  c.requestMethod,
+		service:        c.service,
+		method:         c.method,
+		ClientOptions:  &ClientOptions{},
+		transport:      c.transport,
+		signer:         c.signer,
+		bodyDigest:     c.bodyDigest,
+		responseVerify: c.responseVerify,
+		responseKey:    c.responseKey,
+		encrypt:        c.encrypt,
+		encryptKeys:    c.encryptKeys,
+		credentials:    c.credentials,
+		clock:          c.clock,
+		sidecars:       c.sidecars,
+		timeEndpoint:   c.timeEndpoint,
+		logger:         c.logger,
+		debug:          c.debug,
+		compressSize:   c.compressSize,
+		maxDecompress:  c.maxDecompress,
+		maxRequest:     c.maxRequest,
+		maxResponse:    c.maxResponse,
+		sizeLimits:     c.sizeLimits,
+		limiter:        c.limiter,
+		breakers:       c.breakers,
+		profile:        c.profile,
+		cfgPath:        c.cfgPath,
+	}
+	if c.ClientOptions != nil {
+		*clone.ClientOptions = *c.ClientOptions
+		if c.ClientOptions.Headers != nil {
+			clone.ClientOptions.Headers = make(map[string]string, len(c.ClientOptions.Headers))
+			for key, val := range c.ClientOptions.Headers {
+				clone.ClientOptions.Headers[key] = val
+			}
+		}
+	}
+	for service, serverUrl := range c.endpoints {
+		clone.SetEndpoint(service, serverUrl)
+	}
+	return clone
+}
@@ -0,0 +1,247 @@
+package sapiclient
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	//时间偏差平滑系数，新样本所占权重
+	CLOCK_SMOOTHING = 0.2
+)
+
+// ErrClockSkew 服务端因请求时间超出允许范围拒绝了请求
+var ErrClockSkew = errors.New("客户端时间与服务器时间偏差过大")
+
+// clockSkew
+// @Description: 根据服务端时间测量并平滑本地时间偏差
+type clockSkew struct {
+	mu      sync.RWMutex
+	enabled bool          //是否使用测得的偏差修正签名时间
+	offset  time.Duration //服务端时间 - 本地时间
+	samples int           //已采集的样本数
+}
+
+// now
+//
+//	@Description: 返回用于签名的当前时间，开启修正时加上测得的偏差
+//	@receiver s
+//	@Author zzh 2026-10-19 14:05:11
+//	@return time.Time
+func (s *clockSkew) now() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if !s.enabled {
+		return time.Now()
+	}
+	return time.Now().Add(s.offset)
+}
+
+// observe
+//
+//	@Description: 根据服务端时间采集一次偏差样本，以请求往返的中点作为本地对照时间
+//	@receiver s
+//	@Author zzh 2026-10-19 14:08:37
+//	@param serverTime
+//	@param sentAt
+//	@param receivedAt
+func (s *clockSkew) observe(serverTime, sentAt, receivedAt time.Time) {
+	if serverTime.IsZero() || receivedAt.Before(sentAt) {
+		return
+	}
+	sample := serverTime.Sub(sentAt.Add(receivedAt.Sub(sentAt) / 2))
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.samples == 0 {
+		s.offset = sample
+	} else {
+		s.offset += time.Duration(float64(sample-s.offset) * CLOCK_SMOOTHING)
+	}
+	s.samples++
+}
+
+// observeHeader
+//
+//	@Description: 从响应头Date采集偏差样本，Date精度为秒，补偿半秒的截断误差
+//	@receiver s
+//	@Author zzh 2026-10-19 14:11:02
+//	@param header
+//	@param sentAt
+//	@param receivedAt
+func (s *clockSkew) observeHeader(header http.Header, sentAt, receivedAt time.Time) {
+	date := header.Get("Date")
+	if date == "" {
+		return
+	}
+	serverTime, err := http.ParseTime(date)
+	if err != nil {
+		return
+	}
+	s.observe(serverTime.Add(500*time.Millisecond), sentAt, receivedAt)
+}
+
+// Offset
+//
+//	@Description: 返回当前测得的偏差
+//	@receiver s
+//	@Author zzh 2026-10-19 14:12:25
+//	@return time.Duration
+func (s *clockSkew) Offset() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.offset
+}
+
+// clockSkewError
+//
+//	@Description: 检查响应是否为请求时间被拒绝，是则返回ErrClockSkew。code为CODE_TIME_INVALID时总是返回；
+//	其它服务端可能以其它code拒绝请求，请求被拒绝（401、403或401xx）、未开启修正且响应头Date测得的偏差超过DEFAULT_TIME_WINDOW时同样返回
+//	@receiver s
+//	@Author zzh 2026-10-19 14:14:48
+//	@param statusCode
+//	@param responseData
+//	@return error
+func (s *clockSkew) clockSkewError(statusCode int, responseData *ResponseData) error {
+	if responseData == nil {
+		responseData = &ResponseData{}
+	}
+	if responseData.Code == CODE_TIME_INVALID {
+		return &ClockSkewError{Offset: s.Offset(), Msg: responseData.Msg}
+	}
+	rejected := statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden || responseData.Code/100 == CODE_SIGN_MISSING/100
+	if !rejected {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.enabled || s.samples == 0 || (s.offset <= DEFAULT_TIME_WINDOW && s.offset >= -DEFAULT_TIME_WINDOW) {
+		return nil
+	}
+	return &ClockSkewError{Offset: s.offset, Msg: responseData.Msg}
+}
+
+// ClockSkewError
+// @Description: 请求时间被服务端拒绝，可通过errors.Is(err, ErrClockSkew)判断
+type ClockSkewError struct {
+	Offset time.Duration //当前测得的偏差
+	Msg    string        //服务端返回的信息
+}
+
+// Error
+//
+//	@Description: 错误信息
+//	@receiver e
+//	@Author zzh 2026-10-19 14:16:03
+//	@return string
+func (e *ClockSkewError) Error() string {
+	return ErrClockSkew.Error() + "，当前测得偏差" + e.Offset.String() + ": " + e.Msg
+}
+
+// Is
+//
+//	@Description: 支持errors.Is判断ErrClockSkew
+//	@receiver e
+//	@Author zzh 2026-10-19 14:16:59
+//	@param target
+//	@return bool
+func (e *ClockSkewError) Is(target error) bool {
+	return target == ErrClockSkew
+}
+
+// SetClockSkewCorrection
+//
+//	@Description: 开启后使用测得的服务端时间偏差修正签名时间
+//	@receiver c
+//	@Author zzh 2026-10-19 14:18:22
+//	@param enabled
+//	@return *sApiClient
+func (c *sApiClient) SetClockSkewCorrection(enabled bool) *sApiClient {
+	c.clock.mu.Lock()
+	c.clock.enabled = enabled
+	c.clock.mu.Unlock()
+	return c
+}
+
+// SetTimeEndpoint
+//
+//	@Description: 设置服务端时间接口路径，供SyncClock使用
+//	@receiver c
+//	@Author zzh 2026-10-19 14:19:40
+//	@param timeEndpoint 相对sapiServerUrl的路径，如sapi/time/now
+//	@return *sApiClient
+func (c *sApiClient) SetTimeEndpoint(timeEndpoint string) *sApiClient {
+	c.timeEndpoint = timeEndpoint
+	return c
+}
+
+// ClockOffset
+//
+//	@Description: 返回当前测得的服务端时间偏差，正数表示本地时间落后于服务端
+//	@receiver c
+//	@Author zzh 2026-10-19 14:20:58
+//	@return time.Duration
+func (c *sApiClient) ClockOffset() time.Duration {
+	return c.clock.Offset()
+}
+
+// SyncClock
+//
+//	@Description: 请求时间接口采集一次偏差样本，未设置时间接口时请求服务地址根路径，
+//	优先使用响应data中的时间戳（秒或毫秒），否则使用响应头Date。服务地址与普通请求相同，
+//	已设置service时使用其endpoint，并使用指定的服务ip
+//	@receiver c
+//	@Author zzh 2026-10-19 14:24:13
+//	@return time.Duration 同步后的偏差
+//	@return error
+func (c *sApiClient) SyncClock() (time.Duration, error) {
+	c.mu.RLock()
+	serverUrl, sidecar := c.serverUrlFor(c.service)
+	client := c.newRestyClient(sidecar, c.maxResponse, false)
+	urlReq := strings.TrimRight(serverUrl, "/") + "/" + strings.TrimLeft(c.timeEndpoint, "/")
+	c.mu.RUnlock()
+	sentAt := time.Now()
+	res, err := client.R().Get(urlReq)
+	receivedAt := time.Now()
+	if err != nil {
+		return c.clock.Offset(), errors.New("时间同步失败: " + err.Error())
+	}
+	responseData := &ResponseData{}
+	if json.Unmarshal(res.Body(), responseData) == nil {
+		if unix, ok := unixOf(responseData.Data); ok {
+			if unix > 1e12 {
+				c.clock.observe(time.UnixMilli(unix), sentAt, receivedAt)
+			} else {
+				c.clock.observe(time.Unix(unix, 0).Add(500*time.Millisecond), sentAt, receivedAt)
+			}
+			return c.clock.Offset(), nil
+		}
+	}
+	if res.Header().Get("Date") == "" {
+		return c.clock.Offset(), errors.New("时间同步失败: 响应中没有服务端时间")
+	}
+	c.clock.observeHeader(res.Header(), sentAt, receivedAt)
+	return c.clock.Offset(), nil
+}
+
+// unixOf
+//
+//	@Description: 解析响应data中的时间戳
+//	@Author zzh 2026-10-19 14:26:30
+//	@param data
+//	@return int64
+//	@return bool
+func unixOf(data interface{}) (int64, bool) {
+	switch val := data.(type) {
+	case float64:
+		return int64(val), val > 0
+	case string:
+		unix, err := strconv.ParseInt(val, 10, 64)
+		return unix, err == nil && unix > 0
+	}
+	return 0, false
+}
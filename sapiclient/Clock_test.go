@@ -0,0 +1,106 @@
+package sapiclient
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestClockSkewFromDateHeader(t *testing.T) {
+	skew := time.Hour
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", time.Now().Add(skew).UTC().Format(http.TimeFormat))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"code":40102,"msg":"signature expired"}`))
+	}))
+	defer server.Close()
+	c, err := newClient(WithCredentials("key", "secret"), WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.Call("demo", "echo", nil)
+	var skewErr *ClockSkewError
+	if !errors.As(err, &skewErr) || !errors.Is(err, ErrClockSkew) {
+		t.Fatalf("err = %v, want ClockSkewError", err)
+	}
+	if skewErr.Offset < 59*time.Minute {
+		t.Fatalf("offset = %s, want about 1h", skewErr.Offset)
+	}
+
+	skew = 0
+	c, err = newClient(WithCredentials("key", "secret"), WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.Call("demo", "echo", nil); err == nil || errors.Is(err, ErrClockSkew) {
+		t.Fatalf("err without skew = %v", err)
+	}
+}
+
+func TestSyncClockUsesEndpoint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/demo/sapi/time" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		unix := time.Now().Add(time.Hour).Unix()
+		_, _ = w.Write([]byte(`{"code":200,"msg":"success","data":` + strconv.FormatInt(unix, 10) + `}`))
+	}))
+	defer server.Close()
+	c, err := newClient(WithCredentials("key", "secret"), WithBaseURL("http://127.0.0.1:1/"), WithEndpoint("demo", server.URL+"/demo/"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetService("demo").SetTimeEndpoint("sapi/time")
+	offset, err := c.SyncClock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if offset < 59*time.Minute || offset > 61*time.Minute {
+		t.Fatalf("offset = %s, want about 1h", offset)
+	}
+}
+
+func TestClockIgnoresReplayedDate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":200,"msg":"success"}`))
+	}))
+	defer server.Close()
+	cassettePath := filepath.Join(t.TempDir(), "clock.json")
+	recorder, err := NewRecorder(cassettePath, CASSETTE_RECORD_ONCE, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := newClient(WithCredentials("key", "secret"), WithBaseURL(server.URL), WithTransport(recorder))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.Call("demo", "echo", nil); err != nil {
+		t.Fatal(err)
+	}
+	if c.ClockOffset() < 59*time.Minute {
+		t.Fatalf("recorded offset = %s, want about 1h", c.ClockOffset())
+	}
+
+	recorder, err = NewRecorder(cassettePath, CASSETTE_REPLAY_ONLY, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err = newClient(WithCredentials("key", "secret"), WithBaseURL(server.URL), WithTransport(recorder))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.Call("demo", "echo", nil); err != nil {
+		t.Fatal(err)
+	}
+	if offset := c.ClockOffset(); offset != 0 {
+		t.Fatalf("replayed offset = %s, want 0", offset)
+	}
+}
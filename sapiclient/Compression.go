@@ -0,0 +1,264 @@
+package sapiclient
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	//请求体与响应体编码请求头
+	CONTENT_ENCODING_HEADER = "Content-Encoding"
+	//gzip编码
+	ENCODING_GZIP = "gzip"
+	//deflate编码，兼容zlib格式与原始deflate格式
+	ENCODING_DEFLATE = "deflate"
+	//默认的解压后大小上限 64MB，只用于读取整个响应体的调用与服务端解压请求体，流式调用与下载使用单次调用的响应体大小上限
+	DEFAULT_MAX_DECOMPRESSED_SIZE = 64 << 20
+)
+
+// ErrDecompressionLimit 解压后的内容超过大小限制，可通过errors.Is(err, ErrDecompressionLimit)判断
+var ErrDecompressionLimit = errors.New("解压后的内容超过大小限制")
+
+// DecompressionLimitError
+// @Description: 解压后的内容超过大小限制，用于防止解压炸弹
+type DecompressionLimitError struct {
+	Encoding string //内容编码
+	Limit    int64  //解压后大小上限 字节
+}
+
+// Error
+//
+//	@Description: 错误信息
+//	@receiver e
+//	@Author zzh 2026-10-19 23:55:10
+//	@return string
+func (e *DecompressionLimitError) Error() string {
+	return ErrDecompressionLimit.Error() + ": " + e.Encoding + "内容超过" + strconv.FormatInt(e.Limit, 10) + "字节"
+}
+
+// Is
+//
+//	@Description: 支持errors.Is(err, ErrDecompressionLimit)
+//	@receiver e
+//	@Author zzh 2026-10-19 23:55:42
+//	@param target
+//	@return bool
+func (e *DecompressionLimitError) Is(target error) bool {
+	return target == ErrDecompressionLimit
+}
+
+// CompressionConfig
+// @Description: 压缩配置
+type CompressionConfig struct {
+	RequestThreshold    int   //请求体超过该字节数时gzip压缩，0表示不压缩
+	MaxDecompressedSize int64 //响应解压后的大小上限 字节，0表示使用默认值
+}
+
+// compressBody
+//
+//	@Description: gzip压缩请求体，压缩后没有变小时返回false
+//	@Author zzh 2026-10-19 23:57:20
+//	@param body
+//	@return []byte
+//	@return bool
+func compressBody(body []byte) ([]byte, bool) {
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	if _, err := writer.Write(body); err != nil {
+		return body, false
+	}
+	if err := writer.Close(); err != nil || buffer.Len() >= len(body) {
+		return body, false
+	}
+	return buffer.Bytes(), true
+}
+
+// decodingReader
+// @Description: 解压内容并限制解压后的大小
+type decodingReader struct {
+	reader   io.Reader
+	body     io.Closer //原始内容
+	encoding string
+	limit    int64
+	read     int64
+}
+
+// newDecodingReader
+//
+//	@Description: 按内容编码创建解压reader
+//	@Author zzh 2026-10-19 23:59:05
+//	@param body
+//	@param encoding gzip或deflate
+//	@param limit 解压后大小上限，小于等于0表示不限制
+//	@return io.ReadCloser
+//	@return error
+func newDecodingReader(body io.ReadCloser, encoding string, limit int64) (io.ReadCloser, error) {
+	r := &decodingReader{body: body, encoding: encoding, limit: limit}
+	switch encoding {
+	case ENCODING_GZIP:
+		reader, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, errors.New("gzip内容解压失败: " + err.Error())
+		}
+		r.reader = reader
+	case ENCODING_DEFLATE:
+		buffered := bufio.NewReader(body)
+		header, _ := buffered.Peek(2)
+		if len(header) == 2 && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+			reader, err := zlib.NewReader(buffered)
+			if err != nil {
+				return nil, errors.New("deflate内容解压失败: " + err.Error())
+			}
+			r.reader = reader
+		} else {
+			r.reader = flate.NewReader(buffered)
+		}
+	default:
+		return nil, errors.New("不支持的内容编码: " + encoding)
+	}
+	return r, nil
+}
+
+// Read
+//
+//	@Description: 读取解压后的内容，超过大小限制时返回DecompressionLimitError
+//	@receiver r
+//	@Author zzh 2026-10-20 00:01:12
+//	@param p
+//	@return int
+//	@return error
+func (r *decodingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.read += int64(n)
+	if r.limit > 0 && r.read > r.limit {
+		return 0, &DecompressionLimitError{Encoding: r.encoding, Limit: r.limit}
+	}
+	return n, err
+}
+
+// Close
+//
+//	@Description: 关闭原始内容
+//	@receiver r
+//	@Author zzh 2026-10-20 00:01:50
+//	@return error
+func (r *decodingReader) Close() error {
+	return r.body.Close()
+}
+
+// decompressTransport
+// @Description: 声明支持gzip与deflate并解压响应，替代标准库与resty不限大小的自动解压，并限制响应体大小
+type decompressTransport struct {
+	next    http.RoundTripper
+	maxSize int64 //解压后大小上限，0表示不限制
+	maxBody int64 //响应体大小上限，0表示不限制
+}
+
+// RoundTrip
+//
+//	@Description: 发送请求并解压响应，请求已设置Accept-Encoding时同样解压响应；响应体超过大小限制时返回SizeLimitError
+//	@receiver t
+//	@Author zzh 2026-10-20 00:03:30
+//	@param req
+//	@return *http.Response
+//	@return error
+func (t *decompressTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Accept-Encoding") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("Accept-Encoding", ENCODING_GZIP+", "+ENCODING_DEFLATE)
+	}
+	res, err := t.next.RoundTrip(req)
+	if err != nil || res.ContentLength == 0 || req.Method == http.MethodHead {
+		return res, err
+	}
+	encoding := strings.ToLower(strings.TrimSpace(res.Header.Get(CONTENT_ENCODING_HEADER)))
+	if encoding == ENCODING_GZIP || encoding == ENCODING_DEFLATE {
+		body, err := newDecodingReader(res.Body, encoding, t.maxSize)
+		if err != nil {
+			_ = res.Body.Close()
+			return nil, err
+		}
+		res.Body = body
+		res.Header.Del(CONTENT_ENCODING_HEADER)
+		res.Header.Del("Content-Length")
+		res.ContentLength = -1
+		res.Uncompressed = true
+	}
+	if t.maxBody > 0 {
+		// 未压缩的响应按Content-Length提前拒绝，不读取响应体
+		if res.ContentLength > t.maxBody {
+			_ = res.Body.Close()
+			return nil, &SizeLimitError{Kind: SIZE_LIMIT_RESPONSE, Size: res.ContentLength, Limit: t.maxBody}
+		}
+		res.Body = &limitedBody{ReadCloser: res.Body, limit: t.maxBody}
+	}
+	return res, nil
+}
+
+// DecodeRequestBody
+//
+//	@Description: 服务端按Content-Encoding解压请求体，解压后移除Content-Encoding，
+//	读取超过maxSize时返回DecompressionLimitError。签名针对压缩后的内容，需要在签名校验之后调用
+//	@Author zzh 2026-10-20 00:08:16
+//	@param r
+//	@param maxSize 解压后大小上限，小于等于0时使用DEFAULT_MAX_DECOMPRESSED_SIZE
+//	@return error
+func DecodeRequestBody(r *http.Request, maxSize int64) error {
+	encoding := strings.ToLower(strings.TrimSpace(r.Header.Get(CONTENT_ENCODING_HEADER)))
+	if encoding == "" || encoding == "identity" || r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+	if maxSize <= 0 {
+		maxSize = DEFAULT_MAX_DECOMPRESSED_SIZE
+	}
+	body, err := newDecodingReader(r.Body, encoding, maxSize)
+	if err != nil {
+		return err
+	}
+	r.Body = body
+	r.Header.Del(CONTENT_ENCODING_HEADER)
+	r.Header.Del("Content-Length")
+	r.ContentLength = -1
+	return nil
+}
+
+// WithCompression
+//
+//	@Description: 请求体超过threshold字节时使用gzip压缩，Content-Encoding纳入签名，需要使用hmac-sha256签名
+//	@Author zzh 2026-10-20 00:05:12
+//	@param threshold
+//	@return Option
+func WithCompression(threshold int) Option {
+	return func(c *sApiClient) error {
+		if threshold <= 0 {
+			return errors.New("压缩阈值应大于0")
+		}
+		c.compressSize = threshold
+		return nil
+	}
+}
+
+// WithMaxDecompressedSize
+//
+//	@Description: 设置响应解压后的大小上限，对所有调用生效；未设置时读取整个响应体的调用使用DEFAULT_MAX_DECOMPRESSED_SIZE，
+//	流式调用与下载只受响应体大小上限限制
+//	@Author zzh 2026-10-20 00:05:50
+//	@param maxSize 字节
+//	@return Option
+func WithMaxDecompressedSize(maxSize int64) Option {
+	return func(c *sApiClient) error {
+		if maxSize <= 0 {
+			return errors.New("解压后大小上限应大于0")
+		}
+		c.maxDecompress = maxSize
+		return nil
+	}
+}
@@ -0,0 +1,92 @@
+package sapiclient
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCompressionHeaderNotReused(t *testing.T) {
+	encodings := make([]string, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encodings = append(encodings, r.Header.Get(CONTENT_ENCODING_HEADER))
+		if err := DecodeRequestBody(r, 0); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		if !json.Valid(body) {
+			http.Error(w, "invalid body", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":200,"msg":"success"}`))
+	}))
+	defer server.Close()
+	c, err := newClient(WithCredentials("key", "secret"), WithBaseURL(server.URL), WithSigner(HMACSHA256Signer{}), WithCompression(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetService("demo").SetMethod("echo")
+	if _, err = c.DoRequest(map[string]interface{}{"data": strings.Repeat("a", 1000)}); err != nil {
+		t.Fatalf("large request: %v", err)
+	}
+	if _, err = c.DoRequest(map[string]interface{}{"data": "a"}); err != nil {
+		t.Fatalf("small request: %v", err)
+	}
+	if len(encodings) != 2 || encodings[0] != ENCODING_GZIP || encodings[1] != "" {
+		t.Fatalf("Content-Encoding = %q, want [gzip \"\"]", encodings)
+	}
+	if _, ok := c.ClientOptions.Headers[CONTENT_ENCODING_HEADER]; ok {
+		t.Fatal("request headers written back to client options")
+	}
+}
+
+func TestDefaultDecompressionLimitOnlyForBufferedCalls(t *testing.T) {
+	var compressed bytes.Buffer
+	writer, _ := gzip.NewWriterLevel(&compressed, gzip.BestSpeed)
+	size := int64(DEFAULT_MAX_DECOMPRESSED_SIZE + 1<<20)
+	if _, err := io.CopyN(writer, zeroReader{}, size); err != nil {
+		t.Fatal(err)
+	}
+	_ = writer.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(CONTENT_ENCODING_HEADER, ENCODING_GZIP)
+		_, _ = w.Write(compressed.Bytes())
+	}))
+	defer server.Close()
+	c, err := newClient(WithCredentials("key", "secret"), WithBaseURL(server.URL), WithSigner(HMACSHA256Signer{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.Call("report", "export", nil); !errors.Is(err, ErrDecompressionLimit) {
+		t.Fatalf("buffered call err = %v, want ErrDecompressionLimit", err)
+	}
+	counter := &countingWriter{}
+	if _, err = c.Download(context.Background(), "report", "export", nil, counter, DownloadOptions{Retries: -1}); err != nil || counter.n != size {
+		t.Fatalf("download = %d bytes, %v", counter.n, err)
+	}
+	// 流式调用使用该服务方法的响应体大小上限
+	c.setSizeLimits("report", "export", SizeLimits{MaxResponseSize: 1 << 20})
+	if _, err = c.Download(context.Background(), "report", "export", nil, &countingWriter{}, DownloadOptions{Retries: -1}); !errors.Is(err, ErrSizeLimit) {
+		t.Fatalf("limited download err = %v, want ErrSizeLimit", err)
+	}
+}
+
+// zeroReader 无限返回0字节
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
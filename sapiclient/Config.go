@@ -0,0 +1,693 @@
+package sapiclient
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	//未指定默认profile时使用的profile名称，对应sapi配置块本身
+	DEFAULT_PROFILE = "default"
+)
+
+// ProfileConfig
+// @Description: 单个profile的客户端配置
+type ProfileConfig struct {
+	Name                string
+	AppKey              string
+	AppSecret           string
+	ServerUrl           string
+	ServerIp            string
+	RequestMethod       string
+	Timeout             int
+	RetryCount          int
+	RetryWaitTime       int
+	Headers             map[string]string
+	Endpoints           map[string]string //service -> 服务地址
+	SignVersion         string
+	SignedHeaders       []string
+	BodyDigest          bool
+	ResponseVerify      bool
+	ResponseKey         string
+	Encrypt             bool
+	EncryptKeys         *EncryptKeys
+	ClockSkewCorrection bool
+	TimeEndpoint        string
+	Transport           TransportConfig
+	Proxy               ProxyConfig
+	TLS                 TLSConfig
+	Compression         CompressionConfig
+	Limits              LimitsConfig
+	RateLimit           RateLimitConfig
+	Breaker             BreakerConfig
+	Log                 LogConfig
+}
+
+// ConfigIssue
+// @Description: 单个配置错误
+type ConfigIssue struct {
+	Source string //配置来源，如config.toml: sapi.profiles.billing.timeout 或 环境变量 SAPI_TIMEOUT
+	Msg    string
+}
+
+// ConfigError
+// @Description: 配置校验失败，包含发现的全部错误
+type ConfigError struct {
+	Issues []ConfigIssue
+}
+
+// Error
+//
+//	@Description: 错误信息
+//	@receiver e
+//	@Author zzh 2026-10-19 19:20:03
+//	@return string
+func (e *ConfigError) Error() string {
+	messages := make([]string, 0, len(e.Issues))
+	for _, issue := range e.Issues {
+		messages = append(messages, issue.Source+": "+issue.Msg)
+	}
+	return "配置校验失败: " + strings.Join(messages, "; ")
+}
+
+// configFile
+// @Description: 已读取的配置文件
+type configFile struct {
+	path        string //文件路径，未找到配置文件时为空
+	viperObject *viper.Viper
+}
+
+// configValue
+// @Description: 已转换的配置值及其来源
+type configValue struct {
+	value  interface{}
+	source string
+}
+
+// loadConfig
+//
+//	@Description: 读取配置文件，支持viper支持的所有格式，未找到默认配置文件时返回空配置
+//	@Author zzh 2026-10-19 18:02:14
+//	@param cfgPath
+//	@return *configFile
+//	@return error
+func loadConfig(cfgPath ...string) (*configFile, error) {
+	file := &configFile{viperObject: viper.New()}
+	filePath, err := ResolveConfigPath(cfgPath...)
+	if err != nil {
+		return nil, err
+	}
+	if filePath == "" {
+		return file, nil
+	}
+	file.path = filePath
+	file.viperObject.SetConfigFile(filePath)
+	if err = file.viperObject.ReadInConfig(); err != nil {
+		return nil, errors.New("配置文件读取失败: " + err.Error())
+	}
+	return file, nil
+}
+
+// profiles
+//
+//	@Description: 返回sapi.profiles配置块
+//	@receiver f
+//	@Author zzh 2026-10-19 19:22:45
+//	@return map[string]interface{}
+func (f *configFile) profiles() map[string]interface{} {
+	return f.viperObject.GetStringMap("sapi.profiles")
+}
+
+// profileNames
+//
+//	@Description: 返回配置中的所有profile名称，包含默认profile
+//	@receiver f
+//	@Author zzh 2026-10-19 18:04:40
+//	@return []string
+func (f *configFile) profileNames() []string {
+	names := []string{DEFAULT_PROFILE}
+	for name := range f.profiles() {
+		if name != DEFAULT_PROFILE {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// defaultProfileName
+//
+//	@Description: 返回默认profile：环境变量SAPI_PROFILE > sapi.defaultProfile > default
+//	@receiver f
+//	@Author zzh 2026-10-19 18:05:52
+//	@return string
+func (f *configFile) defaultProfileName() string {
+	if name := os.Getenv(ENV_PROFILE); name != "" {
+		return strings.ToLower(name)
+	}
+	if name := f.viperObject.GetString("sapi.defaultProfile"); name != "" {
+		return strings.ToLower(name)
+	}
+	return DEFAULT_PROFILE
+}
+
+// profileChain
+//
+//	@Description: 按extends解析profile继承链，返回从最上层到name本身的顺序
+//	@receiver f
+//	@Author zzh 2026-10-19 18:09:33
+//	@param name
+//	@return []string
+//	@return error
+func (f *configFile) profileChain(name string) ([]string, error) {
+	profiles := f.profiles()
+	chain := make([]string, 0)
+	visited := make(map[string]bool)
+	for name != "" {
+		if visited[name] {
+			return nil, errors.New("profile继承存在循环引用: " + name)
+		}
+		visited[name] = true
+		own, ok := profiles[name].(map[string]interface{})
+		if !ok {
+			if name != DEFAULT_PROFILE {
+				return nil, errors.New("配置profile不存在: " + name + "，可用的profile: " + strings.Join(f.profileNames(), ", "))
+			}
+			chain = append(chain, name)
+			break
+		}
+		chain = append(chain, name)
+		parent, _ := own["extends"].(string)
+		name = strings.ToLower(parent)
+	}
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	return chain, nil
+}
+
+// collect
+//
+//	@Description: 收集一层配置：先读取配置文件中的值，再用环境变量覆盖
+//	@receiver f
+//	@Author zzh 2026-10-19 19:26:14
+//	@param settings 配置块内容
+//	@param keyPrefix 配置块在文件中的路径，如sapi.profiles.billing.
+//	@param envPrefix 配置块对应的环境变量前缀，如SAPI_PROFILES_BILLING_
+//	@param values
+//	@param issues
+func (f *configFile) collect(settings map[string]interface{}, keyPrefix, envPrefix string, values map[string]configValue, issues *[]ConfigIssue) {
+	f.collectSettings(settings, "", keyPrefix, values, issues)
+	for i := range configFields {
+		field := &configFields[i]
+		name := envPrefix + envName(field.key)
+		if val, ok := os.LookupEnv(name); ok {
+			setConfigValue(field, val, "环境变量 "+name, values, issues)
+		}
+	}
+}
+
+// collectSettings
+//
+//	@Description: 按配置项定义展开配置文件中的配置块，未定义的key记为未知配置
+//	@receiver f
+//	@Author zzh 2026-10-19 19:29:40
+//	@param settings
+//	@param prefix 当前分组，如transport.
+//	@param keyPrefix
+//	@param values
+//	@param issues
+func (f *configFile) collectSettings(settings map[string]interface{}, prefix, keyPrefix string, values map[string]configValue, issues *[]ConfigIssue) {
+	for key, val := range settings {
+		key = prefix + strings.ToLower(key)
+		source := f.path + ": " + keyPrefix + key
+		if field, ok := configFieldIndex[key]; ok {
+			setConfigValue(field, val, source, values, issues)
+			continue
+		}
+		if !isConfigSection(key) {
+			*issues = append(*issues, ConfigIssue{Source: source, Msg: "未知的配置项"})
+			continue
+		}
+		section, ok := val.(map[string]interface{})
+		if !ok {
+			*issues = append(*issues, ConfigIssue{Source: source, Msg: "应为配置分组"})
+			continue
+		}
+		f.collectSettings(section, key+".", keyPrefix, values, issues)
+	}
+}
+
+// setConfigValue
+//
+//	@Description: 转换配置值并覆盖之前的值，键值表类型与之前的值合并
+//	@Author zzh 2026-10-19 19:32:18
+//	@param field
+//	@param val
+//	@param source
+//	@param values
+//	@param issues
+func setConfigValue(field *configField, val interface{}, source string, values map[string]configValue, issues *[]ConfigIssue) {
+	converted, err := convertConfigValue(field.kind, val)
+	if err != nil {
+		*issues = append(*issues, ConfigIssue{Source: source, Msg: err.Error()})
+		return
+	}
+	key := strings.ToLower(field.key)
+	if previous, ok := values[key]; ok && field.kind == configStringMap {
+		merged := make(map[string]string)
+		for k, v := range previous.value.(map[string]string) {
+			merged[k] = v
+		}
+		for k, v := range converted.(map[string]string) {
+			merged[k] = v
+		}
+		converted = merged
+	}
+	values[key] = configValue{value: converted, source: source}
+}
+
+// LoadProfile
+//
+//	@Description: 读取指定profile的配置，name为空时使用默认profile
+//	@Author zzh 2026-10-19 18:14:27
+//	@param name
+//	@param cfgPath
+//	@return *ProfileConfig
+//	@return error
+func LoadProfile(name string, cfgPath ...string) (*ProfileConfig, error) {
+	file, err := loadConfig(cfgPath...)
+	if err != nil {
+		return nil, err
+	}
+	return file.resolveProfile(name)
+}
+
+// ValidateConfig
+//
+//	@Description: 校验配置文件中的所有profile，返回*ConfigError包含全部错误
+//	@Author zzh 2026-10-19 19:35:02
+//	@param cfgPath
+//	@return error
+func ValidateConfig(cfgPath ...string) error {
+	file, err := loadConfig(cfgPath...)
+	if err != nil {
+		return err
+	}
+	configErr := &ConfigError{}
+	// 共享配置的错误会在每个profile中重复出现，只保留一次
+	seen := make(map[ConfigIssue]bool)
+	for _, name := range file.profileNames() {
+		_, err = file.resolveProfile(name)
+		if profileErr, ok := err.(*ConfigError); ok {
+			for _, issue := range profileErr.Issues {
+				if !seen[issue] {
+					seen[issue] = true
+					configErr.Issues = append(configErr.Issues, issue)
+				}
+			}
+		} else if err != nil {
+			configErr.Issues = append(configErr.Issues, ConfigIssue{Source: file.path + ": sapi.profiles." + name, Msg: err.Error()})
+		}
+	}
+	if len(configErr.Issues) > 0 {
+		return configErr
+	}
+	return nil
+}
+
+// resolveProfile
+//
+//	@Description: 合并profile配置并校验，优先级从低到高：sapi配置块 < extends指定的profile < profile自身，
+//	每一层的环境变量覆盖该层配置文件中的值
+//	@receiver f
+//	@Author zzh 2026-10-19 18:16:02
+//	@param name
+//	@return *ProfileConfig
+//	@return error
+func (f *configFile) resolveProfile(name string) (*ProfileConfig, error) {
+	name = strings.ToLower(name)
+	if name == "" {
+		name = f.defaultProfileName()
+	}
+	chain, err := f.profileChain(name)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]configValue)
+	issues := make([]ConfigIssue, 0)
+	// GetStringMap返回的是viper内部的map，需要先复制再剔除profile相关配置
+	base := make(map[string]interface{})
+	mergeSettings(base, f.viperObject.GetStringMap("sapi"))
+	delete(base, "profiles")
+	delete(base, "defaultprofile")
+	f.collect(base, "sapi.", ENV_PREFIX, values, &issues)
+	profiles := f.profiles()
+	for _, profileName := range chain {
+		own := make(map[string]interface{})
+		if settings, ok := profiles[profileName].(map[string]interface{}); ok {
+			mergeSettings(own, settings)
+		}
+		delete(own, "extends")
+		f.collect(own, "sapi.profiles."+profileName+".", ENV_PREFIX+"PROFILES_"+envName(profileName)+"_", values, &issues)
+	}
+	profile := &ProfileConfig{Name: name}
+	for i := range configFields {
+		field := &configFields[i]
+		val, ok := values[strings.ToLower(field.key)]
+		if !ok {
+			continue
+		}
+		if err = field.apply(profile, val.value); err != nil {
+			issues = append(issues, ConfigIssue{Source: val.source, Msg: err.Error()})
+		}
+	}
+	if key, err := profile.TLS.check(); err != nil {
+		issues = append(issues, ConfigIssue{Source: values[strings.ToLower("tls."+key)].source, Msg: err.Error()})
+	}
+	if len(issues) > 0 {
+		sort.Slice(issues, func(i, j int) bool {
+			return issues[i].Source < issues[j].Source
+		})
+		return nil, &ConfigError{Issues: issues}
+	}
+	if profile.ServerUrl == "" {
+		profile.ServerUrl = S_API_URL
+	}
+	return profile, nil
+}
+
+// mergeSettings
+//
+//	@Description: 将src深度复制合并到dst
+//	@Author zzh 2026-10-19 18:11:05
+//	@param dst
+//	@param src
+func mergeSettings(dst, src map[string]interface{}) {
+	for key, val := range src {
+		key = strings.ToLower(key)
+		srcMap, srcIsMap := val.(map[string]interface{})
+		dstMap, dstIsMap := dst[key].(map[string]interface{})
+		if srcIsMap && dstIsMap {
+			mergeSettings(dstMap, srcMap)
+			continue
+		}
+		if srcIsMap {
+			copied := make(map[string]interface{}, len(srcMap))
+			mergeSettings(copied, srcMap)
+			val = copied
+		}
+		dst[key] = val
+	}
+}
+
+// NewClient
+//
+//	@Description: 按profile配置创建客户端
+//	@receiver p
+//	@Author zzh 2026-10-19 18:18:45
+//	@return *sApiClient
+//	@return error
+func (p *ProfileConfig) NewClient() (*sApiClient, error) {
+	return newClient(WithProfile(p))
+}
+
+// applyProfile
+//
+//	@Description: 应用profile配置，只修改与当前生效配置不同的部分，代码中单独设置且配置未变化的项保持不变
+//	@receiver c
+//	@Author zzh 2026-10-19 19:52:30
+//	@param p
+//	@return []string 发生变化的配置项
+func (c *sApiClient) applyProfile(p *ProfileConfig) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	old := c.profile
+	if old == nil {
+		old = &ProfileConfig{}
+	}
+	changes := make([]string, 0)
+	changed := func(key string, oldVal, newVal interface{}) bool {
+		if reflect.DeepEqual(oldVal, newVal) {
+			return false
+		}
+		changes = append(changes, key)
+		return true
+	}
+	if changed("appKey", old.AppKey, p.AppKey) {
+		c.appKey = p.AppKey
+	}
+	if changed("appSecret", old.AppSecret, p.AppSecret) {
+		c.appSecret = p.AppSecret
+	}
+	if changed("serverUrl", old.ServerUrl, p.ServerUrl) {
+		c.sapiServerUrl = p.ServerUrl
+	}
+	if changed("serverIp", old.ServerIp, p.ServerIp) {
+		c.sapiServerIp = p.ServerIp
+	}
+	if changed("requestMethod", old.RequestMethod, p.RequestMethod) {
+		c.requestMethod = p.RequestMethod
+	}
+	if changed("timeout", old.Timeout, p.Timeout) {
+		c.ClientOptions.Timeout = p.Timeout
+	}
+	if changed("retryCount", old.RetryCount, p.RetryCount) {
+		c.ClientOptions.RetryCount = p.RetryCount
+	}
+	if changed("retryWaitTime", old.RetryWaitTime, p.RetryWaitTime) {
+		c.ClientOptions.RetryWaitTime = p.RetryWaitTime
+	}
+	if changed("headers", old.Headers, p.Headers) {
+		headers := make(map[string]string, len(p.Headers))
+		for key, val := range p.Headers {
+			headers[key] = val
+		}
+		c.ClientOptions.Headers = headers
+	}
+	if changed("endpoints", old.Endpoints, p.Endpoints) {
+		c.endpoints = nil
+		for service, serverUrl := range p.Endpoints {
+			c.SetEndpoint(service, serverUrl)
+		}
+	}
+	signChanged := changed("signVersion", old.SignVersion, p.SignVersion)
+	if changed("signedHeaders", old.SignedHeaders, p.SignedHeaders) || signChanged {
+		c.signer = LegacyMD5Signer{}
+		if p.SignVersion == SIGN_VERSION_HMAC_SHA256 {
+			c.signer = HMACSHA256Signer{SignedHeaders: p.SignedHeaders}
+		}
+	}
+	if changed("bodyDigest", old.BodyDigest, p.BodyDigest) {
+		c.bodyDigest = p.BodyDigest
+	}
+	if changed("responseVerify", old.ResponseVerify, p.ResponseVerify) {
+		c.responseVerify = p.ResponseVerify
+	}
+	if changed("responseKey", old.ResponseKey, p.ResponseKey) {
+		c.responseKey = p.ResponseKey
+	}
+	if changed("encrypt.enabled", old.Encrypt, p.Encrypt) {
+		c.encrypt = p.Encrypt
+	}
+	if changed("encrypt.keys", old.EncryptKeys, p.EncryptKeys) {
+		c.encryptKeys = p.EncryptKeys
+	}
+	if changed("clockSkewCorrection", old.ClockSkewCorrection, p.ClockSkewCorrection) {
+		c.SetClockSkewCorrection(p.ClockSkewCorrection)
+	}
+	if changed("timeEndpoint", old.TimeEndpoint, p.TimeEndpoint) {
+		c.timeEndpoint = p.TimeEndpoint
+	}
+	transportChanged := changed("transport", old.Transport, p.Transport)
+	tlsChanged := changed("tls", old.TLS, p.TLS)
+	if changed("proxy", old.Proxy, p.Proxy) || transportChanged || tlsChanged {
+		c.transport = p.roundTripper()
+	}
+	if changed("compression", old.Compression, p.Compression) {
+		c.compressSize = p.Compression.RequestThreshold
+		c.maxDecompress = p.Compression.MaxDecompressedSize
+	}
+	if changed("limits", old.Limits, p.Limits) {
+		c.maxRequest = p.Limits.MaxRequestSize
+		c.maxResponse = p.Limits.MaxResponseSize
+		c.sizeLimits = nil
+		for key, limits := range p.Limits.Methods {
+			service, method, _ := splitMethodKey(key)
+			c.setSizeLimits(service, method, limits)
+		}
+	}
+	if changed("rateLimit", old.RateLimit, p.RateLimit) {
+		c.limiter = newRateLimiter(p.RateLimit)
+	}
+	if changed("breaker", old.Breaker, p.Breaker) {
+		c.breakers = newCircuitBreakers(p.Breaker)
+	}
+	if changed("log", old.Log, p.Log) {
+		c.debug = p.Log.Debug
+		c.logger = nil
+		if p.Log.Enabled || p.Log.Debug {
+			c.logger = NewStdLogger(p.Log.Output)
+		}
+	}
+	c.profile = p
+	return changes
+}
+
+// roundTripper
+//
+//	@Description: 按连接、TLS与代理配置创建transport，均未配置时返回nil使用resty默认transport
+//	@receiver p
+//	@Author zzh 2026-10-19 22:33:40
+//	@return http.RoundTripper
+func (p *ProfileConfig) roundTripper() http.RoundTripper {
+	base := p.Transport.newTransport()
+	if p.TLS.enabled() {
+		tlsConfig, err := p.TLS.newTLSConfig()
+		if err != nil {
+			// 证书文件在读取配置后发生变化，不能在缺少TLS配置的情况下发送请求
+			return errorTransport{err: err}
+		}
+		if base == nil {
+			base = http.DefaultTransport.(*http.Transport).Clone()
+		}
+		base.TLSClientConfig = tlsConfig
+	}
+	if p.Proxy.enabled() {
+		transport, err := NewProxyTransport(p.Proxy, base)
+		if err != nil {
+			// 不能在代理配置无效时绕过代理直连
+			return errorTransport{err: err}
+		}
+		return transport
+	}
+	if base == nil {
+		return nil
+	}
+	return base
+}
+
+// NewProfile
+//
+//	@Description: 使用配置文件中[sapi.profiles.<name>]创建客户端，name为空时使用默认profile
+//	@Author zzh 2026-10-19 18:20:31
+//	@param name
+//	@param cfgPath
+//	@return *sApiClient
+//	@return error
+func NewProfile(name string, cfgPath ...string) (*sApiClient, error) {
+	file, err := loadConfig(cfgPath...)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := file.resolveProfile(name)
+	if err != nil {
+		return nil, err
+	}
+	c, err := profile.NewClient()
+	if err != nil {
+		return nil, err
+	}
+	c.cfgPath = cfgPath
+	return c, nil
+}
+
+// Registry
+// @Description: 按profile懒加载并缓存客户端
+type Registry struct {
+	cfgPath []string
+	mu      sync.Mutex
+	file    *configFile
+	clients map[string]*sApiClient
+}
+
+// DefaultRegistry 使用默认配置文件的客户端注册表
+var DefaultRegistry = NewRegistry()
+
+// NewRegistry
+//
+//	@Description: 创建客户端注册表
+//	@Author zzh 2026-10-19 18:22:50
+//	@param cfgPath
+//	@return *Registry
+func NewRegistry(cfgPath ...string) *Registry {
+	return &Registry{
+		cfgPath: cfgPath,
+		clients: make(map[string]*sApiClient),
+	}
+}
+
+// Get
+//
+//	@Description: 返回profile对应的客户端，首次获取时创建
+//	@receiver r
+//	@Author zzh 2026-10-19 18:24:16
+//	@param name 为空时使用默认profile
+//	@return *sApiClient
+//	@return error
+func (r *Registry) Get(name string) (*sApiClient, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	if name == "" {
+		name = r.file.defaultProfileName()
+	}
+	name = strings.ToLower(name)
+	if c, ok := r.clients[name]; ok {
+		return c, nil
+	}
+	profile, err := r.file.resolveProfile(name)
+	if err != nil {
+		return nil, err
+	}
+	c, err := profile.NewClient()
+	if err != nil {
+		return nil, err
+	}
+	c.cfgPath = r.cfgPath
+	r.clients[name] = c
+	return c, nil
+}
+
+// Profiles
+//
+//	@Description: 返回配置中可用的profile名称
+//	@receiver r
+//	@Author zzh 2026-10-19 18:25:38
+//	@return []string
+//	@return error
+func (r *Registry) Profiles() ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r.file.profileNames(), nil
+}
+
+// load
+//
+//	@Description: 首次使用时读取配置文件，需要在持有锁时调用
+//	@receiver r
+//	@Author zzh 2026-10-19 18:26:20
+//	@return error
+func (r *Registry) load() error {
+	if r.file != nil {
+		return nil
+	}
+	file, err := loadConfig(r.cfgPath...)
+	if err != nil {
+		return err
+	}
+	r.file = file
+	return nil
+}
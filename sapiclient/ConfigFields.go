@@ -0,0 +1,597 @@
+package sapiclient
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	//环境变量前缀，如sapi.transport.maxIdleConns对应SAPI_TRANSPORT_MAX_IDLE_CONNS
+	ENV_PREFIX = "SAPI_"
+	//指定默认profile的环境变量
+	ENV_PROFILE = "SAPI_PROFILE"
+)
+
+// configKind 配置值类型
+type configKind int
+
+const (
+	configString     configKind = iota //字符串
+	configInt                          //非负整数
+	configBool                         //布尔值
+	configDuration                     //时长，整数表示秒，字符串如"1m30s"
+	configStringList                   //字符串列表，环境变量中以逗号分隔
+	configStringMap                    //字符串map，环境变量中为k1=v1,k2=v2
+	configSize                         //字节数，整数或带KB、MB、GB后缀的字符串，如"16MB"
+)
+
+// configField
+// @Description: 配置项定义
+type configField struct {
+	key   string                                        //相对sapi配置块的key
+	kind  configKind                                    //值类型
+	apply func(p *ProfileConfig, val interface{}) error //写入配置，val已按kind转换
+}
+
+// TransportConfig
+// @Description: 连接配置，均为零值时使用resty默认transport
+type TransportConfig struct {
+	MaxIdleConns          int
+	MaxIdleConnsPerHost   int
+	MaxConnsPerHost       int
+	IdleConnTimeout       time.Duration
+	DialTimeout           time.Duration
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration
+	DisableKeepAlives     bool
+}
+
+// LogConfig
+// @Description: 日志配置
+type LogConfig struct {
+	Enabled bool   //每次请求输出一行日志
+	Debug   bool   //输出完整的请求与响应内容
+	Output  string //stdout或stderr
+}
+
+// configFields 所有支持的配置项，未在此列出的key视为未知配置
+var configFields = []configField{
+	{"appKey", configString, func(p *ProfileConfig, val interface{}) error {
+		p.AppKey = val.(string)
+		return nil
+	}},
+	{"appSecret", configString, func(p *ProfileConfig, val interface{}) error {
+		p.AppSecret = val.(string)
+		return nil
+	}},
+	{"serverUrl", configString, func(p *ProfileConfig, val interface{}) error {
+		if err := checkServerUrl(val.(string)); err != nil {
+			return err
+		}
+		p.ServerUrl = val.(string)
+		return nil
+	}},
+	{"serverIp", configString, func(p *ProfileConfig, val interface{}) error {
+		p.ServerIp = val.(string)
+		return nil
+	}},
+	{"requestMethod", configString, func(p *ProfileConfig, val interface{}) error {
+		method := strings.ToUpper(val.(string))
+		if method != "" && method != http.MethodGet && method != http.MethodPost {
+			return errors.New("仅支持GET或POST")
+		}
+		p.RequestMethod = method
+		return nil
+	}},
+	{"timeout", configInt, func(p *ProfileConfig, val interface{}) error {
+		p.Timeout = val.(int)
+		return nil
+	}},
+	{"retryCount", configInt, func(p *ProfileConfig, val interface{}) error {
+		p.RetryCount = val.(int)
+		return nil
+	}},
+	{"retryWaitTime", configInt, func(p *ProfileConfig, val interface{}) error {
+		p.RetryWaitTime = val.(int)
+		return nil
+	}},
+	{"headers", configStringMap, func(p *ProfileConfig, val interface{}) error {
+		p.Headers = val.(map[string]string)
+		return nil
+	}},
+	{"endpoints", configStringMap, func(p *ProfileConfig, val interface{}) error {
+		for service, serverUrl := range val.(map[string]string) {
+			if err := checkServerUrl(serverUrl); err != nil {
+				return errors.New(service + ": " + err.Error())
+			}
+		}
+		p.Endpoints = val.(map[string]string)
+		return nil
+	}},
+	{"signVersion", configString, func(p *ProfileConfig, val interface{}) error {
+		if _, ok := SignerForVersion(val.(string)); !ok {
+			return errors.New("不支持的签名版本")
+		}
+		p.SignVersion = val.(string)
+		return nil
+	}},
+	{"signedHeaders", configStringList, func(p *ProfileConfig, val interface{}) error {
+		p.SignedHeaders = val.([]string)
+		return nil
+	}},
+	{"bodyDigest", configBool, func(p *ProfileConfig, val interface{}) error {
+		p.BodyDigest = val.(bool)
+		return nil
+	}},
+	{"responseVerify", configBool, func(p *ProfileConfig, val interface{}) error {
+		p.ResponseVerify = val.(bool)
+		return nil
+	}},
+	{"responseKey", configString, func(p *ProfileConfig, val interface{}) error {
+		p.ResponseKey = val.(string)
+		return nil
+	}},
+	{"encrypt.enabled", configBool, func(p *ProfileConfig, val interface{}) error {
+		p.Encrypt = val.(bool)
+		return nil
+	}},
+	{"encrypt.currentKeyId", configString, func(p *ProfileConfig, val interface{}) error {
+		if p.EncryptKeys == nil {
+			p.EncryptKeys = &EncryptKeys{}
+		}
+		p.EncryptKeys.CurrentKeyId = val.(string)
+		return nil
+	}},
+	{"encrypt.keys", configStringMap, func(p *ProfileConfig, val interface{}) error {
+		keys := make(map[string][]byte)
+		for keyId, encoded := range val.(map[string]string) {
+			key, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil || (len(key) != 16 && len(key) != 24 && len(key) != 32) {
+				return errors.New(keyId + ": 密钥应为base64编码的16、24或32字节")
+			}
+			keys[keyId] = key
+		}
+		if p.EncryptKeys == nil {
+			p.EncryptKeys = &EncryptKeys{}
+		}
+		p.EncryptKeys.Keys = keys
+		return nil
+	}},
+	{"clockSkewCorrection", configBool, func(p *ProfileConfig, val interface{}) error {
+		p.ClockSkewCorrection = val.(bool)
+		return nil
+	}},
+	{"timeEndpoint", configString, func(p *ProfileConfig, val interface{}) error {
+		p.TimeEndpoint = val.(string)
+		return nil
+	}},
+	{"transport.maxIdleConns", configInt, func(p *ProfileConfig, val interface{}) error {
+		p.Transport.MaxIdleConns = val.(int)
+		return nil
+	}},
+	{"transport.maxIdleConnsPerHost", configInt, func(p *ProfileConfig, val interface{}) error {
+		p.Transport.MaxIdleConnsPerHost = val.(int)
+		return nil
+	}},
+	{"transport.maxConnsPerHost", configInt, func(p *ProfileConfig, val interface{}) error {
+		p.Transport.MaxConnsPerHost = val.(int)
+		return nil
+	}},
+	{"transport.idleConnTimeout", configDuration, func(p *ProfileConfig, val interface{}) error {
+		p.Transport.IdleConnTimeout = val.(time.Duration)
+		return nil
+	}},
+	{"transport.dialTimeout", configDuration, func(p *ProfileConfig, val interface{}) error {
+		p.Transport.DialTimeout = val.(time.Duration)
+		return nil
+	}},
+	{"transport.tlsHandshakeTimeout", configDuration, func(p *ProfileConfig, val interface{}) error {
+		p.Transport.TLSHandshakeTimeout = val.(time.Duration)
+		return nil
+	}},
+	{"transport.responseHeaderTimeout", configDuration, func(p *ProfileConfig, val interface{}) error {
+		p.Transport.ResponseHeaderTimeout = val.(time.Duration)
+		return nil
+	}},
+	{"transport.disableKeepAlives", configBool, func(p *ProfileConfig, val interface{}) error {
+		p.Transport.DisableKeepAlives = val.(bool)
+		return nil
+	}},
+	{"proxy.url", configString, func(p *ProfileConfig, val interface{}) error {
+		if val.(string) != "" {
+			if _, err := parseProxyUrl(val.(string), "", ""); err != nil {
+				return err
+			}
+		}
+		p.Proxy.URL = val.(string)
+		return nil
+	}},
+	{"proxy.username", configString, func(p *ProfileConfig, val interface{}) error {
+		p.Proxy.Username = val.(string)
+		return nil
+	}},
+	{"proxy.password", configString, func(p *ProfileConfig, val interface{}) error {
+		p.Proxy.Password = val.(string)
+		return nil
+	}},
+	{"proxy.noProxy", configStringList, func(p *ProfileConfig, val interface{}) error {
+		if _, err := parseNoProxy(val.([]string)); err != nil {
+			return err
+		}
+		p.Proxy.NoProxy = val.([]string)
+		return nil
+	}},
+	{"proxy.endpoints", configStringMap, func(p *ProfileConfig, val interface{}) error {
+		for service, proxyUrl := range val.(map[string]string) {
+			if strings.ToLower(proxyUrl) == PROXY_DIRECT {
+				continue
+			}
+			if _, err := parseProxyUrl(proxyUrl, "", ""); err != nil {
+				return errors.New(service + ": " + err.Error())
+			}
+		}
+		p.Proxy.Endpoints = val.(map[string]string)
+		return nil
+	}},
+	{"proxy.fromEnvironment", configBool, func(p *ProfileConfig, val interface{}) error {
+		p.Proxy.FromEnvironment = val.(bool)
+		return nil
+	}},
+	{"tls.caFiles", configStringList, func(p *ProfileConfig, val interface{}) error {
+		if _, err := loadCAFiles(val.([]string)); err != nil {
+			return err
+		}
+		p.TLS.CAFiles = val.([]string)
+		return nil
+	}},
+	{"tls.certFile", configString, func(p *ProfileConfig, val interface{}) error {
+		p.TLS.CertFile = val.(string)
+		return nil
+	}},
+	{"tls.keyFile", configString, func(p *ProfileConfig, val interface{}) error {
+		p.TLS.KeyFile = val.(string)
+		return nil
+	}},
+	{"tls.minVersion", configString, func(p *ProfileConfig, val interface{}) error {
+		if val.(string) != "" {
+			if _, err := parseTLSVersion(val.(string)); err != nil {
+				return err
+			}
+		}
+		p.TLS.MinVersion = val.(string)
+		return nil
+	}},
+	{"tls.serverName", configString, func(p *ProfileConfig, val interface{}) error {
+		p.TLS.ServerName = val.(string)
+		return nil
+	}},
+	{"tls.pins", configStringList, func(p *ProfileConfig, val interface{}) error {
+		for _, pin := range val.([]string) {
+			if _, err := parsePin(pin); err != nil {
+				return err
+			}
+		}
+		p.TLS.Pins = val.([]string)
+		return nil
+	}},
+	{"tls.backupPins", configStringList, func(p *ProfileConfig, val interface{}) error {
+		for _, pin := range val.([]string) {
+			if _, err := parsePin(pin); err != nil {
+				return err
+			}
+		}
+		p.TLS.BackupPins = val.([]string)
+		return nil
+	}},
+	{"compression.requestThreshold", configInt, func(p *ProfileConfig, val interface{}) error {
+		p.Compression.RequestThreshold = val.(int)
+		return nil
+	}},
+	{"compression.maxDecompressedSize", configSize, func(p *ProfileConfig, val interface{}) error {
+		p.Compression.MaxDecompressedSize = val.(int64)
+		return nil
+	}},
+	{"limits.maxRequestSize", configSize, func(p *ProfileConfig, val interface{}) error {
+		p.Limits.MaxRequestSize = val.(int64)
+		return nil
+	}},
+	{"limits.maxResponseSize", configSize, func(p *ProfileConfig, val interface{}) error {
+		p.Limits.MaxResponseSize = val.(int64)
+		return nil
+	}},
+	{"limits.requestSizes", configStringMap, func(p *ProfileConfig, val interface{}) error {
+		return p.Limits.setMethodSizes(val.(map[string]string), func(limits *SizeLimits, size int64) {
+			limits.MaxRequestSize = size
+		})
+	}},
+	{"limits.responseSizes", configStringMap, func(p *ProfileConfig, val interface{}) error {
+		return p.Limits.setMethodSizes(val.(map[string]string), func(limits *SizeLimits, size int64) {
+			limits.MaxResponseSize = size
+		})
+	}},
+	{"rateLimit.requestsPerSecond", configInt, func(p *ProfileConfig, val interface{}) error {
+		p.RateLimit.RequestsPerSecond = val.(int)
+		return nil
+	}},
+	{"rateLimit.burst", configInt, func(p *ProfileConfig, val interface{}) error {
+		p.RateLimit.Burst = val.(int)
+		return nil
+	}},
+	{"breaker.failureThreshold", configInt, func(p *ProfileConfig, val interface{}) error {
+		p.Breaker.FailureThreshold = val.(int)
+		return nil
+	}},
+	{"breaker.openTimeout", configDuration, func(p *ProfileConfig, val interface{}) error {
+		p.Breaker.OpenTimeout = val.(time.Duration)
+		return nil
+	}},
+	{"log.enabled", configBool, func(p *ProfileConfig, val interface{}) error {
+		p.Log.Enabled = val.(bool)
+		return nil
+	}},
+	{"log.debug", configBool, func(p *ProfileConfig, val interface{}) error {
+		p.Log.Debug = val.(bool)
+		return nil
+	}},
+	{"log.output", configString, func(p *ProfileConfig, val interface{}) error {
+		output := strings.ToLower(val.(string))
+		if output != "" && output != "stdout" && output != "stderr" {
+			return errors.New("仅支持stdout或stderr")
+		}
+		p.Log.Output = output
+		return nil
+	}},
+}
+
+// configFieldIndex 小写key -> 配置项
+var configFieldIndex = func() map[string]*configField {
+	index := make(map[string]*configField, len(configFields))
+	for i := range configFields {
+		index[strings.ToLower(configFields[i].key)] = &configFields[i]
+	}
+	return index
+}()
+
+// isConfigSection
+//
+//	@Description: key是否为配置分组（如transport、log）
+//	@Author zzh 2026-10-19 19:02:11
+//	@param key 小写
+//	@return bool
+func isConfigSection(key string) bool {
+	for fieldKey := range configFieldIndex {
+		if strings.HasPrefix(fieldKey, key+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// envName
+//
+//	@Description: 配置key对应的环境变量名，驼峰转为大写下划线，如transport.maxIdleConns -> TRANSPORT_MAX_IDLE_CONNS
+//	@Author zzh 2026-10-19 19:04:36
+//	@param key
+//	@return string
+func envName(key string) string {
+	var builder strings.Builder
+	for i, r := range key {
+		switch {
+		case r == '.' || r == '-':
+			builder.WriteByte('_')
+		case r >= 'A' && r <= 'Z':
+			if i > 0 && key[i-1] != '.' {
+				builder.WriteByte('_')
+			}
+			builder.WriteRune(r)
+		default:
+			builder.WriteString(strings.ToUpper(string(r)))
+		}
+	}
+	return builder.String()
+}
+
+// convertConfigValue
+//
+//	@Description: 按配置项类型转换配置文件或环境变量中的值
+//	@Author zzh 2026-10-19 19:08:50
+//	@param kind
+//	@param val
+//	@return interface{}
+//	@return error
+func convertConfigValue(kind configKind, val interface{}) (interface{}, error) {
+	switch kind {
+	case configString:
+		switch v := val.(type) {
+		case string:
+			return v, nil
+		case int, int64, float64, bool:
+			return fmt.Sprint(v), nil
+		}
+		return nil, errors.New("应为字符串")
+	case configInt:
+		var n int64
+		var err error
+		switch v := val.(type) {
+		case int:
+			n = int64(v)
+		case int64:
+			n = v
+		case float64:
+			if v != float64(int64(v)) {
+				return nil, errors.New("应为整数")
+			}
+			n = int64(v)
+		case string:
+			if n, err = strconv.ParseInt(strings.TrimSpace(v), 10, 64); err != nil {
+				return nil, errors.New("应为整数")
+			}
+		default:
+			return nil, errors.New("应为整数")
+		}
+		if n < 0 {
+			return nil, errors.New("不能为负数")
+		}
+		return int(n), nil
+	case configBool:
+		switch v := val.(type) {
+		case bool:
+			return v, nil
+		case string:
+			b, err := strconv.ParseBool(strings.TrimSpace(v))
+			if err != nil {
+				return nil, errors.New("应为true或false")
+			}
+			return b, nil
+		}
+		return nil, errors.New("应为true或false")
+	case configDuration:
+		if s, ok := val.(string); ok {
+			s = strings.TrimSpace(s)
+			if d, err := time.ParseDuration(s); err == nil {
+				if d < 0 {
+					return nil, errors.New("不能为负数")
+				}
+				return d, nil
+			}
+		}
+		seconds, err := convertConfigValue(configInt, val)
+		if err != nil {
+			return nil, errors.New("应为秒数或时长字符串，如30s")
+		}
+		return time.Duration(seconds.(int)) * time.Second, nil
+	case configStringList:
+		switch v := val.(type) {
+		case string:
+			list := make([]string, 0)
+			for _, item := range strings.Split(v, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					list = append(list, item)
+				}
+			}
+			return list, nil
+		case []interface{}:
+			list := make([]string, 0, len(v))
+			for _, item := range v {
+				s, err := convertConfigValue(configString, item)
+				if err != nil {
+					return nil, errors.New("应为字符串列表")
+				}
+				list = append(list, s.(string))
+			}
+			return list, nil
+		case []string:
+			return v, nil
+		}
+		return nil, errors.New("应为字符串列表")
+	case configStringMap:
+		result := make(map[string]string)
+		switch v := val.(type) {
+		case string:
+			for _, pair := range strings.Split(v, ",") {
+				if pair = strings.TrimSpace(pair); pair == "" {
+					continue
+				}
+				kv := strings.SplitN(pair, "=", 2)
+				if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+					return nil, errors.New("应为k1=v1,k2=v2格式")
+				}
+				result[strings.ToLower(strings.TrimSpace(kv[0]))] = strings.TrimSpace(kv[1])
+			}
+			return result, nil
+		case map[string]interface{}:
+			for key, item := range v {
+				s, err := convertConfigValue(configString, item)
+				if err != nil {
+					return nil, errors.New(key + "应为字符串")
+				}
+				result[strings.ToLower(key)] = s.(string)
+			}
+			return result, nil
+		}
+		return nil, errors.New("应为键值表")
+	case configSize:
+		switch v := val.(type) {
+		case string:
+			return parseSize(v)
+		case int, int64, float64:
+			n, err := convertConfigValue(configInt, v)
+			if err != nil {
+				return nil, err
+			}
+			return int64(n.(int)), nil
+		}
+		return nil, errors.New("应为字节数，可带KB、MB、GB后缀")
+	}
+	return nil, errors.New("未知的配置类型")
+}
+
+// checkServerUrl
+//
+//	@Description: 校验服务地址，支持http、https、h2c://host:port与unix:///socket路径
+//	@Author zzh 2026-10-19 19:12:27
+//	@param serverUrl
+//	@return error
+func checkServerUrl(serverUrl string) error {
+	urlParse, err := url.Parse(serverUrl)
+	if err == nil {
+		switch urlParse.Scheme {
+		case "http", "https":
+			if urlParse.Host != "" {
+				return nil
+			}
+		case SCHEME_H2C:
+			if urlParse.Host != "" {
+				return h2cSupported()
+			}
+		case SCHEME_UNIX:
+			if urlParse.Host == "" && strings.TrimRight(urlParse.Path, "/") != "" {
+				return nil
+			}
+		}
+	}
+	return errors.New("应为http、https、h2c或unix:///socket路径地址")
+}
+
+// newTransport
+//
+//	@Description: 按配置创建transport，均为零值时返回nil
+//	@receiver t
+//	@Author zzh 2026-10-19 19:14:05
+//	@return *http.Transport
+func (t TransportConfig) newTransport() *http.Transport {
+	if t == (TransportConfig{}) {
+		return nil
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if t.MaxIdleConns > 0 {
+		transport.MaxIdleConns = t.MaxIdleConns
+	}
+	if t.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = t.MaxIdleConnsPerHost
+	}
+	if t.MaxConnsPerHost > 0 {
+		transport.MaxConnsPerHost = t.MaxConnsPerHost
+	}
+	if t.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = t.IdleConnTimeout
+	}
+	if t.DialTimeout > 0 {
+		transport.DialContext = (&net.Dialer{Timeout: t.DialTimeout, KeepAlive: 30 * time.Second}).DialContext
+	}
+	if t.TLSHandshakeTimeout > 0 {
+		transport.TLSHandshakeTimeout = t.TLSHandshakeTimeout
+	}
+	if t.ResponseHeaderTimeout > 0 {
+		transport.ResponseHeaderTimeout = t.ResponseHeaderTimeout
+	}
+	transport.DisableKeepAlives = t.DisableKeepAlives
+	return transport
+}
@@ -0,0 +1,125 @@
+package sapiclient
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	//指定配置文件路径的环境变量，优先级低于New/NewProfile传入的路径
+	ENV_CONFIG = "SAPI_CONFIG"
+	//系统级配置目录
+	SYSTEM_CONFIG_DIR = "/etc/sapi"
+)
+
+// ErrConfigNotFound 明确指定的配置文件不存在
+var ErrConfigNotFound = errors.New("配置文件不存在")
+
+// ConfigNotFoundError
+// @Description: 明确指定的配置文件不存在，包含已查找的路径
+type ConfigNotFoundError struct {
+	Path     string   //指定的路径
+	Searched []string //已查找的完整路径
+}
+
+// Error
+//
+//	@Description: 错误信息
+//	@receiver e
+//	@Author zzh 2026-10-19 20:32:10
+//	@return string
+func (e *ConfigNotFoundError) Error() string {
+	return ErrConfigNotFound.Error() + ": " + e.Path + "，已查找: " + strings.Join(e.Searched, ", ")
+}
+
+// Is
+//
+//	@Description: 支持errors.Is(err, ErrConfigNotFound)
+//	@receiver e
+//	@Author zzh 2026-10-19 20:32:48
+//	@param target
+//	@return bool
+func (e *ConfigNotFoundError) Is(target error) bool {
+	return target == ErrConfigNotFound
+}
+
+// ConfigSearchDirs
+//
+//	@Description: 相对路径的查找目录，按顺序为：工作目录、可执行文件所在目录、$XDG_CONFIG_HOME/sapi
+//	（未设置时为~/.config/sapi）、/etc/sapi
+//	@Author zzh 2026-10-19 20:35:21
+//	@return []string
+func ConfigSearchDirs() []string {
+	appDirs, configDirs := configSearchDirs()
+	return append(appDirs, configDirs...)
+}
+
+// configSearchDirs
+//
+//	@Description: 分别返回按相对路径查找的程序目录与按文件名查找的配置目录
+//	@Author zzh 2026-10-19 20:36:40
+//	@return appDirs 工作目录、可执行文件所在目录
+//	@return configDirs $XDG_CONFIG_HOME/sapi、/etc/sapi
+func configSearchDirs() (appDirs, configDirs []string) {
+	if workDir, err := os.Getwd(); err == nil {
+		appDirs = append(appDirs, workDir)
+	}
+	if executable, err := os.Executable(); err == nil {
+		if resolved, err := filepath.EvalSymlinks(executable); err == nil {
+			executable = resolved
+		}
+		appDirs = append(appDirs, filepath.Dir(executable))
+	}
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			configHome = filepath.Join(home, ".config")
+		}
+	}
+	if configHome != "" {
+		configDirs = append(configDirs, filepath.Join(configHome, "sapi"))
+	}
+	configDirs = append(configDirs, SYSTEM_CONFIG_DIR)
+	return appDirs, configDirs
+}
+
+// ResolveConfigPath
+//
+//	@Description: 解析配置文件路径，优先级：传入的路径 > 环境变量SAPI_CONFIG > 默认路径manifest/config/config.toml。
+//	绝对路径直接使用；相对路径在工作目录与可执行文件所在目录下按相对路径查找，
+//	在$XDG_CONFIG_HOME/sapi与/etc/sapi下按文件名查找，返回第一个存在的文件。
+//	明确指定（传入或环境变量）的文件不存在时返回*ConfigNotFoundError；默认路径未找到时返回空字符串，使用默认配置
+//	@Author zzh 2026-10-19 20:40:06
+//	@param cfgPath
+//	@return string
+//	@return error
+func ResolveConfigPath(cfgPath ...string) (string, error) {
+	cfg, explicit := CFG_PATH, false
+	if len(cfgPath) > 0 && cfgPath[0] != "" {
+		cfg, explicit = cfgPath[0], true
+	} else if env := os.Getenv(ENV_CONFIG); env != "" {
+		cfg, explicit = env, true
+	}
+	candidates := []string{cfg}
+	if !filepath.IsAbs(cfg) {
+		candidates = candidates[:0]
+		appDirs, configDirs := configSearchDirs()
+		for _, dir := range appDirs {
+			candidates = append(candidates, filepath.Join(dir, cfg))
+		}
+		for _, dir := range configDirs {
+			candidates = append(candidates, filepath.Join(dir, filepath.Base(cfg)))
+		}
+	}
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, nil
+		}
+	}
+	if explicit {
+		return "", &ConfigNotFoundError{Path: cfg, Searched: candidates}
+	}
+	return "", nil
+}
@@ -0,0 +1,99 @@
+package sapiclient
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// chdir 切换工作目录，测试结束后恢复
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	workDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(workDir) })
+}
+
+// touch 创建空文件及其所在目录
+func touch(t *testing.T, path string) string {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestResolveConfigPath(t *testing.T) {
+	workDir, configHome := t.TempDir(), t.TempDir()
+	chdir(t, workDir)
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	t.Setenv(ENV_CONFIG, "")
+	// 默认路径未找到时使用默认配置
+	if path, err := ResolveConfigPath(); err != nil || path != "" {
+		t.Fatalf("default path = %q, %v", path, err)
+	}
+	// 相对路径在配置目录下按文件名查找
+	xdgFile := touch(t, filepath.Join(configHome, "sapi", "config.toml"))
+	if path, err := ResolveConfigPath(); err != nil || path != xdgFile {
+		t.Fatalf("xdg path = %q, %v, want %s", path, err, xdgFile)
+	}
+	// 工作目录下的相对路径优先于配置目录
+	workFile := touch(t, filepath.Join(workDir, CFG_PATH))
+	if path, err := ResolveConfigPath(); err != nil || path != workFile {
+		t.Fatalf("work dir path = %q, %v, want %s", path, err, workFile)
+	}
+	// 传入的路径 > SAPI_CONFIG > 默认路径
+	envFile := touch(t, filepath.Join(workDir, "env.toml"))
+	t.Setenv(ENV_CONFIG, envFile)
+	if path, err := ResolveConfigPath(); err != nil || path != envFile {
+		t.Fatalf("SAPI_CONFIG path = %q, %v, want %s", path, err, envFile)
+	}
+	argFile := touch(t, filepath.Join(configHome, "sapi", "arg.toml"))
+	if path, err := ResolveConfigPath("conf/arg.toml"); err != nil || path != argFile {
+		t.Fatalf("argument path = %q, %v, want %s", path, err, argFile)
+	}
+}
+
+func TestResolveConfigPathNotFound(t *testing.T) {
+	workDir, configHome := t.TempDir(), t.TempDir()
+	chdir(t, workDir)
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	t.Setenv(ENV_CONFIG, "missing.toml")
+	_, err := ResolveConfigPath()
+	var notFound *ConfigNotFoundError
+	if !errors.Is(err, ErrConfigNotFound) || !errors.As(err, &notFound) || notFound.Path != "missing.toml" {
+		t.Fatalf("err = %v, want ConfigNotFoundError", err)
+	}
+	want := []string{
+		filepath.Join(workDir, "missing.toml"),
+		filepath.Join(configHome, "sapi", "missing.toml"),
+		filepath.Join(SYSTEM_CONFIG_DIR, "missing.toml"),
+	}
+	searched := make(map[string]bool)
+	for _, path := range notFound.Searched {
+		searched[path] = true
+	}
+	for _, path := range want {
+		if !searched[path] {
+			t.Fatalf("searched = %v, want %s", notFound.Searched, path)
+		}
+	}
+	// 绝对路径不再查找其他目录
+	absolute := filepath.Join(workDir, "absent.toml")
+	if _, err = ResolveConfigPath(absolute); !errors.As(err, &notFound) || len(notFound.Searched) != 1 || notFound.Searched[0] != absolute {
+		t.Fatalf("absolute path err = %v", err)
+	}
+	if _, err = NewProfile("", absolute); !errors.Is(err, ErrConfigNotFound) {
+		t.Fatalf("NewProfile err = %v, want ErrConfigNotFound", err)
+	}
+}
@@ -0,0 +1,155 @@
+package sapiclient
+
+import (
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadProfileRateLimitAndBreaker(t *testing.T) {
+	path := writeConfig(t, `
+[sapi]
+appKey = "key"
+appSecret = "secret"
+
+[sapi.rateLimit]
+requestsPerSecond = 50
+burst = 10
+
+[sapi.breaker]
+failureThreshold = 5
+openTimeout = "1m"
+
+[sapi.compression]
+maxDecompressedSize = "16MB"
+`)
+	t.Setenv("SAPI_RATE_LIMIT_BURST", "20")
+	profile, err := LoadProfile("", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.RateLimit != (RateLimitConfig{RequestsPerSecond: 50, Burst: 20}) {
+		t.Fatalf("rateLimit = %+v", profile.RateLimit)
+	}
+	if profile.Breaker != (BreakerConfig{FailureThreshold: 5, OpenTimeout: time.Minute}) {
+		t.Fatalf("breaker = %+v", profile.Breaker)
+	}
+	if profile.Compression.MaxDecompressedSize != 16<<20 {
+		t.Fatalf("maxDecompressedSize = %d", profile.Compression.MaxDecompressedSize)
+	}
+	c, err := profile.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.limiter == nil || c.breakers == nil || c.breakers.openTimeout != time.Minute {
+		t.Fatal("rate limit or breaker not applied")
+	}
+}
+
+func TestLoadProfileInvalidRateLimit(t *testing.T) {
+	path := writeConfig(t, `
+[sapi.rateLimit]
+requestsPerSecond = -1
+qps = 10
+`)
+	_, err := LoadProfile("", path)
+	configErr, ok := err.(*ConfigError)
+	if !ok || len(configErr.Issues) != 2 {
+		t.Fatalf("err = %v", err)
+	}
+	for _, issue := range configErr.Issues {
+		if !strings.HasPrefix(issue.Source, path+": sapi.ratelimit.") {
+			t.Fatalf("issue source = %s", issue.Source)
+		}
+	}
+}
+
+func TestProfileInvalidProxyFailsRequests(t *testing.T) {
+	profile := &ProfileConfig{Proxy: ProxyConfig{URL: "ftp://proxy.example.com"}}
+	transport := profile.roundTripper()
+	if _, ok := transport.(errorTransport); !ok {
+		t.Fatalf("transport = %T, want errorTransport", transport)
+	}
+	req, _ := http.NewRequest(http.MethodGet, "http://127.0.0.1:1/sapi/demo/echo", nil)
+	if _, err := transport.RoundTrip(req); err == nil || !strings.Contains(err.Error(), "ftp") {
+		t.Fatalf("err = %v, want proxy config error", err)
+	}
+}
+
+func TestLoadProfileExtends(t *testing.T) {
+	path := writeConfig(t, `
+[sapi]
+appKey = "shared"
+appSecret = "secret"
+timeout = 5
+retryCount = 1
+defaultProfile = "billing"
+
+[sapi.profiles.billing]
+appKey = "billing"
+serverUrl = "http://billing.sapi/"
+retryCount = 2
+
+[sapi.profiles.billing-slow]
+extends = "billing"
+timeout = 30
+`)
+	t.Setenv("SAPI_PROFILES_BILLING_RETRY_COUNT", "3")
+	profile, err := LoadProfile("billing-slow", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 自身配置 > extends > sapi配置块，环境变量覆盖所在层的配置
+	if profile.Name != "billing-slow" || profile.AppKey != "billing" || profile.AppSecret != "secret" ||
+		profile.ServerUrl != "http://billing.sapi/" || profile.Timeout != 30 || profile.RetryCount != 3 {
+		t.Fatalf("profile = %+v", profile)
+	}
+	if profile, err = LoadProfile("", path); err != nil || profile.Name != "billing" || profile.Timeout != 5 {
+		t.Fatalf("default profile = %+v, %v", profile, err)
+	}
+	t.Setenv("SAPI_PROFILE", "BILLING-SLOW")
+	if profile, err = LoadProfile("", path); err != nil || profile.Name != "billing-slow" {
+		t.Fatalf("SAPI_PROFILE profile = %+v, %v", profile, err)
+	}
+}
+
+func TestLoadProfileErrors(t *testing.T) {
+	path := writeConfig(t, `
+[sapi.profiles.billing]
+appKey = "billing"
+
+[sapi.profiles.loop-a]
+extends = "loop-b"
+
+[sapi.profiles.loop-b]
+extends = "loop-a"
+
+[sapi.profiles.orphan]
+extends = "missing"
+`)
+	_, err := LoadProfile("unknown", path)
+	if err == nil || !strings.Contains(err.Error(), "配置profile不存在: unknown") || !strings.Contains(err.Error(), "billing") {
+		t.Fatalf("unknown profile err = %v", err)
+	}
+	if _, err = LoadProfile("orphan", path); err == nil || !strings.Contains(err.Error(), "配置profile不存在: missing") {
+		t.Fatalf("unknown parent err = %v", err)
+	}
+	if _, err = LoadProfile("loop-a", path); err == nil || !strings.Contains(err.Error(), "循环引用") {
+		t.Fatalf("cyclic extends err = %v", err)
+	}
+	if _, err = NewProfile("unknown", path); err == nil {
+		t.Fatal("NewProfile with unknown profile succeeded")
+	}
+}
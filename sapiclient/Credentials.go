@@ -0,0 +1,510 @@
+package sapiclient
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	//默认appKey环境变量
+	ENV_APP_KEY = "SAPI_APP_KEY"
+	//默认appSecret环境变量
+	ENV_APP_SECRET = "SAPI_APP_SECRET"
+	//加密凭证文件口令环境变量
+	ENV_CREDENTIALS_PASSPHRASE = "SAPI_CREDENTIALS_PASSPHRASE"
+	//凭证文件变更检查间隔
+	CREDENTIALS_CHECK_INTERVAL = time.Second
+	//加密凭证文件的口令派生算法
+	CREDENTIALS_KDF = "pbkdf2-sha256"
+	//加密凭证文件的默认迭代次数
+	CREDENTIALS_KDF_ITERATIONS = 600000
+	//读取加密凭证文件时接受的迭代次数范围，避免被改小或改大到无法计算
+	CREDENTIALS_KDF_MIN_ITERATIONS = 100000
+	CREDENTIALS_KDF_MAX_ITERATIONS = 10000000
+	//加密凭证文件的salt长度 字节
+	CREDENTIALS_SALT_SIZE = 16
+)
+
+// ErrNoCredentials 没有可用的凭证
+var ErrNoCredentials = errors.New("没有可用的appKey和appSecret")
+
+// Credentials
+// @Description: 客户端凭证
+type Credentials struct {
+	AppKey    string `json:"appKey"`
+	AppSecret string `json:"appSecret"`
+}
+
+// valid
+//
+//	@Description: appKey与appSecret均不为空
+//	@receiver c
+//	@Author zzh 2026-10-19 17:20:11
+//	@return bool
+func (c Credentials) valid() bool {
+	return c.AppKey != "" && c.AppSecret != ""
+}
+
+// CredentialsProvider
+// @Description: 凭证来源，Retrieve在每次请求时调用，实现方应自行缓存
+type CredentialsProvider interface {
+	// Retrieve 返回当前凭证
+	Retrieve() (Credentials, error)
+	// Refresh 强制重新加载凭证
+	Refresh() error
+}
+
+// StaticCredentials
+// @Description: 固定凭证
+type StaticCredentials Credentials
+
+// Retrieve
+//
+//	@Description: 返回固定凭证
+//	@receiver s
+//	@Author zzh 2026-10-19 17:21:40
+//	@return Credentials
+//	@return error
+func (s StaticCredentials) Retrieve() (Credentials, error) {
+	if !Credentials(s).valid() {
+		return Credentials{}, ErrNoCredentials
+	}
+	return Credentials(s), nil
+}
+
+// Refresh
+//
+//	@Description: 固定凭证无需刷新
+//	@receiver s
+//	@Author zzh 2026-10-19 17:22:15
+//	@return error
+func (s StaticCredentials) Refresh() error {
+	return nil
+}
+
+// EnvCredentials
+// @Description: 从环境变量读取凭证，变量名为空时使用SAPI_APP_KEY与SAPI_APP_SECRET
+type EnvCredentials struct {
+	KeyEnv    string
+	SecretEnv string
+}
+
+// Retrieve
+//
+//	@Description: 读取环境变量，每次调用都重新读取
+//	@receiver e
+//	@Author zzh 2026-10-19 17:23:48
+//	@return Credentials
+//	@return error
+func (e EnvCredentials) Retrieve() (Credentials, error) {
+	keyEnv, secretEnv := e.KeyEnv, e.SecretEnv
+	if keyEnv == "" {
+		keyEnv = ENV_APP_KEY
+	}
+	if secretEnv == "" {
+		secretEnv = ENV_APP_SECRET
+	}
+	credentials := Credentials{AppKey: os.Getenv(keyEnv), AppSecret: os.Getenv(secretEnv)}
+	if !credentials.valid() {
+		return Credentials{}, errors.New("环境变量" + keyEnv + "、" + secretEnv + "未设置")
+	}
+	return credentials, nil
+}
+
+// Refresh
+//
+//	@Description: 环境变量每次读取，无需刷新
+//	@receiver e
+//	@Author zzh 2026-10-19 17:24:30
+//	@return error
+func (e EnvCredentials) Refresh() error {
+	return nil
+}
+
+// fileCredentials
+// @Description: 基于文件的凭证，文件修改时间变化时重新加载
+type fileCredentials struct {
+	mu          sync.Mutex
+	paths       []string
+	load        func() (Credentials, error)
+	credentials Credentials
+	err         error
+	modTimes    []time.Time
+	checkedAt   time.Time
+}
+
+// Retrieve
+//
+//	@Description: 返回缓存的凭证，超过检查间隔时检查文件是否变化
+//	@receiver f
+//	@Author zzh 2026-10-19 17:27:02
+//	@return Credentials
+//	@return error
+func (f *fileCredentials) Retrieve() (Credentials, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.checkedAt.IsZero() || time.Since(f.checkedAt) >= CREDENTIALS_CHECK_INTERVAL {
+		f.checkedAt = time.Now()
+		if modTimes := f.stat(); !sameTimes(modTimes, f.modTimes) {
+			f.reload(modTimes)
+		}
+	}
+	return f.credentials, f.err
+}
+
+// Refresh
+//
+//	@Description: 强制重新加载
+//	@receiver f
+//	@Author zzh 2026-10-19 17:28:15
+//	@return error
+func (f *fileCredentials) Refresh() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.checkedAt = time.Now()
+	f.reload(f.stat())
+	return f.err
+}
+
+// reload
+//
+//	@Description: 加载凭证，需要在持有锁时调用
+//	@receiver f
+//	@Author zzh 2026-10-19 17:29:31
+//	@param modTimes
+func (f *fileCredentials) reload(modTimes []time.Time) {
+	f.modTimes = modTimes
+	f.credentials, f.err = f.load()
+	if f.err == nil && !f.credentials.valid() {
+		f.err = ErrNoCredentials
+	}
+}
+
+// stat
+//
+//	@Description: 获取各文件的修改时间，符号链接会被解析（兼容Kubernetes挂载的secret）
+//	@receiver f
+//	@Author zzh 2026-10-19 17:30:52
+//	@return []time.Time
+func (f *fileCredentials) stat() []time.Time {
+	modTimes := make([]time.Time, len(f.paths))
+	for i, filePath := range f.paths {
+		if info, err := os.Stat(filePath); err == nil {
+			modTimes[i] = info.ModTime()
+		}
+	}
+	return modTimes
+}
+
+// sameTimes
+//
+//	@Description: 比较修改时间是否一致
+//	@Author zzh 2026-10-19 17:31:40
+//	@param a
+//	@param b
+//	@return bool
+func sameTimes(a, b []time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// NewConfigFileCredentials
+//
+//	@Description: 从配置文件的sapi.appKey、sapi.appSecret读取凭证，支持viper支持的所有格式
+//	@Author zzh 2026-10-19 17:34:08
+//	@param cfgPath
+//	@return CredentialsProvider
+func NewConfigFileCredentials(cfgPath string) CredentialsProvider {
+	return &fileCredentials{
+		paths: []string{cfgPath},
+		load: func() (Credentials, error) {
+			viperObject := viper.New()
+			viperObject.SetConfigFile(cfgPath)
+			if err := viperObject.ReadInConfig(); err != nil {
+				return Credentials{}, errors.New("配置文件读取失败: " + err.Error())
+			}
+			return Credentials{
+				AppKey:    viperObject.GetString("sapi.appKey"),
+				AppSecret: viperObject.GetString("sapi.appSecret"),
+			}, nil
+		},
+	}
+}
+
+// NewSecretDirCredentials
+//
+//	@Description: 从挂载的secret目录读取凭证（Kubernetes/Docker secret），每个文件保存一个值，
+//	文件名为空时使用appKey与appSecret
+//	@Author zzh 2026-10-19 17:37:25
+//	@param dir
+//	@param keyFile
+//	@param secretFile
+//	@return CredentialsProvider
+func NewSecretDirCredentials(dir, keyFile, secretFile string) CredentialsProvider {
+	if keyFile == "" {
+		keyFile = "appKey"
+	}
+	if secretFile == "" {
+		secretFile = "appSecret"
+	}
+	keyPath, secretPath := filepath.Join(dir, keyFile), filepath.Join(dir, secretFile)
+	return &fileCredentials{
+		paths: []string{keyPath, secretPath},
+		load: func() (Credentials, error) {
+			appKey, err := ioutil.ReadFile(keyPath)
+			if err != nil {
+				return Credentials{}, errors.New("appKey文件读取失败: " + err.Error())
+			}
+			appSecret, err := ioutil.ReadFile(secretPath)
+			if err != nil {
+				return Credentials{}, errors.New("appSecret文件读取失败: " + err.Error())
+			}
+			return Credentials{
+				AppKey:    strings.TrimSpace(string(appKey)),
+				AppSecret: strings.TrimSpace(string(appSecret)),
+			}, nil
+		},
+	}
+}
+
+// NewEncryptedFileCredentials
+//
+//	@Description: 从本地加密凭证文件读取凭证，口令为空时读取环境变量SAPI_CREDENTIALS_PASSPHRASE
+//	@Author zzh 2026-10-19 17:40:12
+//	@param filePath
+//	@param passphrase
+//	@return CredentialsProvider
+func NewEncryptedFileCredentials(filePath, passphrase string) CredentialsProvider {
+	return &fileCredentials{
+		paths: []string{filePath},
+		load: func() (Credentials, error) {
+			key := passphrase
+			if key == "" {
+				key = os.Getenv(ENV_CREDENTIALS_PASSPHRASE)
+			}
+			if key == "" {
+				return Credentials{}, errors.New("加密凭证文件口令未设置")
+			}
+			payload, err := ioutil.ReadFile(filePath)
+			if err != nil {
+				return Credentials{}, errors.New("加密凭证文件读取失败: " + err.Error())
+			}
+			content, err := decryptCredentialsFile(key, payload)
+			if err != nil {
+				return Credentials{}, errors.New("加密凭证文件解密失败: " + err.Error())
+			}
+			credentials := Credentials{}
+			if err = json.Unmarshal(content, &credentials); err != nil {
+				return Credentials{}, errors.New("加密凭证文件解析失败: " + err.Error())
+			}
+			return credentials, nil
+		},
+	}
+}
+
+// WriteEncryptedCredentials
+//
+//	@Description: 生成本地加密凭证文件，供NewEncryptedFileCredentials读取
+//	@Author zzh 2026-10-19 17:42:50
+//	@param filePath
+//	@param passphrase
+//	@param credentials
+//	@return error
+func WriteEncryptedCredentials(filePath, passphrase string, credentials Credentials) error {
+	if passphrase == "" {
+		return errors.New("加密凭证文件口令不能为空")
+	}
+	content, err := json.Marshal(credentials)
+	if err != nil {
+		return err
+	}
+	salt := make([]byte, CREDENTIALS_SALT_SIZE)
+	if _, err = rand.Read(salt); err != nil {
+		return err
+	}
+	file := &credentialsFile{Kdf: CREDENTIALS_KDF, Iterations: CREDENTIALS_KDF_ITERATIONS, Salt: base64.StdEncoding.EncodeToString(salt)}
+	payload, err := EncryptPayload(pbkdf2SHA256([]byte(passphrase), salt, file.Iterations, 32), content, file.aad())
+	if err != nil {
+		return err
+	}
+	envelope := &encryptEnvelope{}
+	if err = json.Unmarshal(payload, envelope); err != nil {
+		return err
+	}
+	file.Iv, file.Data = envelope.Iv, envelope.Data
+	if payload, err = json.Marshal(file); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filePath, payload, 0600)
+}
+
+// credentialsFile
+// @Description: 加密凭证文件，文件头记录口令派生参数，每个文件使用随机salt
+type credentialsFile struct {
+	Kdf        string `json:"kdf"`        //口令派生算法
+	Iterations int    `json:"iterations"` //迭代次数
+	Salt       string `json:"salt"`       //salt base64
+	Iv         string `json:"iv"`         //GCM随机数 base64
+	Data       string `json:"data"`       //密文 base64
+}
+
+// aad
+//
+//	@Description: 附加认证数据绑定派生参数，参数被修改时解密失败
+//	@receiver f
+//	@Author zzh 2026-10-20 04:10:12
+//	@return string
+func (f *credentialsFile) aad() string {
+	return "credentials\n" + f.Kdf + "\n" + strconv.Itoa(f.Iterations) + "\n" + f.Salt
+}
+
+// decryptCredentialsFile
+//
+//	@Description: 按文件头的参数由口令派生密钥并解密凭证文件
+//	@Author zzh 2026-10-20 04:11:30
+//	@param passphrase
+//	@param payload
+//	@return []byte
+//	@return error
+func decryptCredentialsFile(passphrase string, payload []byte) ([]byte, error) {
+	file := &credentialsFile{}
+	if err := json.Unmarshal(payload, file); err != nil {
+		return nil, errors.New("文件格式不正确")
+	}
+	if file.Kdf != CREDENTIALS_KDF {
+		return nil, errors.New("不支持的口令派生算法: " + file.Kdf + "，请使用WriteEncryptedCredentials重新生成")
+	}
+	if file.Iterations < CREDENTIALS_KDF_MIN_ITERATIONS || file.Iterations > CREDENTIALS_KDF_MAX_ITERATIONS {
+		return nil, errors.New("迭代次数不在允许范围内: " + strconv.Itoa(file.Iterations))
+	}
+	salt, err := base64.StdEncoding.DecodeString(file.Salt)
+	if err != nil || len(salt) < CREDENTIALS_SALT_SIZE {
+		return nil, errors.New("salt不正确")
+	}
+	envelope, _ := json.Marshal(&encryptEnvelope{Iv: file.Iv, Data: file.Data})
+	return DecryptPayload(pbkdf2SHA256([]byte(passphrase), salt, file.Iterations, 32), envelope, file.aad())
+}
+
+// pbkdf2SHA256
+//
+//	@Description: PBKDF2-HMAC-SHA256（RFC 8018）
+//	@Author zzh 2026-10-20 04:13:05
+//	@param password
+//	@param salt
+//	@param iterations
+//	@param keyLen
+//	@return []byte
+func pbkdf2SHA256(password, salt []byte, iterations, keyLen int) []byte {
+	prf := hmac.New(sha256.New, password)
+	key := make([]byte, 0, keyLen+sha256.Size)
+	block := make([]byte, 4)
+	for i := uint32(1); len(key) < keyLen; i++ {
+		prf.Reset()
+		prf.Write(salt)
+		binary.BigEndian.PutUint32(block, i)
+		prf.Write(block)
+		u := prf.Sum(nil)
+		t := append([]byte{}, u...)
+		for n := 1; n < iterations; n++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		key = append(key, t...)
+	}
+	return key[:keyLen]
+}
+
+// ChainCredentials
+// @Description: 按顺序尝试多个凭证来源，返回第一个可用的凭证
+type ChainCredentials []CredentialsProvider
+
+// Retrieve
+//
+//	@Description: 返回第一个可用的凭证
+//	@receiver c
+//	@Author zzh 2026-10-19 17:45:16
+//	@return Credentials
+//	@return error
+func (c ChainCredentials) Retrieve() (Credentials, error) {
+	messages := make([]string, 0, len(c))
+	for _, provider := range c {
+		credentials, err := provider.Retrieve()
+		if err == nil && credentials.valid() {
+			return credentials, nil
+		}
+		if err != nil {
+			messages = append(messages, err.Error())
+		}
+	}
+	if len(messages) == 0 {
+		return Credentials{}, ErrNoCredentials
+	}
+	return Credentials{}, errors.New(ErrNoCredentials.Error() + ": " + strings.Join(messages, "; "))
+}
+
+// Refresh
+//
+//	@Description: 刷新所有凭证来源，返回第一个错误
+//	@receiver c
+//	@Author zzh 2026-10-19 17:46:33
+//	@return error
+func (c ChainCredentials) Refresh() error {
+	var firstErr error
+	for _, provider := range c {
+		if err := provider.Refresh(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// SetCredentialsProvider
+//
+//	@Description: 设置凭证来源，设置后每次请求从中获取appKey与appSecret，优先于SetClientCfg
+//	@receiver c
+//	@Author zzh 2026-10-19 17:48:02
+//	@param provider
+//	@return *sApiClient
+func (c *sApiClient) SetCredentialsProvider(provider CredentialsProvider) *sApiClient {
+	c.credentials = provider
+	return c
+}
+
+// currentCredentials
+//
+//	@Description: 返回本次请求使用的凭证
+//	@receiver c
+//	@Author zzh 2026-10-19 17:49:40
+//	@return Credentials
+//	@return error
+func (c *sApiClient) currentCredentials() (Credentials, error) {
+	if c.credentials != nil {
+		return c.credentials.Retrieve()
+	}
+	return Credentials{AppKey: c.appKey, AppSecret: c.appSecret}, nil
+}
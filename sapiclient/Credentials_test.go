@@ -0,0 +1,65 @@
+package sapiclient
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestPBKDF2SHA256(t *testing.T) {
+	// RFC 7914 11节测试向量
+	key := pbkdf2SHA256([]byte("passwd"), []byte("salt"), 1, 64)
+	want := "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783"
+	if hex.EncodeToString(key) != want {
+		t.Fatalf("pbkdf2 = %x", key)
+	}
+}
+
+func TestEncryptedFileCredentials(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "credentials.enc")
+	credentials := Credentials{AppKey: "key", AppSecret: "secret"}
+	if err := WriteEncryptedCredentials(path, "passphrase", credentials); err != nil {
+		t.Fatal(err)
+	}
+	got, err := NewEncryptedFileCredentials(path, "passphrase").Retrieve()
+	if err != nil || got != credentials {
+		t.Fatalf("Retrieve = %+v, %v", got, err)
+	}
+	if _, err = NewEncryptedFileCredentials(path, "wrong").Retrieve(); err == nil {
+		t.Fatal("wrong passphrase accepted")
+	}
+
+	other := filepath.Join(dir, "other.enc")
+	if err = WriteEncryptedCredentials(other, "passphrase", credentials); err != nil {
+		t.Fatal(err)
+	}
+	first, second := readCredentialsFile(t, path), readCredentialsFile(t, other)
+	if first.Kdf != CREDENTIALS_KDF || first.Iterations != CREDENTIALS_KDF_ITERATIONS || first.Salt == second.Salt {
+		t.Fatalf("header = %+v, other salt %s", first, second.Salt)
+	}
+
+	// 修改文件头中的参数后解密失败
+	first.Iterations = CREDENTIALS_KDF_MIN_ITERATIONS
+	content, _ := json.Marshal(first)
+	if err = ioutil.WriteFile(path, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = NewEncryptedFileCredentials(path, "passphrase").Retrieve(); err == nil {
+		t.Fatal("tampered header accepted")
+	}
+}
+
+func readCredentialsFile(t *testing.T, path string) *credentialsFile {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := &credentialsFile{}
+	if err = json.Unmarshal(content, file); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
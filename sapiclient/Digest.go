@@ -0,0 +1,63 @@
+package sapiclient
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/http"
+)
+
+const (
+	//请求体摘要请求头
+	CONTENT_DIGEST_HEADER = "Content-Digest"
+)
+
+// ErrContentDigest 请求体摘要与内容不一致
+var ErrContentDigest = errors.New("请求体摘要校验失败")
+
+// ContentDigest
+//
+//	@Description: 计算请求体摘要，格式为sha-256=:<base64>:。
+//	规范化规则：GET请求对CanonicalQuery(query)的结果做摘要（key按字节序排序、同名参数按值排序、
+//	RFC3986编码即PHP rawurlencode，以&和=连接）；其他请求对实际发送的原始body字节做摘要
+//	@Author zzh 2026-10-19 15:40:27
+//	@param req
+//	@return string
+func ContentDigest(req *SignRequest) string {
+	content := req.Body
+	if req.Method == http.MethodGet {
+		content = []byte(CanonicalQuery(req.Query))
+	}
+	sum := sha256.Sum256(content)
+	return "sha-256=:" + base64.StdEncoding.EncodeToString(sum[:]) + ":"
+}
+
+// verifyContentDigest
+//
+//	@Description: 请求头带有Content-Digest时按原始内容重新计算并比对
+//	@Author zzh 2026-10-19 15:43:10
+//	@param req
+//	@return error
+func verifyContentDigest(req *SignRequest) error {
+	digest := req.Header.Get(CONTENT_DIGEST_HEADER)
+	if digest == "" {
+		return nil
+	}
+	if subtle.ConstantTimeCompare([]byte(digest), []byte(ContentDigest(req))) != 1 {
+		return ErrContentDigest
+	}
+	return nil
+}
+
+// SetBodyDigest
+//
+//	@Description: 开启后发送Content-Digest请求头，并将其纳入签名，需要使用hmac-sha256签名
+//	@receiver c
+//	@Author zzh 2026-10-19 15:45:36
+//	@param enabled
+//	@return *sApiClient
+func (c *sApiClient) SetBodyDigest(enabled bool) *sApiClient {
+	c.bodyDigest = enabled
+	return c
+}
@@ -0,0 +1,63 @@
+package sapiclient
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestContentDigest(t *testing.T) {
+	if digest := ContentDigest(&SignRequest{Method: http.MethodPost}); digest != "sha-256=:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=:" {
+		t.Fatalf("empty body digest = %s", digest)
+	}
+	// GET对规范化query做摘要，与参数顺序无关
+	query := url.Values{"b": {"2", "1"}, "a": {"x y"}}
+	get := ContentDigest(&SignRequest{Method: http.MethodGet, Query: query})
+	if want := ContentDigest(&SignRequest{Method: http.MethodPost, Body: []byte("a=x%20y&b=1&b=2")}); get != want {
+		t.Fatalf("GET digest = %s, want %s", get, want)
+	}
+	req := &SignRequest{Method: http.MethodPost, Body: []byte(`{"data":"hello"}`), Header: http.Header{}}
+	if err := verifyContentDigest(req); err != nil {
+		t.Fatalf("missing digest err = %v", err)
+	}
+	req.Header.Set(CONTENT_DIGEST_HEADER, ContentDigest(req))
+	if err := verifyContentDigest(req); err != nil {
+		t.Fatalf("matching digest err = %v", err)
+	}
+	req.Body = []byte(`{"data":"hellO"}`)
+	if err := verifyContentDigest(req); !errors.Is(err, ErrContentDigest) {
+		t.Fatalf("tampered body err = %v, want ErrContentDigest", err)
+	}
+}
+
+func TestClientSendsContentDigest(t *testing.T) {
+	captured := captureSigned(t, HMACSHA256Signer{}, map[string]interface{}{"data": "hello"})
+	digest := captured.header.Get(CONTENT_DIGEST_HEADER)
+	if digest != ContentDigest(&SignRequest{Method: http.MethodPost, Body: captured.body}) {
+		t.Fatalf("Content-Digest = %q does not match body %s", digest, captured.body)
+	}
+	if !strings.Contains(captured.header.Get(SIGNED_HEADERS_HEADER), "content-digest") {
+		t.Fatalf("signed-headers = %q, want content-digest", captured.header.Get(SIGNED_HEADERS_HEADER))
+	}
+	options := &VerifyOptions{
+		SecretStore:       StaticSecretStore{"key": "secret"},
+		NonceStore:        NewMemoryNonceStore(MemoryNonceOptions{}),
+		RequireBodyDigest: true,
+	}
+	if _, err := VerifyRequest(captured.replay(), options); err != nil {
+		t.Fatalf("verify = %v", err)
+	}
+	// 替换摘要后签名不再匹配
+	forged := captured.replay()
+	forged.Header.Set(CONTENT_DIGEST_HEADER, ContentDigest(&SignRequest{Method: http.MethodPost, Body: captured.body[1:]}))
+	if _, err := VerifyRequest(forged, options); !isVerifyCode(err, CODE_SIGN_INVALID) {
+		t.Fatalf("forged digest err = %v, want CODE_SIGN_INVALID", err)
+	}
+	// 未携带摘要的请求在RequireBodyDigest时被拒绝
+	missing := captureSigned(t, LegacyMD5Signer{}, map[string]interface{}{"data": "hello"})
+	if _, err := VerifyRequest(missing.replay(), options); !isVerifyCode(err, CODE_SIGN_MISSING) {
+		t.Fatalf("missing digest err = %v, want CODE_SIGN_MISSING", err)
+	}
+}
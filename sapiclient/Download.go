@@ -0,0 +1,726 @@
+package sapiclient
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	//下载内容的sha256（十六进制），由服务端返回，用于校验下载内容并判断续传时内容是否变化
+	DOWNLOAD_CHECKSUM_HEADER = "content-sha256"
+	//续传请求头，v2签名中总是参与签名
+	RANGE_HEADER = "Range"
+	//续传时内容未变化才按Range返回，v2签名中总是参与签名
+	IF_RANGE_HEADER = "If-Range"
+	//下载文件时未完成内容的文件后缀
+	DOWNLOAD_PART_SUFFIX = ".sapipart"
+	//下载状态文件的后缀
+	DOWNLOAD_STATE_SUFFIX = ".sapidownload"
+	//默认连续续传次数
+	DEFAULT_DOWNLOAD_RETRIES = 3
+	//服务端内容变化后最多重新下载的次数，重新下载不计入续传次数
+	DOWNLOAD_MAX_RESTARTS = 3
+)
+
+// ErrDownload 下载失败，可通过errors.Is(err, ErrDownload)判断
+var ErrDownload = errors.New("下载失败")
+
+// ErrChecksumMismatch 下载内容与服务端返回的sha256不一致
+var ErrChecksumMismatch = errors.New("下载内容校验失败")
+
+// ErrContentChanged 续传时服务端内容已变化。下载到文件时自动重新下载，超过DOWNLOAD_MAX_RESTARTS次后返回；
+// 下载到io.Writer时已写入的内容无法丢弃，直接返回，调用方需丢弃已写入的内容后重新调用
+var ErrContentChanged = errors.New("下载内容已变化")
+
+// ErrDownloadLocked 下载文件正被其它下载使用
+var ErrDownloadLocked = errors.New("下载文件正被其它下载使用")
+
+// DownloadError
+// @Description: 下载失败，服务端返回错误时Code、Msg为响应内容，否则Err为原始错误
+type DownloadError struct {
+	Offset     int64  //出错时已写入的字节数
+	StatusCode int    //响应状态码，未收到响应时为0
+	Code       int    //服务端返回的code
+	Msg        string //服务端返回的msg
+	Err        error
+}
+
+// Error
+//
+//	@Description: 错误信息
+//	@receiver e
+//	@Author zzh 2026-10-20 03:12:10
+//	@return string
+func (e *DownloadError) Error() string {
+	msg := ErrDownload.Error() + ": 偏移" + strconv.FormatInt(e.Offset, 10) + "字节"
+	if e.Err != nil {
+		return msg + ": " + e.Err.Error()
+	}
+	return msg + ": " + strconv.Itoa(e.StatusCode) + " " + strconv.Itoa(e.Code) + " " + e.Msg
+}
+
+// Is
+//
+//	@Description: 支持errors.Is(err, ErrDownload)
+//	@receiver e
+//	@Author zzh 2026-10-20 03:12:42
+//	@param target
+//	@return bool
+func (e *DownloadError) Is(target error) bool {
+	return target == ErrDownload
+}
+
+// Unwrap
+//
+//	@Description: 返回原始错误
+//	@receiver e
+//	@Author zzh 2026-10-20 03:13:01
+//	@return error
+func (e *DownloadError) Unwrap() error {
+	return e.Err
+}
+
+// DownloadOptions
+// @Description: 下载选项，零值使用默认值
+type DownloadOptions struct {
+	CallOptions                            //单次调用选项，Nonce只用于第一次请求
+	Progress    func(written, total int64) //进度回调，written为已写入的字节数，包含续传前已写入的部分，total未知时为-1
+	Retries     int                        //连续未取得进展的续传次数，默认DEFAULT_DOWNLOAD_RETRIES，小于0表示不续传
+	RetryWait   time.Duration              //续传等待时间，默认1秒，连续失败时翻倍
+	Checksum    string                     //期望的sha256（十六进制），为空时使用服务端返回的content-sha256
+}
+
+// DownloadResult
+// @Description: 下载结果
+type DownloadResult struct {
+	StatusCode int         //最后一次响应的状态码
+	Header     http.Header //最后一次响应的响应头
+	Size       int64       //下载内容的大小
+	Checksum   string      //下载内容的sha256
+	Requests   int         //发送的请求数，大于1表示发生过续传
+}
+
+// downloadTarget
+// @Description: 下载内容的写入目标
+type downloadTarget interface {
+	io.Writer
+	// rewind 丢弃offset之后已写入的内容，返回之前内容的sha256状态
+	rewind(offset int64) (hash.Hash, error)
+}
+
+// writerTarget
+// @Description: 写入io.Writer，已写入的内容无法丢弃
+type writerTarget struct {
+	io.Writer
+	written int64
+}
+
+// Write
+//
+//	@Description: 写入内容
+//	@receiver t
+//	@Author zzh 2026-10-20 03:14:20
+//	@param p
+//	@return int
+//	@return error
+func (t *writerTarget) Write(p []byte) (int, error) {
+	n, err := t.Writer.Write(p)
+	t.written += int64(n)
+	return n, err
+}
+
+// rewind
+//
+//	@Description: 只能从头开始且尚未写入内容时回退
+//	@receiver t
+//	@Author zzh 2026-10-20 03:14:52
+//	@param offset
+//	@return hash.Hash
+//	@return error
+func (t *writerTarget) rewind(offset int64) (hash.Hash, error) {
+	if offset != 0 || t.written != 0 {
+		return nil, errors.New("已写入io.Writer的内容无法丢弃")
+	}
+	return sha256.New(), nil
+}
+
+// fileTarget
+// @Description: 写入未完成内容的文件
+type fileTarget struct {
+	*os.File
+}
+
+// rewind
+//
+//	@Description: 截断文件并重新计算之前内容的sha256
+//	@receiver t
+//	@Author zzh 2026-10-20 03:15:40
+//	@param offset
+//	@return hash.Hash
+//	@return error
+func (t *fileTarget) rewind(offset int64) (hash.Hash, error) {
+	if err := t.Truncate(offset); err != nil {
+		return nil, err
+	}
+	if _, err := t.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, io.NewSectionReader(t.File, 0, offset)); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// download
+// @Description: 一次下载，中断后使用Range从已写入的位置续传
+type download struct {
+	client     *sApiClient
+	service    string
+	method     string
+	body       map[string]interface{}
+	options    DownloadOptions
+	target     downloadTarget
+	hash       hash.Hash //已写入内容的sha256
+	written    int64
+	total      int64  //内容大小，-1表示未知
+	checksum   string //服务端返回的sha256
+	etag       string //服务端返回的ETag
+	requests   int
+	statusCode int
+	header     http.Header
+	saveState  func() error //收到响应后保存下载状态，可为nil
+}
+
+// newDownload
+//
+//	@Description: 应用下载选项的默认值
+//	@receiver c
+//	@Author zzh 2026-10-20 03:17:12
+//	@param service
+//	@param method
+//	@param body
+//	@param options
+//	@param target
+//	@return *download
+func (c *sApiClient) newDownload(service, method string, body map[string]interface{}, options DownloadOptions, target downloadTarget) *download {
+	if options.Retries == 0 {
+		options.Retries = DEFAULT_DOWNLOAD_RETRIES
+	} else if options.Retries < 0 {
+		options.Retries = 0
+	}
+	if options.RetryWait <= 0 {
+		options.RetryWait = time.Second
+	}
+	options.Checksum = strings.ToLower(options.Checksum)
+	return &download{
+		client:  c,
+		service: service,
+		method:  method,
+		body:    body,
+		options: options,
+		target:  target,
+		hash:    sha256.New(),
+		total:   -1,
+	}
+}
+
+// Download
+//
+//	@Description: 下载服务方法返回的内容并边读取边写入w，连接中断时使用Range从已写入的位置续传，
+//	续传请求同样签名且Range参与签名。服务端返回content-sha256时校验整个内容。开启响应签名校验时每次响应分别校验，
+//	写入w的内容无法丢弃，因此中断后不续传；续传时内容已变化返回ErrContentChanged。出错时w中可能已写入部分内容
+//	@receiver c
+//	@Author zzh 2026-10-20 03:19:40
+//	@param ctx
+//	@param service
+//	@param method
+//	@param body
+//	@param w
+//	@param options
+//	@return *DownloadResult
+//	@return error
+func (c *sApiClient) Download(ctx context.Context, service, method string, body map[string]interface{}, w io.Writer, options DownloadOptions) (*DownloadResult, error) {
+	if err := c.checkDownload(service, method, options.CallOptions); err != nil {
+		return nil, err
+	}
+	return c.newDownload(service, method, body, options, &writerTarget{Writer: w}).run(ctx)
+}
+
+// checkDownload
+//
+//	@Description: 下载不支持报文加密；续传的Range需要参与签名，要求使用hmac-sha256签名
+//	@receiver c
+//	@Author zzh 2026-10-20 04:40:12
+//	@param service
+//	@param method
+//	@param options
+//	@return error
+func (c *sApiClient) checkDownload(service, method string, options CallOptions) error {
+	clone := c.cloneForCall(service, method, options)
+	if clone.encrypt {
+		return fmt.Errorf("%w: 下载不支持报文加密", ErrEncrypt)
+	}
+	if clone.signer.Version() == SIGN_VERSION_MD5 {
+		return fmt.Errorf("%w: %s", ErrLegacyUnsupported, "下载")
+	}
+	return nil
+}
+
+// DownloadFile
+//
+//	@Description: 下载到文件，内容先写入<path>.sapipart，下载状态写入<path>.sapidownload，完成并校验后重命名为path。
+//	进程重启后使用相同的参数调用即从已下载的位置续传，服务端内容已变化时重新下载。
+//	下载期间对<path>.sapipart加排它锁，同一文件已在下载时返回ErrDownloadLocked
+//	@receiver c
+//	@Author zzh 2026-10-20 03:22:05
+//	@param ctx
+//	@param service
+//	@param method
+//	@param body
+//	@param path
+//	@param options
+//	@return *DownloadResult
+//	@return error
+func (c *sApiClient) DownloadFile(ctx context.Context, service, method string, body map[string]interface{}, path string, options DownloadOptions) (*DownloadResult, error) {
+	if err := c.checkDownload(service, method, options.CallOptions); err != nil {
+		return nil, err
+	}
+	partPath, statePath := path+DOWNLOAD_PART_SUFFIX, path+DOWNLOAD_STATE_SUFFIX
+	file, err := os.OpenFile(partPath, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	unlock, err := lockFile(file)
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	defer unlock()
+	d := c.newDownload(service, method, body, options, &fileTarget{File: file})
+	state := &downloadState{Service: service, Method: method, Params: paramsDigest(body), Size: -1}
+	offset := int64(0)
+	if saved := loadDownloadState(statePath, state); saved != nil {
+		if info, statErr := file.Stat(); statErr == nil {
+			offset = info.Size()
+		}
+		if saved.Size >= 0 && offset > saved.Size {
+			offset = 0
+		}
+		if offset > 0 {
+			d.checksum, d.etag, d.total = saved.Checksum, saved.ETag, saved.Size
+		}
+	}
+	if err = d.rewind(offset); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	d.saveState = func() error {
+		state.Checksum, state.ETag, state.Size = d.checksum, d.etag, d.total
+		return state.save(statePath)
+	}
+	result, err := d.run(ctx)
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		if errors.Is(err, ErrChecksumMismatch) {
+			// 内容已损坏，下次重新下载
+			_ = os.Remove(partPath)
+			_ = os.Remove(statePath)
+		}
+		return nil, err
+	}
+	if err = os.Rename(partPath, path); err != nil {
+		return nil, err
+	}
+	_ = os.Remove(statePath)
+	return result, nil
+}
+
+// run
+//
+//	@Description: 发送请求直到内容下载完毕，连续未取得进展的失败次数超过Retries时返回最后的错误，最后校验sha256。
+//	内容变化后立即重新下载，不计入失败次数，超过DOWNLOAD_MAX_RESTARTS次时返回ErrContentChanged
+//	@receiver d
+//	@Author zzh 2026-10-20 03:24:30
+//	@param ctx
+//	@return *DownloadResult
+//	@return error
+func (d *download) run(ctx context.Context) (*DownloadResult, error) {
+	failures, restarts := 0, 0
+	wait := d.options.RetryWait
+	for {
+		before := d.written
+		retry, err := d.fetch(ctx)
+		if err == nil {
+			break
+		}
+		if !retry || ctx.Err() != nil {
+			return nil, err
+		}
+		if errors.Is(err, ErrContentChanged) {
+			if restarts++; restarts > DOWNLOAD_MAX_RESTARTS {
+				return nil, d.fail(d.statusCode, fmt.Errorf("%w: 重新下载%d次后内容仍在变化", ErrContentChanged, DOWNLOAD_MAX_RESTARTS))
+			}
+			failures, wait = 0, d.options.RetryWait
+			continue
+		}
+		if d.written > before {
+			failures, wait = 0, d.options.RetryWait
+		}
+		if failures++; failures > d.options.Retries {
+			return nil, err
+		}
+		select {
+		case <-time.After(wait):
+			wait *= 2
+		case <-ctx.Done():
+			return nil, &DownloadError{Offset: d.written, Err: ctx.Err()}
+		}
+	}
+	checksum := hex.EncodeToString(d.hash.Sum(nil))
+	expected := d.options.Checksum
+	if expected == "" {
+		expected = d.checksum
+	}
+	if expected != "" && expected != checksum {
+		return nil, &DownloadError{Offset: d.written, StatusCode: d.statusCode, Err: ErrChecksumMismatch}
+	}
+	return &DownloadResult{StatusCode: d.statusCode, Header: d.header, Size: d.written, Checksum: checksum, Requests: d.requests}, nil
+}
+
+// fetch
+//
+//	@Description: 发送一次请求并写入响应内容，已写入内容时请求剩余部分。retry表示出错后可以续传
+//	@receiver d
+//	@Author zzh 2026-10-20 03:27:15
+//	@param ctx
+//	@return retry
+//	@return err
+func (d *download) fetch(ctx context.Context) (retry bool, err error) {
+	options := d.options.CallOptions
+	headers := make(map[string]string, len(options.Headers)+3)
+	for key, val := range options.Headers {
+		headers[key] = val
+	}
+	// Range按未压缩的内容计算
+	headers["Accept-Encoding"] = "identity"
+	if d.written > 0 {
+		headers[RANGE_HEADER] = "bytes=" + strconv.FormatInt(d.written, 10) + "-"
+		if d.etag != "" {
+			headers[IF_RANGE_HEADER] = d.etag
+		}
+	}
+	options.Headers = headers
+	if d.requests > 0 {
+		// 每次续传使用新的nonce
+		options.Nonce = ""
+	}
+	clone := d.client.cloneForCall(d.service, d.method, options)
+	clone.ClientOptions.RetryCount = 0
+	d.requests++
+	req, res, sentAt, err := clone.send(ctx, d.body, true)
+	if req == nil {
+		return false, err
+	}
+	defer func() {
+		clone.logRequest(req, res.StatusCode(), sentAt, err)
+	}()
+	if err != nil {
+		// 超过大小限制与证书错误时重试的结果相同
+		return !errors.Is(err, ErrSizeLimit) && !errors.Is(err, ErrTLS), d.fail(0, err)
+	}
+	rawBody := res.RawBody()
+	defer rawBody.Close()
+	statusCode, header := res.StatusCode(), res.Header()
+	if statusCode == http.StatusRequestedRangeNotSatisfiable && d.written > 0 {
+		return d.restart(statusCode)
+	}
+	checksum := strings.ToLower(header.Get(DOWNLOAD_CHECKSUM_HEADER))
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	if res.IsError() || (checksum == "" && mediaType == "application/json") {
+		// 服务端错误或以code、msg返回的业务错误
+		content, _ := ioutil.ReadAll(io.LimitReader(rawBody, STREAM_ERROR_BODY_LIMIT))
+		responseData := &ResponseData{}
+		_ = json.Unmarshal(content, responseData)
+		if err = clone.clock.clockSkewError(statusCode, responseData); err != nil {
+			return false, d.fail(statusCode, err)
+		}
+		err = &DownloadError{Offset: d.written, StatusCode: statusCode, Code: responseData.Code, Msg: responseData.Msg}
+		return statusCode >= http.StatusInternalServerError, err
+	}
+	etag := header.Get("ETag")
+	if d.written > 0 && ((d.checksum != "" && checksum != d.checksum) || (d.etag != "" && etag != d.etag)) {
+		if statusCode != http.StatusOK {
+			return d.restart(statusCode)
+		}
+		// 服务端按If-Range返回了全部新内容，丢弃已写入的内容后继续读取
+		if err = d.rewind(0); err != nil {
+			return false, d.fail(statusCode, fmt.Errorf("%w: %s", ErrContentChanged, err.Error()))
+		}
+	}
+	start, total := int64(0), res.RawResponse.ContentLength
+	if statusCode == http.StatusPartialContent {
+		if start, total, err = parseContentRange(header.Get("Content-Range")); err != nil {
+			return false, d.fail(statusCode, err)
+		}
+		if start > d.written {
+			return false, d.fail(statusCode, errors.New("Content-Range与请求的Range不一致"))
+		}
+	}
+	d.checksum, d.etag, d.total, d.statusCode, d.header = checksum, etag, total, statusCode, header
+	if d.saveState != nil {
+		if err = d.saveState(); err != nil {
+			return false, d.fail(statusCode, err)
+		}
+	}
+	reader, finish, err := clone.streamVerifier(req, header, rawBody)
+	if err != nil {
+		return false, d.fail(statusCode, err)
+	}
+	if start < d.written {
+		// 服务端未按Range返回，跳过已写入的部分
+		if _, err = io.CopyN(ioutil.Discard, reader, d.written-start); err != nil {
+			return true, d.fail(statusCode, err)
+		}
+	}
+	segment := d.written
+	_, err = io.Copy(&downloadWriter{d: d}, reader)
+	if err == nil && finish != nil {
+		err = finish()
+	}
+	if err != nil {
+		if finish != nil && d.written > segment {
+			// 未通过响应签名校验的内容不保留，无法丢弃时不续传
+			if rewindErr := d.rewind(segment); rewindErr != nil {
+				return false, d.fail(statusCode, err)
+			}
+		}
+		retry = !errors.Is(err, ErrResponseSignature) && !errors.Is(err, ErrSizeLimit) && !errors.Is(err, ErrDecompressionLimit)
+		return retry, d.fail(statusCode, err)
+	}
+	if d.total >= 0 && d.written != d.total {
+		return true, d.fail(statusCode, io.ErrUnexpectedEOF)
+	}
+	return false, nil
+}
+
+// restart
+//
+//	@Description: 服务端内容已变化，丢弃已写入的内容后重新下载；写入io.Writer时无法丢弃，返回不可续传的ErrContentChanged
+//	@receiver d
+//	@Author zzh 2026-10-20 03:30:02
+//	@param statusCode
+//	@return bool
+//	@return error
+func (d *download) restart(statusCode int) (bool, error) {
+	if err := d.rewind(0); err != nil {
+		return false, d.fail(statusCode, fmt.Errorf("%w: %s", ErrContentChanged, err.Error()))
+	}
+	d.checksum, d.etag, d.total = "", "", -1
+	return true, d.fail(statusCode, ErrContentChanged)
+}
+
+// rewind
+//
+//	@Description: 回退到offset
+//	@receiver d
+//	@Author zzh 2026-10-20 03:30:45
+//	@param offset
+//	@return error
+func (d *download) rewind(offset int64) error {
+	h, err := d.target.rewind(offset)
+	if err != nil {
+		return err
+	}
+	d.hash, d.written = h, offset
+	return nil
+}
+
+// fail
+//
+//	@Description: 生成DownloadError
+//	@receiver d
+//	@Author zzh 2026-10-20 03:31:10
+//	@param statusCode
+//	@param err
+//	@return error
+func (d *download) fail(statusCode int, err error) error {
+	return &DownloadError{Offset: d.written, StatusCode: statusCode, Err: err}
+}
+
+// downloadWriter
+// @Description: 写入目标并计算sha256、报告进度
+type downloadWriter struct {
+	d *download
+}
+
+// Write
+//
+//	@Description: 写入内容
+//	@receiver w
+//	@Author zzh 2026-10-20 03:31:48
+//	@param p
+//	@return int
+//	@return error
+func (w *downloadWriter) Write(p []byte) (int, error) {
+	d := w.d
+	n, err := d.target.Write(p)
+	d.hash.Write(p[:n])
+	d.written += int64(n)
+	if n > 0 && d.options.Progress != nil {
+		d.options.Progress(d.written, d.total)
+	}
+	return n, err
+}
+
+// parseContentRange
+//
+//	@Description: 解析Content-Range: bytes start-end/total，total为*时返回-1
+//	@Author zzh 2026-10-20 03:32:30
+//	@param value
+//	@return start
+//	@return total
+//	@return err
+func parseContentRange(value string) (start, total int64, err error) {
+	spec := strings.TrimPrefix(value, "bytes ")
+	slash := strings.IndexByte(spec, '/')
+	if spec == value || slash < 0 {
+		return 0, 0, errors.New("Content-Range格式不正确: " + value)
+	}
+	dash := strings.IndexByte(spec[:slash], '-')
+	if dash < 0 {
+		return 0, 0, errors.New("Content-Range格式不正确: " + value)
+	}
+	if start, err = strconv.ParseInt(spec[:dash], 10, 64); err != nil {
+		return 0, 0, errors.New("Content-Range格式不正确: " + value)
+	}
+	if spec[slash+1:] == "*" {
+		return start, -1, nil
+	}
+	if total, err = strconv.ParseInt(spec[slash+1:], 10, 64); err != nil {
+		return 0, 0, errors.New("Content-Range格式不正确: " + value)
+	}
+	return start, total, nil
+}
+
+// downloadState
+// @Description: 持久化的下载状态，进程重启后据此续传
+type downloadState struct {
+	Service  string `json:"service"`
+	Method   string `json:"method"`
+	Params   string `json:"params"` //请求参数的sha256
+	Checksum string `json:"checksum"`
+	ETag     string `json:"etag"`
+	Size     int64  `json:"size"` //内容大小，-1表示未知
+}
+
+// loadDownloadState
+//
+//	@Description: 读取状态文件，文件不存在、内容无法解析、与本次下载不一致或无法判断内容是否变化时返回nil
+//	@Author zzh 2026-10-20 03:34:12
+//	@param path
+//	@param current
+//	@return *downloadState
+func loadDownloadState(path string, current *downloadState) *downloadState {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	state := &downloadState{}
+	if json.Unmarshal(content, state) != nil {
+		return nil
+	}
+	if state.Service != current.Service || state.Method != current.Method || state.Params != current.Params ||
+		(state.Checksum == "" && state.ETag == "") {
+		return nil
+	}
+	return state
+}
+
+// save
+//
+//	@Description: 写入状态文件，先写临时文件再重命名
+//	@receiver s
+//	@Author zzh 2026-10-20 03:35:02
+//	@param path
+//	@return error
+func (s *downloadState) save(path string) error {
+	content, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err = ioutil.WriteFile(tmp, content, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// paramsDigest
+//
+//	@Description: 请求参数的sha256，用于判断状态文件是否属于同一次下载
+//	@Author zzh 2026-10-20 03:35:40
+//	@param body
+//	@return string
+func paramsDigest(body map[string]interface{}) string {
+	content, _ := json.Marshal(body)
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// ServeDownload
+//
+//	@Description: 服务端返回下载内容，设置content-sha256与ETag并按Range返回部分内容。
+//	标准库只对GET请求处理If-Range，这里对sapi的POST请求同样处理：If-Range与ETag不一致时返回全部内容。
+//	需要在签名校验之后调用，checksum为空时读取一遍content计算
+//	@Author zzh 2026-10-20 03:37:20
+//	@param w
+//	@param r
+//	@param content
+//	@param checksum 内容的sha256（十六进制）
+func ServeDownload(w http.ResponseWriter, r *http.Request, content io.ReadSeeker, checksum string) {
+	if checksum == "" {
+		h := sha256.New()
+		if _, err := io.Copy(h, content); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if _, err := content.Seek(0, io.SeekStart); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		checksum = hex.EncodeToString(h.Sum(nil))
+	}
+	checksum = strings.ToLower(checksum)
+	header := w.Header()
+	header.Set(DOWNLOAD_CHECKSUM_HEADER, checksum)
+	if header.Get("ETag") == "" {
+		header.Set("ETag", `"`+checksum+`"`)
+	}
+	if header.Get("Content-Type") == "" {
+		header.Set("Content-Type", DEFAULT_UPLOAD_CONTENT_TYPE)
+	}
+	if ifRange := r.Header.Get(IF_RANGE_HEADER); ifRange != "" && r.Method != http.MethodGet && r.Method != http.MethodHead {
+		if ifRange != header.Get("ETag") {
+			r = r.Clone(r.Context())
+			r.Header.Del(RANGE_HEADER)
+		}
+	}
+	http.ServeContent(w, r, "", time.Time{}, content)
+}
@@ -0,0 +1,199 @@
+package sapiclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// truncatingWriter 写入limit字节后返回错误，模拟连接中断
+type truncatingWriter struct {
+	http.ResponseWriter
+	limit int
+}
+
+func (w *truncatingWriter) Write(p []byte) (int, error) {
+	if len(p) > w.limit {
+		n, _ := w.ResponseWriter.Write(p[:w.limit])
+		w.limit = 0
+		return n, errors.New("truncated")
+	}
+	w.limit -= len(p)
+	return w.ResponseWriter.Write(p)
+}
+
+// downloadServer 使用ServeDownload返回content，truncate次请求在limit字节后中断
+type downloadServer struct {
+	mu       sync.Mutex
+	content  []byte
+	checksum string //不为空时代替内容的sha256返回
+	ifRange  bool   //是否按If-Range判断内容变化
+	truncate int
+	limit    int
+	next     []byte //不为空时中断的请求结束后替换content
+	ranges   []string
+}
+
+func (s *downloadServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	content, checksum, truncate := s.content, s.checksum, s.truncate > 0
+	s.ranges = append(s.ranges, r.Header.Get(RANGE_HEADER))
+	if truncate {
+		s.truncate--
+		w = &truncatingWriter{ResponseWriter: w, limit: s.limit}
+	}
+	if !s.ifRange {
+		r.Header.Del(IF_RANGE_HEADER)
+	}
+	s.mu.Unlock()
+	ServeDownload(w, r, bytes.NewReader(content), checksum)
+	if truncate && s.next != nil {
+		s.setContent(s.next)
+	}
+}
+
+func (s *downloadServer) setContent(content []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.content = content
+}
+
+func newDownloadTest(t *testing.T, size int) (*downloadServer, *sApiClient, func()) {
+	content := make([]byte, size)
+	rand.Read(content)
+	s := &downloadServer{content: content, ifRange: true, limit: size / 4}
+	server := httptest.NewServer(s)
+	c, err := newClient(WithCredentials("key", "secret"), WithBaseURL(server.URL), WithSigner(HMACSHA256Signer{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, c, server.Close
+}
+
+func TestDownloadRangeResume(t *testing.T) {
+	s, c, closeServer := newDownloadTest(t, 200000)
+	defer closeServer()
+	s.truncate = 2
+	var buf bytes.Buffer
+	res, err := c.Download(context.Background(), "report", "export", nil, &buf, DownloadOptions{RetryWait: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), s.content) || res.Requests != 3 {
+		t.Fatalf("size = %d, requests = %d", buf.Len(), res.Requests)
+	}
+	want := []string{"", "bytes=50000-", "bytes=100000-"}
+	for i, val := range want {
+		if s.ranges[i] != val {
+			t.Fatalf("request %d Range = %q, want %q", i, s.ranges[i], val)
+		}
+	}
+}
+
+func TestDownloadFileResume(t *testing.T) {
+	s, c, closeServer := newDownloadTest(t, 200000)
+	defer closeServer()
+	path := filepath.Join(t.TempDir(), "report.bin")
+	s.truncate = 1
+	_, err := c.DownloadFile(context.Background(), "report", "export", nil, path, DownloadOptions{Retries: -1})
+	if !errors.Is(err, ErrDownload) {
+		t.Fatalf("interrupted err = %v", err)
+	}
+	if info, statErr := os.Stat(path + DOWNLOAD_PART_SUFFIX); statErr != nil || info.Size() != 50000 {
+		t.Fatalf("part file = %v, %v", info, statErr)
+	}
+	res, err := c.DownloadFile(context.Background(), "report", "export", nil, path, DownloadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := ioutil.ReadFile(path)
+	if !bytes.Equal(got, s.content) || res.Requests != 1 || s.ranges[1] != "bytes=50000-" {
+		t.Fatalf("requests = %d, Range = %q", res.Requests, s.ranges[1])
+	}
+	for _, suffix := range []string{DOWNLOAD_PART_SUFFIX, DOWNLOAD_STATE_SUFFIX} {
+		if _, statErr := os.Stat(path + suffix); !os.IsNotExist(statErr) {
+			t.Fatalf("%s left after download", suffix)
+		}
+	}
+}
+
+func TestDownloadChecksumMismatch(t *testing.T) {
+	s, c, closeServer := newDownloadTest(t, 10000)
+	defer closeServer()
+	_, err := c.Download(context.Background(), "report", "export", nil, &bytes.Buffer{}, DownloadOptions{Checksum: "abc"})
+	if !errors.Is(err, ErrChecksumMismatch) || !errors.Is(err, ErrDownload) {
+		t.Fatalf("err = %v, want ErrChecksumMismatch", err)
+	}
+	sum := sha256.Sum256([]byte("other"))
+	s.checksum = hex.EncodeToString(sum[:])
+	path := filepath.Join(t.TempDir(), "report.bin")
+	if _, err = c.DownloadFile(context.Background(), "report", "export", nil, path, DownloadOptions{}); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("file err = %v, want ErrChecksumMismatch", err)
+	}
+	if _, statErr := os.Stat(path + DOWNLOAD_PART_SUFFIX); !os.IsNotExist(statErr) {
+		t.Fatal("corrupted part file kept")
+	}
+}
+
+func TestDownloadContentChanged(t *testing.T) {
+	s, c, closeServer := newDownloadTest(t, 200000)
+	defer closeServer()
+	path := filepath.Join(t.TempDir(), "report.bin")
+	s.truncate = 1
+	if _, err := c.DownloadFile(context.Background(), "report", "export", nil, path, DownloadOptions{Retries: -1}); err == nil {
+		t.Fatal("interrupted download succeeded")
+	}
+	// 服务端忽略If-Range按Range返回新内容，重新下载不计入续传次数
+	s.ifRange = false
+	changed := append([]byte{}, s.content...)
+	changed[0] ^= 1
+	s.setContent(changed)
+	res, err := c.DownloadFile(context.Background(), "report", "export", nil, path, DownloadOptions{Retries: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := ioutil.ReadFile(path)
+	if !bytes.Equal(got, changed) || res.Requests != 2 {
+		t.Fatalf("requests = %d", res.Requests)
+	}
+
+	// 已写入io.Writer的内容无法丢弃
+	s.ifRange, s.truncate, s.next = true, 1, changed[1:]
+	_, err = c.Download(context.Background(), "report", "export", nil, &bytes.Buffer{}, DownloadOptions{RetryWait: time.Millisecond})
+	if !errors.Is(err, ErrContentChanged) {
+		t.Fatalf("writer err = %v, want ErrContentChanged", err)
+	}
+}
+
+func TestDownloadFileLocked(t *testing.T) {
+	_, c, closeServer := newDownloadTest(t, 1000)
+	defer closeServer()
+	path := filepath.Join(t.TempDir(), "report.bin")
+	file, err := os.OpenFile(path+DOWNLOAD_PART_SUFFIX, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unlock, err := lockFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.DownloadFile(context.Background(), "report", "export", nil, path, DownloadOptions{}); !errors.Is(err, ErrDownloadLocked) {
+		t.Fatalf("err = %v, want ErrDownloadLocked", err)
+	}
+	unlock()
+	_ = file.Close()
+	if _, err = c.DownloadFile(context.Background(), "report", "export", nil, path, DownloadOptions{}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,375 @@
+package sapiclient
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+const (
+	//加密版本请求头
+	ENCRYPT_VERSION_HEADER = "encrypt-version"
+	//加密密钥id请求头
+	ENCRYPT_KEY_ID_HEADER = "encrypt-key-id"
+	//当前加密版本 AES-256-GCM
+	ENCRYPT_VERSION = "1"
+	//由appSecret派生密钥时使用的默认key id
+	ENCRYPT_DEFAULT_KEY_ID = "secret"
+)
+
+var (
+	// ErrEncrypt 加解密失败
+	ErrEncrypt = errors.New("报文加解密失败")
+	// ErrEncryptKeyNotFound 找不到key id对应的密钥
+	ErrEncryptKeyNotFound = errors.New("加密密钥不存在")
+)
+
+// EncryptKeys
+// @Description: 报文加密密钥，支持按key id轮换
+type EncryptKeys struct {
+	CurrentKeyId string            //加密使用的key id
+	Keys         map[string][]byte //key id -> 32字节密钥，解密时按响应的key id查找
+}
+
+// encryptEnvelope
+// @Description: 加密报文
+type encryptEnvelope struct {
+	Iv   string `json:"iv"`   //GCM随机数 base64
+	Data string `json:"data"` //密文 base64
+}
+
+// DeriveEncryptKey
+//
+//	@Description: 使用HKDF-SHA256从appSecret派生32字节密钥，salt为"sapi-encrypt"，info为key id
+//	@Author zzh 2026-10-19 16:40:05
+//	@param appSecret
+//	@param keyId
+//	@return []byte
+func DeriveEncryptKey(appSecret, keyId string) []byte {
+	extract := hmac.New(sha256.New, []byte("sapi-encrypt"))
+	extract.Write([]byte(appSecret))
+	expand := hmac.New(sha256.New, extract.Sum(nil))
+	expand.Write([]byte(keyId))
+	expand.Write([]byte{1})
+	return expand.Sum(nil)
+}
+
+// EncryptPayload
+//
+//	@Description: AES-GCM加密报文，aad绑定方向、appKey、路径与nonce，防止密文被挪用
+//	@Author zzh 2026-10-19 16:43:21
+//	@param key
+//	@param plaintext
+//	@param aad
+//	@return []byte json格式的加密报文
+//	@return error
+func EncryptPayload(key, plaintext []byte, aad string) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	iv := make([]byte, aead.NonceSize())
+	if _, err = rand.Read(iv); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrEncrypt, err.Error())
+	}
+	return json.Marshal(&encryptEnvelope{
+		Iv:   base64.StdEncoding.EncodeToString(iv),
+		Data: base64.StdEncoding.EncodeToString(aead.Seal(nil, iv, plaintext, []byte(aad))),
+	})
+}
+
+// DecryptPayload
+//
+//	@Description: 解密EncryptPayload生成的报文
+//	@Author zzh 2026-10-19 16:45:48
+//	@param key
+//	@param payload
+//	@param aad
+//	@return []byte
+//	@return error
+func DecryptPayload(key, payload []byte, aad string) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	envelope := &encryptEnvelope{}
+	if err = json.Unmarshal(payload, envelope); err != nil {
+		return nil, fmt.Errorf("%w: 加密报文格式不正确", ErrEncrypt)
+	}
+	iv, ivErr := base64.StdEncoding.DecodeString(envelope.Iv)
+	data, dataErr := base64.StdEncoding.DecodeString(envelope.Data)
+	if ivErr != nil || dataErr != nil || len(iv) != aead.NonceSize() {
+		return nil, fmt.Errorf("%w: 加密报文格式不正确", ErrEncrypt)
+	}
+	plaintext, err := aead.Open(nil, iv, data, []byte(aad))
+	if err != nil {
+		return nil, ErrEncrypt
+	}
+	return plaintext, nil
+}
+
+// encryptAad
+//
+//	@Description: 生成附加认证数据
+//	@Author zzh 2026-10-19 16:47:10
+//	@param direction req或resp
+//	@param appKey
+//	@param pathUrl
+//	@param nonce
+//	@return string
+func encryptAad(direction, appKey, pathUrl, nonce string) string {
+	return direction + "\n" + appKey + "\n" + strings.ToLower(strings.Trim(pathUrl, "/")) + "\n" + nonce
+}
+
+// newGCM
+//
+//	@Description: 创建AES-GCM
+//	@Author zzh 2026-10-19 16:48:22
+//	@param key
+//	@return cipher.AEAD
+//	@return error
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrEncrypt, err.Error())
+	}
+	return cipher.NewGCM(block)
+}
+
+// keyOf
+//
+//	@Description: 获取加密使用的key id与密钥，未配置密钥时由appSecret派生
+//	@receiver k
+//	@Author zzh 2026-10-19 16:50:36
+//	@param keyId 为空时使用当前key id
+//	@param appSecret
+//	@return string
+//	@return []byte
+//	@return error
+func (k *EncryptKeys) keyOf(keyId, appSecret string) (string, []byte, error) {
+	if k == nil || len(k.Keys) == 0 {
+		if keyId == "" {
+			keyId = ENCRYPT_DEFAULT_KEY_ID
+		}
+		return keyId, DeriveEncryptKey(appSecret, keyId), nil
+	}
+	if keyId == "" {
+		keyId = k.CurrentKeyId
+	}
+	key, ok := k.Keys[keyId]
+	if !ok {
+		return keyId, nil, fmt.Errorf("%w: %s", ErrEncryptKeyNotFound, keyId)
+	}
+	return keyId, key, nil
+}
+
+// SetEncryption
+//
+//	@Description: 开启报文加密，请求体加密发送、响应透明解密；keys为nil时由appSecret派生密钥
+//	@receiver c
+//	@Author zzh 2026-10-19 16:53:02
+//	@param enabled
+//	@param keys
+//	@return *sApiClient
+func (c *sApiClient) SetEncryption(enabled bool, keys *EncryptKeys) *sApiClient {
+	c.encrypt = enabled
+	c.encryptKeys = keys
+	return c
+}
+
+// encryptRequest
+//
+//	@Description: 加密请求体并设置加密请求头
+//	@receiver c
+//	@Author zzh 2026-10-19 16:55:40
+//	@param req
+//	@param headers
+//	@return error
+func (c *sApiClient) encryptRequest(req *preparedRequest, headers map[string]string) error {
+	if req.httpMethod == http.MethodGet {
+		return errors.New("报文加密仅支持POST请求")
+	}
+	keyId, key, err := req.encryptKeys.keyOf("", req.appSecret)
+	if err != nil {
+		return err
+	}
+	body, err := EncryptPayload(key, req.body, encryptAad("req", req.appKey, req.pathUrl, headers["nonce"]))
+	if err != nil {
+		return err
+	}
+	req.body = body
+	headers[ENCRYPT_VERSION_HEADER] = ENCRYPT_VERSION
+	headers[ENCRYPT_KEY_ID_HEADER] = keyId
+	return nil
+}
+
+// decryptResponse
+//
+//	@Description: 解密响应体，开启加密时响应必须是加密报文
+//	@receiver c
+//	@Author zzh 2026-10-19 16:58:14
+//	@param req
+//	@param header
+//	@param body
+//	@return []byte
+//	@return error
+func (c *sApiClient) decryptResponse(req *preparedRequest, header http.Header, body []byte) ([]byte, error) {
+	if !req.encrypt {
+		return body, nil
+	}
+	if header.Get(ENCRYPT_VERSION_HEADER) != ENCRYPT_VERSION {
+		return nil, fmt.Errorf("%w: 响应未加密", ErrEncrypt)
+	}
+	_, key, err := req.encryptKeys.keyOf(header.Get(ENCRYPT_KEY_ID_HEADER), req.appSecret)
+	if err != nil {
+		return nil, err
+	}
+	return DecryptPayload(key, body, encryptAad("resp", req.appKey, req.pathUrl, req.headers["nonce"]))
+}
+
+// decryptErrorResponse
+//
+//	@Description: 解密4xx、5xx响应并解析code、msg。EncryptMiddleware同样加密业务handler返回的错误响应，
+//	未加密的错误响应（如签名校验失败、解密失败）原样返回
+//	@receiver c
+//	@Author zzh 2026-10-20 04:31:15
+//	@param req
+//	@param header
+//	@param body
+//	@return []byte
+//	@return *ResponseData 未加密时为nil
+//	@return error
+func (c *sApiClient) decryptErrorResponse(req *preparedRequest, header http.Header, body []byte) ([]byte, *ResponseData, error) {
+	if !req.encrypt || header.Get(ENCRYPT_VERSION_HEADER) == "" {
+		return body, nil, nil
+	}
+	plaintext, err := c.decryptResponse(req, header, body)
+	if err != nil {
+		return body, nil, err
+	}
+	responseData := &ResponseData{}
+	_ = json.Unmarshal(plaintext, responseData)
+	return plaintext, responseData, nil
+}
+
+// EncryptOptions
+// @Description: 服务端报文加密配置
+type EncryptOptions struct {
+	SecretStore SecretStore  //未在Keys中找到key id时使用appSecret派生密钥
+	Keys        *EncryptKeys //单独配置的密钥
+	PathPrefix  string       //服务挂载前缀，与VerifyOptions一致
+	MaxBodySize int64        //解密前读取的请求体大小上限 字节，默认DEFAULT_MAX_BODY_SIZE
+	Required    bool         //是否拒绝未加密的请求，为false时未加密的请求原样交给next处理
+}
+
+// EncryptMiddleware
+//
+//	@Description: 服务端报文加解密中间件，解密加密请求体后交给next处理，并使用同一密钥加密响应，
+//	包括next返回的4xx、5xx响应。未加密的请求默认原样交给next处理，Required为true时返回400。
+//	与VerifyMiddleware同时使用时应放在其内层，使签名覆盖密文
+//	@Author zzh 2026-10-19 17:02:51
+//	@param options
+//	@return func(http.Handler) http.Handler
+func EncryptMiddleware(options EncryptOptions) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get(ENCRYPT_VERSION_HEADER) == "" {
+				if options.Required {
+					writeEncryptError(w, fmt.Errorf("%w: 请求未加密", ErrEncrypt))
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+			appKey := r.Header.Get("appkey")
+			keyId := r.Header.Get(ENCRYPT_KEY_ID_HEADER)
+			key, err := options.lookupKey(appKey, keyId)
+			if err != nil {
+				writeEncryptError(w, err)
+				return
+			}
+			pathUrl := signPath(r, options.PathPrefix)
+			nonce := r.Header.Get("nonce")
+			body, err := readLimitedBody(r, options.MaxBodySize)
+			if err != nil {
+				writeEncryptError(w, err)
+				return
+			}
+			plaintext, err := DecryptPayload(key, body, encryptAad("req", appKey, pathUrl, nonce))
+			if err != nil {
+				writeEncryptError(w, err)
+				return
+			}
+			r.Body = ioutil.NopCloser(bytes.NewReader(plaintext))
+			r.ContentLength = int64(len(plaintext))
+			recorder := &bufferedResponseWriter{header: make(http.Header), statusCode: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+			payload, err := EncryptPayload(key, recorder.body.Bytes(), encryptAad("resp", appKey, pathUrl, nonce))
+			if err != nil {
+				writeEncryptError(w, err)
+				return
+			}
+			for name, values := range recorder.header {
+				w.Header()[name] = values
+			}
+			w.Header().Del("Content-Length")
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set(ENCRYPT_VERSION_HEADER, ENCRYPT_VERSION)
+			w.Header().Set(ENCRYPT_KEY_ID_HEADER, keyId)
+			w.WriteHeader(recorder.statusCode)
+			_, _ = w.Write(payload)
+		})
+	}
+}
+
+// lookupKey
+//
+//	@Description: 服务端按key id查找密钥
+//	@receiver o
+//	@Author zzh 2026-10-19 17:05:19
+//	@param appKey
+//	@param keyId
+//	@return []byte
+//	@return error
+func (o EncryptOptions) lookupKey(appKey, keyId string) ([]byte, error) {
+	if o.Keys != nil {
+		if key, ok := o.Keys.Keys[keyId]; ok {
+			return key, nil
+		}
+	}
+	if o.SecretStore == nil {
+		return nil, fmt.Errorf("%w: %s", ErrEncryptKeyNotFound, keyId)
+	}
+	appSecret, err := o.SecretStore.GetSecret(appKey)
+	if err != nil {
+		return nil, err
+	}
+	return DeriveEncryptKey(appSecret, keyId), nil
+}
+
+// writeEncryptError
+//
+//	@Description: 输出解密失败信息
+//	@Author zzh 2026-10-19 17:06:44
+//	@param w
+//	@param err
+func writeEncryptError(w http.ResponseWriter, err error) {
+	statusCode := http.StatusBadRequest
+	if errors.Is(err, ErrBodyTooLarge) {
+		statusCode = http.StatusRequestEntityTooLarge
+	}
+	content, _ := json.Marshal(&ResponseData{Code: statusCode, Msg: err.Error()})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_, _ = w.Write(content)
+}
@@ -0,0 +1,153 @@
+package sapiclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestEncryptPayloadRoundTrip(t *testing.T) {
+	key := DeriveEncryptKey("secret", ENCRYPT_DEFAULT_KEY_ID)
+	aad := encryptAad("req", "key", "sapi/demo/echo", "nonce")
+	payload, err := EncryptPayload(key, []byte(`{"data":"hello"}`), aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err := DecryptPayload(key, payload, aad)
+	if err != nil || string(plaintext) != `{"data":"hello"}` {
+		t.Fatalf("decrypt = %q, %v", plaintext, err)
+	}
+
+	envelope := encryptEnvelope{}
+	if err = json.Unmarshal(payload, &envelope); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := base64.StdEncoding.DecodeString(envelope.Data)
+	data[0] ^= 1
+	envelope.Data = base64.StdEncoding.EncodeToString(data)
+	tampered, _ := json.Marshal(envelope)
+	if _, err = DecryptPayload(key, tampered, aad); err == nil {
+		t.Fatal("tampered ciphertext accepted")
+	}
+	if _, err = DecryptPayload(key, payload, encryptAad("req", "key", "sapi/demo/other", "nonce")); err == nil {
+		t.Fatal("payload accepted for another path")
+	}
+	if _, err = DecryptPayload(DeriveEncryptKey("secret", "other"), payload, aad); err == nil {
+		t.Fatal("payload accepted with another key")
+	}
+}
+
+func TestEncryptMiddleware(t *testing.T) {
+	var received string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		received = string(body)
+		w.Header().Set("Content-Type", "application/json")
+		if strings.Contains(received, "fail") {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"code":40001,"msg":"参数错误"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"code":200,"msg":"success","data":"ok"}`))
+	})
+	server := httptest.NewServer(EncryptMiddleware(EncryptOptions{
+		SecretStore: StaticSecretStore{"key": "secret"},
+		MaxBodySize: 1024,
+	})(handler))
+	defer server.Close()
+	c, err := newClient(WithCredentials("key", "secret"), WithBaseURL(server.URL), WithEncryption(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetService("demo").SetMethod("echo")
+
+	responseData, err := c.DoRequest(map[string]interface{}{"data": "hello"})
+	if err != nil || responseData.Data != "ok" {
+		t.Fatalf("response = %+v, %v", responseData, err)
+	}
+	if received != `{"data":"hello"}` {
+		t.Fatalf("handler received %q", received)
+	}
+	if c.RawResponseHeader.Get(ENCRYPT_VERSION_HEADER) != ENCRYPT_VERSION {
+		t.Fatal("response not encrypted")
+	}
+
+	// 业务handler返回的错误响应同样加密，客户端解密后返回code、msg
+	responseData, err = c.DoRequest(map[string]interface{}{"data": "fail"})
+	if err == nil || responseData == nil || responseData.Code != 40001 || responseData.Msg != "参数错误" {
+		t.Fatalf("error response = %+v, %v", responseData, err)
+	}
+	if !strings.Contains(err.Error(), "40001") {
+		t.Fatalf("err = %v", err)
+	}
+
+	// 未加密的错误响应原样解析
+	responseData, err = c.DoRequest(map[string]interface{}{"data": strings.Repeat("a", 2048)})
+	if err == nil || responseData == nil || responseData.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("oversized response = %+v, %v", responseData, err)
+	}
+}
+
+func TestEncryptMiddlewareBodyLimit(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("next called for oversized body")
+	})
+	r := httptest.NewRequest(http.MethodPost, "/sapi/demo/echo", bytes.NewReader(make([]byte, 2048)))
+	r.Header.Set(ENCRYPT_VERSION_HEADER, ENCRYPT_VERSION)
+	r.Header.Set(ENCRYPT_KEY_ID_HEADER, ENCRYPT_DEFAULT_KEY_ID)
+	r.Header.Set("appkey", "key")
+	recorder := httptest.NewRecorder()
+	EncryptMiddleware(EncryptOptions{SecretStore: StaticSecretStore{"key": "secret"}, MaxBodySize: 1024})(next).ServeHTTP(recorder, r)
+	if recorder.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want 413", recorder.Code)
+	}
+}
+
+func TestEncryptMiddlewareRequired(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":200,"msg":"success","data":"ok"}`))
+	})
+	server := httptest.NewServer(EncryptMiddleware(EncryptOptions{
+		SecretStore: StaticSecretStore{"key": "secret"},
+		Required:    true,
+	})(handler))
+	defer server.Close()
+	c, err := newClient(WithCredentials("key", "secret"), WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetService("demo").SetMethod("echo")
+	responseData, err := c.DoRequest(map[string]interface{}{"data": "hello"})
+	if err == nil || responseData == nil || responseData.Code != http.StatusBadRequest {
+		t.Fatalf("plaintext response = %+v, %v", responseData, err)
+	}
+	c.SetEncryption(true, nil)
+	if responseData, err = c.DoRequest(map[string]interface{}{"data": "hello"}); err != nil || responseData.Data != "ok" {
+		t.Fatalf("encrypted response = %+v, %v", responseData, err)
+	}
+}
+
+func TestEncryptErrorsIs(t *testing.T) {
+	key := DeriveEncryptKey("secret", ENCRYPT_DEFAULT_KEY_ID)
+	if _, err := DecryptPayload(key, []byte(`{"iv":"x"}`), ""); !errors.Is(err, ErrEncrypt) {
+		t.Fatalf("decrypt err = %v, want ErrEncrypt", err)
+	}
+	if _, err := (EncryptOptions{}).lookupKey("key", "k1"); !errors.Is(err, ErrEncryptKeyNotFound) {
+		t.Fatalf("lookup err = %v, want ErrEncryptKeyNotFound", err)
+	}
+	c, err := newClient(WithCredentials("key", "secret"), WithBaseURL("http://127.0.0.1:1"), WithEncryption(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.CallStream(context.Background(), "demo", "export", nil, CallOptions{}); !errors.Is(err, ErrEncrypt) {
+		t.Fatalf("stream err = %v, want ErrEncrypt", err)
+	}
+}
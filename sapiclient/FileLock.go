@@ -0,0 +1,26 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package sapiclient
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// lockFile
+//
+//	@Description: 对文件加排它锁（flock），已被其它进程或同一进程的其它下载锁定时返回ErrDownloadLocked，文件关闭时自动解锁
+//	@Author zzh 2026-10-20 05:40:12
+//	@param file
+//	@return func() 解锁，flock在文件关闭时已释放，无需操作
+//	@return error
+func lockFile(file *os.File) (func(), error) {
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		if err == syscall.EWOULDBLOCK {
+			return nil, fmt.Errorf("%w: %s", ErrDownloadLocked, file.Name())
+		}
+		return nil, err
+	}
+	return func() {}, nil
+}
@@ -0,0 +1,33 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package sapiclient
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// lockedFiles 当前进程中已锁定的文件，不支持flock的平台只能防止同一进程内的并发下载
+var lockedFiles sync.Map
+
+// lockFile
+//
+//	@Description: 在当前进程内对文件加排它锁，已被同一进程的其它下载锁定时返回ErrDownloadLocked，无法防止其它进程同时写入
+//	@Author zzh 2026-10-20 05:40:12
+//	@param file
+//	@return func() 解锁
+//	@return error
+func lockFile(file *os.File) (func(), error) {
+	path, err := filepath.Abs(file.Name())
+	if err != nil {
+		return nil, err
+	}
+	if _, loaded := lockedFiles.LoadOrStore(path, true); loaded {
+		return nil, fmt.Errorf("%w: %s", ErrDownloadLocked, file.Name())
+	}
+	return func() {
+		lockedFiles.Delete(path)
+	}, nil
+}
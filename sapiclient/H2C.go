@@ -0,0 +1,29 @@
+//go:build go1.24
+
+package sapiclient
+
+import (
+	"net/http"
+)
+
+// h2cSupported
+//
+//	@Description: 当前Go版本是否支持h2c
+//	@Author zzh 2026-10-19 23:46:10
+//	@return error
+func h2cSupported() error {
+	return nil
+}
+
+// enableH2C
+//
+//	@Description: http请求使用明文HTTP/2（prior knowledge），不再使用HTTP/1.1
+//	@Author zzh 2026-10-19 23:46:52
+//	@param transport
+//	@return error
+func enableH2C(transport *http.Transport) error {
+	protocols := new(http.Protocols)
+	protocols.SetUnencryptedHTTP2(true)
+	transport.Protocols = protocols
+	return nil
+}
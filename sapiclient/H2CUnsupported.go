@@ -0,0 +1,30 @@
+//go:build !go1.24
+
+package sapiclient
+
+import (
+	"errors"
+	"net/http"
+)
+
+// errH2CUnsupported 标准库在Go 1.24之前不支持h2c客户端
+var errH2CUnsupported = errors.New("h2c需要使用Go 1.24及以上版本编译")
+
+// h2cSupported
+//
+//	@Description: 当前Go版本是否支持h2c
+//	@Author zzh 2026-10-19 23:46:10
+//	@return error
+func h2cSupported() error {
+	return errH2CUnsupported
+}
+
+// enableH2C
+//
+//	@Description: 当前Go版本不支持h2c
+//	@Author zzh 2026-10-19 23:46:52
+//	@param transport
+//	@return error
+func enableH2C(transport *http.Transport) error {
+	return errH2CUnsupported
+}
@@ -0,0 +1,269 @@
+package sapiclient
+
+import (
+	"errors"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	//请求体超过大小限制
+	SIZE_LIMIT_REQUEST = "request"
+	//响应体超过大小限制
+	SIZE_LIMIT_RESPONSE = "response"
+)
+
+// ErrSizeLimit 请求体或响应体超过大小限制，可通过errors.Is(err, ErrSizeLimit)判断
+var ErrSizeLimit = errors.New("超过大小限制")
+
+// SizeLimitError
+// @Description: 请求体或响应体超过大小限制
+type SizeLimitError struct {
+	Kind    string //SIZE_LIMIT_REQUEST或SIZE_LIMIT_RESPONSE
+	Service string
+	Method  string
+	Size    int64 //已知的大小 字节，读取响应时超过限制为已读取的大小
+	Limit   int64 //大小上限 字节
+}
+
+// Error
+//
+//	@Description: 错误信息
+//	@receiver e
+//	@Author zzh 2026-10-20 00:30:12
+//	@return string
+func (e *SizeLimitError) Error() string {
+	kind := "请求体"
+	if e.Kind == SIZE_LIMIT_RESPONSE {
+		kind = "响应体"
+	}
+	target := ""
+	if e.Service != "" {
+		target = e.Service + "/" + e.Method + " "
+	}
+	return kind + ErrSizeLimit.Error() + ": " + target + strconv.FormatInt(e.Size, 10) +
+		"字节，上限" + strconv.FormatInt(e.Limit, 10) + "字节"
+}
+
+// Is
+//
+//	@Description: 支持errors.Is(err, ErrSizeLimit)
+//	@receiver e
+//	@Author zzh 2026-10-20 00:30:45
+//	@param target
+//	@return bool
+func (e *SizeLimitError) Is(target error) bool {
+	return target == ErrSizeLimit
+}
+
+// SizeLimits
+// @Description: 请求体与响应体大小上限，0表示使用客户端的设置
+type SizeLimits struct {
+	MaxRequestSize  int64 //请求体大小上限 字节，为实际发送（加密、压缩后）的大小，不包含GET请求与上传字段的query参数
+	MaxResponseSize int64 //响应体大小上限 字节，为解压后的大小
+}
+
+// methodLimits service/method（小写） -> 大小限制
+type methodLimits map[string]SizeLimits
+
+// LimitsConfig
+// @Description: 大小限制配置
+type LimitsConfig struct {
+	MaxRequestSize  int64
+	MaxResponseSize int64
+	Methods         map[string]SizeLimits //service/method -> 大小限制，method为*时匹配该service的所有方法
+}
+
+// sizeLimitsFor
+//
+//	@Description: 返回服务方法的大小限制，服务方法单独的设置优先于service/*，再优先于客户端的设置
+//	@receiver c
+//	@Author zzh 2026-10-20 00:33:20
+//	@param service
+//	@param method
+//	@return SizeLimits
+func (c *sApiClient) sizeLimitsFor(service, method string) SizeLimits {
+	limits := SizeLimits{MaxRequestSize: c.maxRequest, MaxResponseSize: c.maxResponse}
+	for _, key := range []string{service + "/*", service + "/" + method} {
+		override, ok := c.sizeLimits[strings.ToLower(key)]
+		if !ok {
+			continue
+		}
+		if override.MaxRequestSize > 0 {
+			limits.MaxRequestSize = override.MaxRequestSize
+		}
+		if override.MaxResponseSize > 0 {
+			limits.MaxResponseSize = override.MaxResponseSize
+		}
+	}
+	return limits
+}
+
+// setSizeLimits
+//
+//	@Description: 设置服务方法的大小限制
+//	@receiver c
+//	@Author zzh 2026-10-20 00:35:02
+//	@param service
+//	@param method *表示该service的所有方法
+//	@param limits
+func (c *sApiClient) setSizeLimits(service, method string, limits SizeLimits) {
+	sizeLimits := make(methodLimits, len(c.sizeLimits)+1)
+	for key, val := range c.sizeLimits {
+		sizeLimits[key] = val
+	}
+	sizeLimits[strings.ToLower(service+"/"+method)] = limits
+	c.sizeLimits = sizeLimits
+}
+
+// setMethodSizes
+//
+//	@Description: 写入配置中服务方法的大小上限
+//	@receiver l
+//	@Author zzh 2026-10-20 00:44:26
+//	@param sizes service/method -> 字节数
+//	@param set 写入请求体或响应体上限
+//	@return error
+func (l *LimitsConfig) setMethodSizes(sizes map[string]string, set func(limits *SizeLimits, size int64)) error {
+	if l.Methods == nil {
+		l.Methods = make(map[string]SizeLimits, len(sizes))
+	}
+	for key, val := range sizes {
+		if _, _, err := splitMethodKey(key); err != nil {
+			return err
+		}
+		size, err := parseSize(val)
+		if err != nil {
+			return errors.New(key + err.Error())
+		}
+		limits := l.Methods[key]
+		set(&limits, size)
+		l.Methods[key] = limits
+	}
+	return nil
+}
+
+// splitMethodKey
+//
+//	@Description: 拆分service/method
+//	@Author zzh 2026-10-20 00:45:10
+//	@param key
+//	@return service
+//	@return method
+//	@return err
+func splitMethodKey(key string) (service, method string, err error) {
+	parts := strings.Split(key, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", errors.New(key + "应为service/method格式，method为*时匹配该service的所有方法")
+	}
+	return parts[0], parts[1], nil
+}
+
+// limitedBody
+// @Description: 限制读取大小的响应体
+type limitedBody struct {
+	io.ReadCloser
+	limit int64
+	read  int64
+}
+
+// Read
+//
+//	@Description: 读取响应体，超过大小限制时返回SizeLimitError
+//	@receiver b
+//	@Author zzh 2026-10-20 00:36:40
+//	@param p
+//	@return int
+//	@return error
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.read += int64(n)
+	if b.read > b.limit {
+		return 0, &SizeLimitError{Kind: SIZE_LIMIT_RESPONSE, Size: b.read, Limit: b.limit}
+	}
+	return n, err
+}
+
+// parseSize
+//
+//	@Description: 解析字节大小，支持B、KB、MB、GB后缀（按1024换算），不带后缀时为字节，换算后超出int64时返回错误
+//	@Author zzh 2026-10-20 00:38:15
+//	@param size
+//	@return int64
+//	@return error
+func parseSize(size string) (int64, error) {
+	size = strings.ToUpper(strings.TrimSpace(size))
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix     string
+		multiplier int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}} {
+		if strings.HasSuffix(size, unit.suffix) {
+			size, multiplier = strings.TrimSpace(strings.TrimSuffix(size, unit.suffix)), unit.multiplier
+			break
+		}
+	}
+	n, err := strconv.ParseInt(size, 10, 64)
+	if err != nil || n < 0 {
+		return 0, errors.New("应为非负的字节数，可带KB、MB、GB后缀")
+	}
+	if n > math.MaxInt64/multiplier {
+		return 0, errors.New("超出可表示的字节数")
+	}
+	return n * multiplier, nil
+}
+
+// WithMaxRequestSize
+//
+//	@Description: 设置请求体大小上限，超过时不发送请求并返回SizeLimitError。只计算请求体，GET请求的query参数不计入
+//	@Author zzh 2026-10-20 00:40:02
+//	@param maxSize 字节
+//	@return Option
+func WithMaxRequestSize(maxSize int64) Option {
+	return func(c *sApiClient) error {
+		if maxSize <= 0 {
+			return errors.New("请求体大小上限应大于0")
+		}
+		c.maxRequest = maxSize
+		return nil
+	}
+}
+
+// WithMaxResponseSize
+//
+//	@Description: 设置响应体大小上限，Content-Length超过时不读取响应体，读取中超过时停止读取，均返回SizeLimitError
+//	@Author zzh 2026-10-20 00:40:48
+//	@param maxSize 字节
+//	@return Option
+func WithMaxResponseSize(maxSize int64) Option {
+	return func(c *sApiClient) error {
+		if maxSize <= 0 {
+			return errors.New("响应体大小上限应大于0")
+		}
+		c.maxResponse = maxSize
+		return nil
+	}
+}
+
+// WithMethodSizeLimits
+//
+//	@Description: 为服务方法单独设置大小限制
+//	@Author zzh 2026-10-20 00:41:35
+//	@param service
+//	@param method *表示该service的所有方法
+//	@param limits
+//	@return Option
+func WithMethodSizeLimits(service, method string, limits SizeLimits) Option {
+	return func(c *sApiClient) error {
+		if service == "" || method == "" {
+			return errors.New("service与method不能为空")
+		}
+		if limits.MaxRequestSize < 0 || limits.MaxResponseSize < 0 {
+			return errors.New("大小上限不能为负数")
+		}
+		c.setSizeLimits(service, method, limits)
+		return nil
+	}
+}
@@ -0,0 +1,117 @@
+package sapiclient
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestParseSize(t *testing.T) {
+	for _, tt := range []struct {
+		size string
+		want int64
+		ok   bool
+	}{
+		{"1024", 1024, true},
+		{"16MB", 16 << 20, true},
+		{" 2 kb ", 2 << 10, true},
+		{"8589934591GB", 8589934591 << 30, true},
+		{"8589934592GB", 0, false},
+		{"9223372036854775807KB", 0, false},
+		{"-1MB", 0, false},
+		{"1TB", 0, false},
+	} {
+		got, err := parseSize(tt.size)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Fatalf("parseSize(%q) = %d, %v", tt.size, got, err)
+		}
+	}
+}
+
+func TestMethodSizeLimits(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":200,"msg":"success","data":"` + strings.Repeat("a", 2000) + `"}`))
+	}))
+	defer server.Close()
+	c, err := newClient(WithCredentials("key", "secret"), WithBaseURL(server.URL),
+		WithMaxRequestSize(1000), WithMaxResponseSize(1<<20),
+		WithMethodSizeLimits("upload", "*", SizeLimits{MaxRequestSize: 10000}),
+		WithMethodSizeLimits("upload", "big", SizeLimits{MaxRequestSize: 100000}),
+		WithMethodSizeLimits("report", "summary", SizeLimits{MaxResponseSize: 1000}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		service, method string
+		size            int
+		kind            string //为空表示不超过限制
+	}{
+		{"demo", "echo", 500, ""},
+		{"demo", "echo", 2000, SIZE_LIMIT_REQUEST},
+		{"upload", "file", 5000, ""},
+		{"upload", "file", 50000, SIZE_LIMIT_REQUEST},
+		{"upload", "big", 50000, ""},
+		{"report", "summary", 10, SIZE_LIMIT_RESPONSE},
+	} {
+		before := atomic.LoadInt32(&hits)
+		_, err = c.Call(tt.service, tt.method, map[string]interface{}{"data": strings.Repeat("a", tt.size)})
+		var sizeErr *SizeLimitError
+		if tt.kind == "" {
+			if err != nil {
+				t.Fatalf("%s/%s %d bytes: %v", tt.service, tt.method, tt.size, err)
+			}
+			continue
+		}
+		if !errors.As(err, &sizeErr) || sizeErr.Kind != tt.kind {
+			t.Fatalf("%s/%s %d bytes: err = %v, want %s SizeLimitError", tt.service, tt.method, tt.size, err, tt.kind)
+		}
+		if sent := atomic.LoadInt32(&hits) - before; tt.kind == SIZE_LIMIT_REQUEST && sent != 0 {
+			t.Fatalf("%s/%s request over limit was sent", tt.service, tt.method)
+		}
+	}
+	// GET请求的参数在query中，不计入请求体大小
+	c.SetRequestMethod(http.MethodGet)
+	if _, err = c.Call("demo", "echo", map[string]interface{}{"data": strings.Repeat("a", 2000)}); err != nil {
+		t.Fatalf("GET with long query: %v", err)
+	}
+}
+
+func TestLoadProfileMethodSizes(t *testing.T) {
+	path := writeConfig(t, `
+[sapi.limits]
+maxRequestSize = "1KB"
+
+[sapi.limits.requestSizes]
+"upload/*" = "8MB"
+
+[sapi.limits.responseSizes]
+"report/export" = "256MB"
+`)
+	profile, err := LoadProfile("", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := profile.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if limits := c.sizeLimitsFor("upload", "file"); limits.MaxRequestSize != 8<<20 {
+		t.Fatalf("upload/file limits = %+v", limits)
+	}
+	if limits := c.sizeLimitsFor("report", "export"); limits.MaxRequestSize != 1<<10 || limits.MaxResponseSize != 256<<20 {
+		t.Fatalf("report/export limits = %+v", limits)
+	}
+	path = writeConfig(t, `
+[sapi.limits]
+maxResponseSize = "9223372036854775807KB"
+`)
+	if _, err = LoadProfile("", path); err == nil {
+		t.Fatal("overflowing size accepted")
+	}
+}
@@ -0,0 +1,110 @@
+package sapiclient
+
+import (
+	"log"
+	"os"
+	"time"
+)
+
+// Logger
+// @Description: 请求日志输出，*log.Logger即满足该接口
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// NewStdLogger
+//
+//	@Description: 创建输出到stdout或stderr的日志
+//	@Author zzh 2026-10-19 18:40:12
+//	@param output stdout或stderr，为空时为stderr
+//	@return Logger
+func NewStdLogger(output string) Logger {
+	writer := os.Stderr
+	if output == "stdout" {
+		writer = os.Stdout
+	}
+	return log.New(writer, "[sapi] ", log.LstdFlags)
+}
+
+// restyLogger
+// @Description: 将Logger适配为resty的日志接口，用于调试输出
+type restyLogger struct {
+	logger Logger
+}
+
+// Errorf
+//
+//	@Description: 错误日志
+//	@receiver r
+//	@Author zzh 2026-10-19 18:41:30
+//	@param format
+//	@param v
+func (r restyLogger) Errorf(format string, v ...interface{}) {
+	r.logger.Printf("ERROR "+format, v...)
+}
+
+// Warnf
+//
+//	@Description: 警告日志
+//	@receiver r
+//	@Author zzh 2026-10-19 18:41:52
+//	@param format
+//	@param v
+func (r restyLogger) Warnf(format string, v ...interface{}) {
+	r.logger.Printf("WARN "+format, v...)
+}
+
+// Debugf
+//
+//	@Description: 调试日志
+//	@receiver r
+//	@Author zzh 2026-10-19 18:42:14
+//	@param format
+//	@param v
+func (r restyLogger) Debugf(format string, v ...interface{}) {
+	r.logger.Printf("DEBUG "+format, v...)
+}
+
+// SetLogger
+//
+//	@Description: 设置请求日志，每次请求结束后输出方法、路径、状态码与耗时，nil表示不输出
+//	@receiver c
+//	@Author zzh 2026-10-19 18:43:36
+//	@param logger
+//	@return *sApiClient
+func (c *sApiClient) SetLogger(logger Logger) *sApiClient {
+	c.logger = logger
+	return c
+}
+
+// SetDebug
+//
+//	@Description: 开启后通过Logger输出完整的请求与响应内容，未设置Logger时输出到stderr
+//	@receiver c
+//	@Author zzh 2026-10-19 18:44:50
+//	@param debug
+//	@return *sApiClient
+func (c *sApiClient) SetDebug(debug bool) *sApiClient {
+	c.debug = debug
+	return c
+}
+
+// logRequest
+//
+//	@Description: 输出请求日志
+//	@receiver c
+//	@Author zzh 2026-10-19 18:46:08
+//	@param req
+//	@param statusCode
+//	@param sentAt
+//	@param err
+func (c *sApiClient) logRequest(req *preparedRequest, statusCode int, sentAt time.Time, err error) {
+	if req.logger == nil {
+		return
+	}
+	if err != nil {
+		req.logger.Printf("%s %s %d %s error: %s", req.httpMethod, req.pathUrl, statusCode, time.Since(sentAt), err.Error())
+		return
+	}
+	req.logger.Printf("%s %s %d %s", req.httpMethod, req.pathUrl, statusCode, time.Since(sentAt))
+}
@@ -0,0 +1,315 @@
+package sapiclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	//签名参数缺失
+	CODE_SIGN_MISSING = 40100
+	//appKey不存在
+	CODE_APPKEY_INVALID = 40101
+	//签名不正确
+	CODE_SIGN_INVALID = 40102
+	//请求时间超出允许范围
+	CODE_TIME_INVALID = 40103
+	//nonce重复使用
+	CODE_NONCE_REPLAYED = 40104
+	//请求体超过大小上限
+	CODE_BODY_TOO_LARGE = 41300
+	//默认允许的请求时间偏差
+	DEFAULT_TIME_WINDOW = 300 * time.Second
+	//签名校验时默认读取的请求体大小上限 10MB
+	DEFAULT_MAX_BODY_SIZE = 10 << 20
+)
+
+// ErrUnknownAppKey SecretStore中不存在该appKey
+var ErrUnknownAppKey = errors.New("appKey不存在")
+
+// ErrBodyTooLarge 请求体超过大小上限
+var ErrBodyTooLarge = errors.New("请求体超过大小上限")
+
+// SecretStore
+// @Description: 根据appKey查询appSecret
+type SecretStore interface {
+	GetSecret(appKey string) (appSecret string, err error)
+}
+
+// StaticSecretStore
+// @Description: 基于固定map的SecretStore
+type StaticSecretStore map[string]string
+
+// GetSecret
+//
+//	@Description: 查询appSecret
+//	@receiver s
+//	@Author zzh 2026-10-19 11:40:12
+//	@param appKey
+//	@return string
+//	@return error
+func (s StaticSecretStore) GetSecret(appKey string) (string, error) {
+	appSecret, ok := s[appKey]
+	if !ok {
+		return "", ErrUnknownAppKey
+	}
+	return appSecret, nil
+}
+
+// VerifyOptions
+// @Description: 服务端签名校验配置
+type VerifyOptions struct {
+	SecretStore       SecretStore   //appSecret查询
+	TimeWindow        time.Duration //允许的请求时间偏差，默认300秒
+	PathPrefix        string        //服务挂载前缀，签名路径不包含该前缀
+	NonceStore        NonceStore    //nonce防重放存储，默认使用内存存储
+	SignVersions      []string      //允许的签名版本，为空时md5与hmac-sha256均可
+	RequireBodyDigest bool          //是否要求请求携带Content-Digest，不适用于请求体不参与签名的请求
+	MaxDecompressed   int64         //压缩请求体解压后的大小上限 字节，默认DEFAULT_MAX_DECOMPRESSED_SIZE
+	UnsignedPayload   bool          //是否允许请求体不参与签名（payload-hash为UNSIGNED-PAYLOAD），用于上传
+	MaxBodySize       int64         //校验签名前读取的请求体大小上限 字节，默认DEFAULT_MAX_BODY_SIZE
+}
+
+// VerifyError
+// @Description: 签名校验失败
+type VerifyError struct {
+	Code int
+	Msg  string
+}
+
+// Error
+//
+//	@Description: 错误信息
+//	@receiver e
+//	@Author zzh 2026-10-19 11:42:30
+//	@return string
+func (e *VerifyError) Error() string {
+	return e.Msg
+}
+
+type appKeyContextKey struct{}
+
+// AppKeyFromContext
+//
+//	@Description: 获取签名校验通过的appKey
+//	@Author zzh 2026-10-19 11:43:51
+//	@param ctx
+//	@return string
+//	@return bool
+func AppKeyFromContext(ctx context.Context) (string, bool) {
+	appKey, ok := ctx.Value(appKeyContextKey{}).(string)
+	return appKey, ok
+}
+
+// VerifyMiddleware
+//
+//	@Description: net/http签名校验中间件，校验通过后appKey写入请求context，压缩的请求体解压后交给next
+//	@Author zzh 2026-10-19 11:46:07
+//	@param options
+//	@return func(http.Handler) http.Handler
+func VerifyMiddleware(options VerifyOptions) func(http.Handler) http.Handler {
+	if options.TimeWindow <= 0 {
+		options.TimeWindow = DEFAULT_TIME_WINDOW
+	}
+	if options.NonceStore == nil {
+		options.NonceStore = NewMemoryNonceStore(MemoryNonceOptions{})
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			appKey, err := VerifyRequest(r, &options)
+			if err != nil {
+				WriteVerifyError(w, err)
+				return
+			}
+			if err = DecodeRequestBody(r, options.MaxDecompressed); err != nil {
+				content, _ := json.Marshal(&ResponseData{Code: http.StatusBadRequest, Msg: err.Error()})
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write(content)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), appKeyContextKey{}, appKey)))
+		})
+	}
+}
+
+// VerifyRequest
+//
+//	@Description: 校验请求签名、时间与nonce，返回校验通过的appKey
+//	@Author zzh 2026-10-19 11:49:22
+//	@param r
+//	@param options
+//	@return appKey
+//	@return err
+func VerifyRequest(r *http.Request, options *VerifyOptions) (appKey string, err error) {
+	appKey = r.Header.Get("appkey")
+	nonce := r.Header.Get("nonce")
+	timestamp := r.Header.Get("time")
+	sign := r.Header.Get("sign")
+	if appKey == "" || nonce == "" || timestamp == "" || sign == "" {
+		return "", &VerifyError{Code: CODE_SIGN_MISSING, Msg: "签名参数缺失"}
+	}
+	if options.SecretStore == nil {
+		return "", &VerifyError{Code: CODE_APPKEY_INVALID, Msg: "未配置SecretStore"}
+	}
+	appSecret, err := options.SecretStore.GetSecret(appKey)
+	if err != nil {
+		return "", &VerifyError{Code: CODE_APPKEY_INVALID, Msg: err.Error()}
+	}
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return "", &VerifyError{Code: CODE_TIME_INVALID, Msg: "请求时间格式不正确"}
+	}
+	timeWindow := options.TimeWindow
+	if timeWindow <= 0 {
+		timeWindow = DEFAULT_TIME_WINDOW
+	}
+	requestTime := time.Unix(unix, 0)
+	if skew := time.Since(requestTime); skew > timeWindow || skew < -timeWindow {
+		return "", &VerifyError{Code: CODE_TIME_INVALID, Msg: "请求时间超出允许范围"}
+	}
+	version := r.Header.Get(SIGN_VERSION_HEADER)
+	signer, ok := SignerForVersion(version)
+	if !ok || !versionAllowed(options.SignVersions, signer.Version()) {
+		return "", &VerifyError{Code: CODE_SIGN_INVALID, Msg: "不支持的签名版本: " + version}
+	}
+	var body []byte
+	unsignedPayload := r.Header.Get(PAYLOAD_HASH_HEADER) == UNSIGNED_PAYLOAD
+	if unsignedPayload {
+		// 请求体不参与签名，不读取请求体，由next流式处理，也无法校验请求体摘要
+		if !options.UnsignedPayload {
+			return "", &VerifyError{Code: CODE_SIGN_INVALID, Msg: "不允许请求体不参与签名"}
+		}
+		if r.Header.Get(CONTENT_DIGEST_HEADER) != "" {
+			return "", &VerifyError{Code: CODE_SIGN_INVALID, Msg: "请求体不参与签名时不能携带请求体摘要"}
+		}
+	} else if body, err = readLimitedBody(r, options.MaxBodySize); err != nil {
+		if errors.Is(err, ErrBodyTooLarge) {
+			return "", &VerifyError{Code: CODE_BODY_TOO_LARGE, Msg: err.Error()}
+		}
+		return "", &VerifyError{Code: CODE_SIGN_INVALID, Msg: "请求体读取失败: " + err.Error()}
+	}
+	signReq := &SignRequest{
+		AppKey:    appKey,
+		AppSecret: appSecret,
+		Method:    r.Method,
+		Path:      signPath(r, options.PathPrefix),
+		Query:     r.URL.Query(),
+		Body:      body,
+		Nonce:     nonce,
+		Timestamp: timestamp,
+		Header:    r.Header,
+	}
+	if options.RequireBodyDigest && !unsignedPayload && r.Header.Get(CONTENT_DIGEST_HEADER) == "" {
+		return "", &VerifyError{Code: CODE_SIGN_MISSING, Msg: "缺少请求体摘要"}
+	}
+	if err = signer.Verify(signReq); err != nil {
+		return "", &VerifyError{Code: CODE_SIGN_INVALID, Msg: err.Error()}
+	}
+	nonceStore := options.NonceStore
+	if nonceStore == nil {
+		nonceStore = defaultNonceStore
+	}
+	fresh, err := nonceStore.Use(appKey+":"+nonce, requestTime.Add(timeWindow))
+	if err != nil {
+		// 无法确认nonce未被使用（如存储已满）时拒绝请求
+		return "", &VerifyError{Code: CODE_NONCE_REPLAYED, Msg: "nonce校验失败: " + err.Error()}
+	}
+	if !fresh {
+		return "", &VerifyError{Code: CODE_NONCE_REPLAYED, Msg: "nonce已被使用"}
+	}
+	return appKey, nil
+}
+
+// versionAllowed
+//
+//	@Description: 判断签名版本是否允许
+//	@Author zzh 2026-10-19 15:28:03
+//	@param versions
+//	@param version
+//	@return bool
+func versionAllowed(versions []string, version string) bool {
+	if len(versions) == 0 {
+		return true
+	}
+	for _, item := range versions {
+		if item == version {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteVerifyError
+//
+//	@Description: 以ResponseData格式输出校验失败信息
+//	@Author zzh 2026-10-19 11:52:40
+//	@param w
+//	@param err
+func WriteVerifyError(w http.ResponseWriter, err error) {
+	response := &ResponseData{Code: CODE_SIGN_INVALID, Msg: err.Error()}
+	var verifyErr *VerifyError
+	if errors.As(err, &verifyErr) {
+		response.Code = verifyErr.Code
+	}
+	statusCode := http.StatusUnauthorized
+	if response.Code == CODE_BODY_TOO_LARGE {
+		statusCode = http.StatusRequestEntityTooLarge
+	}
+	content, _ := json.Marshal(response)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_, _ = w.Write(content)
+}
+
+// readLimitedBody
+//
+//	@Description: 读取请求体并还原，在签名校验之前调用，超过maxSize时返回ErrBodyTooLarge，避免未认证的请求占用大量内存
+//	@Author zzh 2026-10-20 04:20:10
+//	@param r
+//	@param maxSize 小于等于0时使用DEFAULT_MAX_BODY_SIZE
+//	@return []byte
+//	@return error
+func readLimitedBody(r *http.Request, maxSize int64) ([]byte, error) {
+	if maxSize <= 0 {
+		maxSize = DEFAULT_MAX_BODY_SIZE
+	}
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+	if r.ContentLength > maxSize {
+		return nil, ErrBodyTooLarge
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(nil, r.Body, maxSize))
+	_ = r.Body.Close()
+	if err != nil {
+		if int64(len(body)) >= maxSize {
+			return nil, ErrBodyTooLarge
+		}
+		return nil, err
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// signPath
+//
+//	@Description: 获取参与签名的请求路径，与客户端的sapi/<service>/<method>保持一致
+//	@Author zzh 2026-10-19 11:54:15
+//	@param r
+//	@param pathPrefix
+//	@return string
+func signPath(r *http.Request, pathPrefix string) string {
+	urlPath := strings.TrimPrefix(r.URL.Path, "/")
+	return strings.TrimPrefix(strings.TrimPrefix(urlPath, strings.Trim(pathPrefix, "/")), "/")
+}
+
+// defaultNonceStore 未配置NonceStore时使用的nonce存储
+var defaultNonceStore = NewMemoryNonceStore(MemoryNonceOptions{})
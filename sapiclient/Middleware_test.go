@@ -0,0 +1,127 @@
+package sapiclient
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// signedRequest 客户端发送的已签名请求
+type signedRequest struct {
+	header http.Header
+	url    string
+	body   []byte
+}
+
+// replay 用记录的请求头与请求体重新构造请求
+func (s *signedRequest) replay() *http.Request {
+	r := httptest.NewRequest(http.MethodPost, s.url, bytes.NewReader(s.body))
+	r.Header = s.header.Clone()
+	return r
+}
+
+// captureSigned 使用指定的签名方式发送一次请求，返回服务端收到的请求，v2签名时携带请求体摘要
+func captureSigned(t *testing.T, signer Signer, body map[string]interface{}) *signedRequest {
+	t.Helper()
+	var captured *signedRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, _ := ioutil.ReadAll(r.Body)
+		captured = &signedRequest{header: r.Header.Clone(), url: r.URL.String(), body: content}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":200,"msg":"success"}`))
+	}))
+	defer server.Close()
+	c, err := newClient(WithCredentials("key", "secret"), WithBaseURL(server.URL), WithSigner(signer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetService("demo").SetMethod("echo").SetBodyDigest(signer.Version() == SIGN_VERSION_HMAC_SHA256)
+	if _, err = c.DoRequest(body); err != nil {
+		t.Fatal(err)
+	}
+	if captured == nil {
+		t.Fatal("request not received")
+	}
+	return captured
+}
+
+func TestVerifyRequestRoundTrip(t *testing.T) {
+	for _, signer := range []Signer{LegacyMD5Signer{}, HMACSHA256Signer{}} {
+		t.Run(signer.Version(), func(t *testing.T) {
+			captured := captureSigned(t, signer, map[string]interface{}{"data": "hello"})
+			options := &VerifyOptions{
+				SecretStore: StaticSecretStore{"key": "secret"},
+				NonceStore:  NewMemoryNonceStore(MemoryNonceOptions{}),
+			}
+			appKey, err := VerifyRequest(captured.replay(), options)
+			if err != nil || appKey != "key" {
+				t.Fatalf("verify = %q, %v", appKey, err)
+			}
+			if _, err = VerifyRequest(captured.replay(), options); !isVerifyCode(err, CODE_NONCE_REPLAYED) {
+				t.Fatalf("replay err = %v, want CODE_NONCE_REPLAYED", err)
+			}
+
+			options.NonceStore = NewMemoryNonceStore(MemoryNonceOptions{})
+			if _, err = VerifyRequest(captured.replay(), &VerifyOptions{
+				SecretStore: StaticSecretStore{"key": "other"},
+				NonceStore:  options.NonceStore,
+			}); !isVerifyCode(err, CODE_SIGN_INVALID) {
+				t.Fatalf("wrong secret err = %v, want CODE_SIGN_INVALID", err)
+			}
+		})
+	}
+}
+
+func TestVerifyRequestBodyTampered(t *testing.T) {
+	captured := captureSigned(t, HMACSHA256Signer{}, map[string]interface{}{"data": "hello"})
+	tampered := captured.replay()
+	tampered.Body = ioutil.NopCloser(strings.NewReader(`{"data":"hellO"}`))
+	if _, err := VerifyRequest(tampered, &VerifyOptions{
+		SecretStore: StaticSecretStore{"key": "secret"},
+		NonceStore:  NewMemoryNonceStore(MemoryNonceOptions{}),
+	}); !isVerifyCode(err, CODE_SIGN_INVALID) {
+		t.Fatalf("tampered body err = %v, want CODE_SIGN_INVALID", err)
+	}
+}
+
+func TestVerifyRequestBodyLimit(t *testing.T) {
+	captured := captureSigned(t, HMACSHA256Signer{}, map[string]interface{}{"data": strings.Repeat("a", 1000)})
+	options := &VerifyOptions{
+		SecretStore: StaticSecretStore{"key": "secret"},
+		NonceStore:  NewMemoryNonceStore(MemoryNonceOptions{}),
+		MaxBodySize: 100,
+	}
+	if _, err := VerifyRequest(captured.replay(), options); !isVerifyCode(err, CODE_BODY_TOO_LARGE) {
+		t.Fatalf("oversized err = %v, want CODE_BODY_TOO_LARGE", err)
+	}
+
+	// 未声明Content-Length时读取到上限即停止
+	chunked := captured.replay()
+	chunked.ContentLength = -1
+	chunked.Body = ioutil.NopCloser(bytes.NewReader(captured.body))
+	if _, err := VerifyRequest(chunked, options); !isVerifyCode(err, CODE_BODY_TOO_LARGE) {
+		t.Fatalf("chunked oversized err = %v, want CODE_BODY_TOO_LARGE", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("next called for oversized body")
+	})
+	VerifyMiddleware(*options)(next).ServeHTTP(recorder, captured.replay())
+	if recorder.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want 413", recorder.Code)
+	}
+
+	options.MaxBodySize = int64(len(captured.body))
+	if _, err := VerifyRequest(captured.replay(), options); err != nil {
+		t.Fatalf("body at limit: %v", err)
+	}
+}
+
+func isVerifyCode(err error, code int) bool {
+	verifyErr, ok := err.(*VerifyError)
+	return ok && verifyErr.Code == code
+}
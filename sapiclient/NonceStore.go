@@ -0,0 +1,507 @@
+package sapiclient
+
+import (
+	"bufio"
+	"errors"
+	"hash/fnv"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	//内存nonce存储默认分片数量
+	DEFAULT_NONCE_SHARDS = 32
+	//内存nonce存储默认过期分桶宽度
+	DEFAULT_NONCE_BUCKET_WIDTH = 10 * time.Second
+	//内存nonce存储默认最大记录数量
+	DEFAULT_NONCE_MAX_ENTRIES = 1000000
+	//文件nonce存储刷盘间隔
+	NONCE_FILE_FLUSH_INTERVAL = time.Second
+	//文件nonce存储检查是否需要重写的行数间隔
+	NONCE_FILE_COMPACT_LINES = 4096
+)
+
+// ErrNonceStoreFull nonce存储已达到记录上限，有效期内的nonce不会被提前淘汰，新的nonce被拒绝
+var ErrNonceStoreFull = errors.New("nonce存储已满")
+
+// NonceStore
+// @Description: nonce防重放存储，记录有效期内已使用的nonce
+type NonceStore interface {
+	// Use 记录nonce直到expireAt，nonce在有效期内已被使用时返回false
+	Use(key string, expireAt time.Time) (fresh bool, err error)
+	// Stats 返回存储的统计信息
+	Stats() NonceStats
+}
+
+// NonceStats
+// @Description: nonce存储统计信息
+type NonceStats struct {
+	Inserts uint64 //记录的nonce数量
+	Replays uint64 //检测到的重放次数
+	Rejects uint64 //因达到记录上限被拒绝的数量
+	Size    int    //当前记录数量
+}
+
+// MemoryNonceOptions
+// @Description: 内存nonce存储配置
+type MemoryNonceOptions struct {
+	Shards      int           //分片数量，默认32
+	BucketWidth time.Duration //过期时间分桶宽度，默认10秒
+	MaxEntries  int           //所有分片合计的最大记录数量，默认1000000，达到后拒绝新的nonce直到有记录过期
+}
+
+// MemoryNonceStore
+// @Description: 分片map实现的内存nonce存储，按过期时间分桶批量清理
+type MemoryNonceStore struct {
+	shards      []*nonceShard
+	bucketWidth int64
+	maxEntries  int64
+	size        int64 //所有分片的记录数量
+	inserts     uint64
+	replays     uint64
+	rejects     uint64
+}
+
+// nonceShard
+// @Description: nonce存储分片
+type nonceShard struct {
+	mu      sync.Mutex
+	entries map[string]int64   //nonce -> 过期时间 纳秒
+	buckets map[int64][]string //过期分桶 -> nonce列表
+	purged  int64              //已清理到的分桶
+}
+
+// NewMemoryNonceStore
+//
+//	@Description: 创建内存nonce存储
+//	@Author zzh 2026-10-19 13:05:18
+//	@param options
+//	@return *MemoryNonceStore
+func NewMemoryNonceStore(options MemoryNonceOptions) *MemoryNonceStore {
+	if options.Shards <= 0 {
+		options.Shards = DEFAULT_NONCE_SHARDS
+	}
+	if options.BucketWidth <= 0 {
+		options.BucketWidth = DEFAULT_NONCE_BUCKET_WIDTH
+	}
+	if options.MaxEntries <= 0 {
+		options.MaxEntries = DEFAULT_NONCE_MAX_ENTRIES
+	}
+	store := &MemoryNonceStore{
+		shards:      make([]*nonceShard, options.Shards),
+		bucketWidth: int64(options.BucketWidth),
+		maxEntries:  int64(options.MaxEntries),
+	}
+	for i := range store.shards {
+		store.shards[i] = &nonceShard{
+			entries: make(map[string]int64),
+			buckets: make(map[int64][]string),
+		}
+	}
+	return store
+}
+
+// Use
+//
+//	@Description: 记录nonce，有效期内重复使用返回false，达到记录上限时返回ErrNonceStoreFull
+//	@receiver m
+//	@Author zzh 2026-10-19 13:08:44
+//	@param key
+//	@param expireAt
+//	@return bool
+//	@return error
+func (m *MemoryNonceStore) Use(key string, expireAt time.Time) (bool, error) {
+	now := time.Now().UnixNano()
+	fresh, err := m.add(key, expireAt.UnixNano(), now)
+	if err != nil {
+		atomic.AddUint64(&m.rejects, 1)
+		return false, err
+	}
+	if !fresh {
+		atomic.AddUint64(&m.replays, 1)
+		return false, nil
+	}
+	atomic.AddUint64(&m.inserts, 1)
+	return true, nil
+}
+
+// Stats
+//
+//	@Description: 返回统计信息
+//	@receiver m
+//	@Author zzh 2026-10-19 13:10:02
+//	@return NonceStats
+func (m *MemoryNonceStore) Stats() NonceStats {
+	return NonceStats{
+		Inserts: atomic.LoadUint64(&m.inserts),
+		Replays: atomic.LoadUint64(&m.replays),
+		Rejects: atomic.LoadUint64(&m.rejects),
+		Size:    int(atomic.LoadInt64(&m.size)),
+	}
+}
+
+// add
+//
+//	@Description: 写入nonce，已存在且未过期时返回false。记录总数达到上限时先清理所有分片中已过期的记录，
+//	仍然已满时返回ErrNonceStoreFull，不淘汰有效期内的nonce，否则大量请求可以挤掉已截获请求的nonce后重放
+//	@receiver m
+//	@Author zzh 2026-10-19 13:13:27
+//	@param key
+//	@param expire 过期时间 纳秒
+//	@param now 当前时间 纳秒
+//	@return bool
+//	@return error
+func (m *MemoryNonceStore) add(key string, expire, now int64) (bool, error) {
+	if expire <= now {
+		//已过期的nonce无需记录，时间窗口由调用方校验
+		return true, nil
+	}
+	shard := m.shardOf(key)
+	fresh, full := m.insert(shard, key, expire, now)
+	if full {
+		m.evictExpired(now)
+		if fresh, full = m.insert(shard, key, expire, now); full {
+			return false, ErrNonceStoreFull
+		}
+	}
+	return fresh, nil
+}
+
+// insert
+//
+//	@Description: 在分片中写入nonce，记录总数已达到上限时返回full
+//	@receiver m
+//	@Author zzh 2026-10-20 11:20:14
+//	@param shard
+//	@param key
+//	@param expire 过期时间 纳秒
+//	@param now 当前时间 纳秒
+//	@return fresh
+//	@return full
+func (m *MemoryNonceStore) insert(shard *nonceShard, key string, expire, now int64) (fresh, full bool) {
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	m.purge(shard, now)
+	existing, ok := shard.entries[key]
+	if ok && existing > now {
+		return false, false
+	}
+	if !ok {
+		if atomic.AddInt64(&m.size, 1) > m.maxEntries {
+			atomic.AddInt64(&m.size, -1)
+			return false, true
+		}
+	}
+	bucket := expire / m.bucketWidth
+	shard.entries[key] = expire
+	shard.buckets[bucket] = append(shard.buckets[bucket], key)
+	return true, false
+}
+
+// evictExpired
+//
+//	@Description: 删除所有分片中已过期的记录，包括所在分桶尚未整体过期的记录，在记录总数达到上限时调用
+//	@receiver m
+//	@Author zzh 2026-10-20 11:22:40
+//	@param now 当前时间 纳秒
+func (m *MemoryNonceStore) evictExpired(now int64) {
+	for _, shard := range m.shards {
+		shard.mu.Lock()
+		for key, expire := range shard.entries {
+			if expire <= now {
+				// 分桶中残留的key在分桶过期时清理
+				delete(shard.entries, key)
+				atomic.AddInt64(&m.size, -1)
+			}
+		}
+		shard.mu.Unlock()
+	}
+}
+
+// purge
+//
+//	@Description: 清理已整体过期的分桶，需要在持有分片锁时调用
+//	@receiver m
+//	@Author zzh 2026-10-19 13:16:50
+//	@param shard
+//	@param now
+func (m *MemoryNonceStore) purge(shard *nonceShard, now int64) {
+	current := now / m.bucketWidth
+	if current <= shard.purged {
+		return
+	}
+	for bucket, keys := range shard.buckets {
+		if bucket >= current {
+			continue
+		}
+		for _, key := range keys {
+			if expire, ok := shard.entries[key]; ok && expire/m.bucketWidth == bucket {
+				delete(shard.entries, key)
+				atomic.AddInt64(&m.size, -1)
+			}
+		}
+		delete(shard.buckets, bucket)
+	}
+	shard.purged = current
+}
+
+// snapshot
+//
+//	@Description: 返回当前未过期的nonce
+//	@receiver m
+//	@Author zzh 2026-10-19 13:21:38
+//	@return map[string]int64
+func (m *MemoryNonceStore) snapshot() map[string]int64 {
+	now := time.Now().UnixNano()
+	entries := make(map[string]int64)
+	for _, shard := range m.shards {
+		shard.mu.Lock()
+		for key, expire := range shard.entries {
+			if expire > now {
+				entries[key] = expire
+			}
+		}
+		shard.mu.Unlock()
+	}
+	return entries
+}
+
+// shardOf
+//
+//	@Description: 根据key计算分片
+//	@receiver m
+//	@Author zzh 2026-10-19 13:22:54
+//	@param key
+//	@return *nonceShard
+func (m *MemoryNonceStore) shardOf(key string) *nonceShard {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return m.shards[h.Sum32()%uint32(len(m.shards))]
+}
+
+// FileNonceStore
+// @Description: 基于追加写文件的nonce存储，进程重启后仍能识别有效期内的重放。
+// 文件每NONCE_FILE_FLUSH_INTERVAL刷盘一次，进程崩溃时最近一个间隔内的nonce会丢失，重启后这些请求在时间窗口内可以重放；
+// 需要严格防重放时，重启后等待一个时间窗口再接受请求，或使用共享存储实现NonceStore
+type FileNonceStore struct {
+	memory   *MemoryNonceStore
+	mu       sync.Mutex
+	path     string
+	file     *os.File
+	writer   *bufio.Writer
+	written  int //文件中的记录行数
+	closed   chan struct{}
+	closeErr error
+	once     sync.Once
+}
+
+// NewFileNonceStore
+//
+//	@Description: 创建文件nonce存储，启动时加载文件中未过期的nonce
+//	@Author zzh 2026-10-19 13:26:31
+//	@param filePath
+//	@param options
+//	@return *FileNonceStore
+//	@return error
+func NewFileNonceStore(filePath string, options MemoryNonceOptions) (*FileNonceStore, error) {
+	store := &FileNonceStore{
+		memory: NewMemoryNonceStore(options),
+		path:   filePath,
+		closed: make(chan struct{}),
+	}
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return nil, errors.New("nonce文件目录创建失败: " + err.Error())
+	}
+	content, err := ioutil.ReadFile(filePath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, errors.New("nonce文件读取失败: " + err.Error())
+	}
+	now := time.Now().UnixNano()
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.SplitN(line, "\t", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		expire, parseErr := strconv.ParseInt(fields[0], 10, 64)
+		key, unquoteErr := strconv.Unquote(fields[1])
+		if parseErr != nil || unquoteErr != nil {
+			continue
+		}
+		_, _ = store.memory.add(key, expire, now)
+	}
+	if err = store.compact(); err != nil {
+		return nil, err
+	}
+	go store.flushLoop()
+	return store, nil
+}
+
+// Use
+//
+//	@Description: 记录nonce并追加写入文件，有效期内重复使用返回false
+//	@receiver f
+//	@Author zzh 2026-10-19 13:29:12
+//	@param key
+//	@param expireAt
+//	@return bool
+//	@return error
+func (f *FileNonceStore) Use(key string, expireAt time.Time) (bool, error) {
+	fresh, err := f.memory.Use(key, expireAt)
+	if err != nil || !fresh {
+		return fresh, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.writer == nil {
+		return false, errors.New("nonce存储已关闭或文件不可用")
+	}
+	if _, err = f.writer.WriteString(strconv.FormatInt(expireAt.UnixNano(), 10) + "\t" + strconv.Quote(key) + "\n"); err != nil {
+		return false, errors.New("nonce写入失败: " + err.Error())
+	}
+	f.written++
+	//每写入一定行数检查一次，文件行数超过存活记录两倍时重写
+	if f.written%NONCE_FILE_COMPACT_LINES == 0 && f.written > 2*f.memory.Stats().Size {
+		if err = f.compactLocked(); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// Stats
+//
+//	@Description: 返回统计信息
+//	@receiver f
+//	@Author zzh 2026-10-19 13:30:40
+//	@return NonceStats
+func (f *FileNonceStore) Stats() NonceStats {
+	return f.memory.Stats()
+}
+
+// Close
+//
+//	@Description: 刷盘并关闭文件
+//	@receiver f
+//	@Author zzh 2026-10-19 13:31:55
+//	@return error
+func (f *FileNonceStore) Close() error {
+	f.once.Do(func() {
+		close(f.closed)
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if f.writer == nil {
+			return
+		}
+		if err := f.writer.Flush(); err != nil {
+			f.closeErr = err
+		}
+		if err := f.file.Close(); err != nil && f.closeErr == nil {
+			f.closeErr = err
+		}
+		f.file, f.writer = nil, nil
+	})
+	return f.closeErr
+}
+
+// flushLoop
+//
+//	@Description: 定时刷盘
+//	@receiver f
+//	@Author zzh 2026-10-19 13:33:08
+func (f *FileNonceStore) flushLoop() {
+	ticker := time.NewTicker(NONCE_FILE_FLUSH_INTERVAL)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			f.mu.Lock()
+			if f.writer != nil {
+				_ = f.writer.Flush()
+			}
+			f.mu.Unlock()
+		case <-f.closed:
+			return
+		}
+	}
+}
+
+// compact
+//
+//	@Description: 重写文件只保留未过期的nonce
+//	@receiver f
+//	@Author zzh 2026-10-19 13:35:21
+//	@return error
+func (f *FileNonceStore) compact() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.compactLocked()
+}
+
+// compactLocked
+//
+//	@Description: 重写文件只保留未过期的nonce，需要在持有锁时调用。重写失败时重新以追加方式打开原文件，
+//	之后的nonce继续写入原文件
+//	@receiver f
+//	@Author zzh 2026-10-19 13:37:46
+//	@return error
+func (f *FileNonceStore) compactLocked() error {
+	if f.writer != nil {
+		if err := f.writer.Flush(); err != nil {
+			return errors.New("nonce文件刷盘失败: " + err.Error())
+		}
+		_ = f.file.Close()
+		f.file, f.writer = nil, nil
+	}
+	tmpPath := f.path + ".tmp"
+	tmpFile, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return f.reopenLocked(errors.New("nonce文件创建失败: " + err.Error()))
+	}
+	writer := bufio.NewWriter(tmpFile)
+	entries := f.memory.snapshot()
+	for key, expire := range entries {
+		_, _ = writer.WriteString(strconv.FormatInt(expire, 10) + "\t" + strconv.Quote(key) + "\n")
+	}
+	if err = writer.Flush(); err == nil {
+		err = tmpFile.Close()
+	} else {
+		_ = tmpFile.Close()
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, f.path)
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return f.reopenLocked(errors.New("nonce文件重写失败: " + err.Error()))
+	}
+	if err = f.reopenLocked(nil); err != nil {
+		return err
+	}
+	f.written = len(entries)
+	return nil
+}
+
+// reopenLocked
+//
+//	@Description: 以追加方式打开nonce文件并恢复writer，需要在持有锁时调用，打开失败时之后的Use返回错误
+//	@receiver f
+//	@Author zzh 2026-10-20 11:30:05
+//	@param cause 重写失败的原因，为nil表示重写成功
+//	@return error cause与打开失败的错误
+func (f *FileNonceStore) reopenLocked(cause error) error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		if cause != nil {
+			return errors.New(cause.Error() + "; nonce文件打开失败: " + err.Error())
+		}
+		return errors.New("nonce文件打开失败: " + err.Error())
+	}
+	f.file, f.writer = file, bufio.NewWriter(file)
+	return cause
+}
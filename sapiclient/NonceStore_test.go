@@ -0,0 +1,126 @@
+package sapiclient
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestMemoryNonceStoreReplay(t *testing.T) {
+	store := NewMemoryNonceStore(MemoryNonceOptions{})
+	expireAt := time.Now().Add(time.Minute)
+	if fresh, err := store.Use("key:n1", expireAt); err != nil || !fresh {
+		t.Fatalf("first use = %v, %v", fresh, err)
+	}
+	if fresh, err := store.Use("key:n1", expireAt); err != nil || fresh {
+		t.Fatalf("replay = %v, %v", fresh, err)
+	}
+	if fresh, _ := store.Use("key:n2", expireAt); !fresh {
+		t.Fatal("different nonce rejected")
+	}
+	if stats := store.Stats(); stats.Inserts != 2 || stats.Replays != 1 || stats.Size != 2 {
+		t.Fatalf("stats = %+v", stats)
+	}
+}
+
+func TestMemoryNonceStoreFullKeepsLiveNonces(t *testing.T) {
+	store := NewMemoryNonceStore(MemoryNonceOptions{Shards: 1, MaxEntries: 4})
+	expireAt := time.Now().Add(time.Minute)
+	if fresh, _ := store.Use("captured", expireAt); !fresh {
+		t.Fatal("first use rejected")
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := store.Use("flood"+strconv.Itoa(i), expireAt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := store.Use("flood-overflow", expireAt); !errors.Is(err, ErrNonceStoreFull) {
+		t.Fatalf("overflow err = %v, want ErrNonceStoreFull", err)
+	}
+	if fresh, err := store.Use("captured", expireAt); err != nil || fresh {
+		t.Fatalf("replay after flood = %v, %v", fresh, err)
+	}
+	if stats := store.Stats(); stats.Rejects != 1 {
+		t.Fatalf("rejects = %d", stats.Rejects)
+	}
+}
+
+func TestMemoryNonceStoreGlobalLimit(t *testing.T) {
+	store := NewMemoryNonceStore(MemoryNonceOptions{Shards: 8, BucketWidth: time.Hour, MaxEntries: 4})
+	now := time.Now().UnixNano()
+	for i := 0; i < 4; i++ {
+		if fresh, err := store.add("n"+strconv.Itoa(i), now+int64(time.Second), now); err != nil || !fresh {
+			t.Fatalf("add %d = %v, %v", i, fresh, err)
+		}
+	}
+	if _, err := store.add("overflow", now+int64(time.Second), now); !errors.Is(err, ErrNonceStoreFull) {
+		t.Fatalf("overflow err = %v, want ErrNonceStoreFull", err)
+	}
+	// 记录已过期但所在分桶尚未过期，写入前先清理
+	later := now + 2*int64(time.Second)
+	if fresh, err := store.add("overflow", later+int64(time.Second), later); err != nil || !fresh {
+		t.Fatalf("add after expiry = %v, %v", fresh, err)
+	}
+	if size := store.Stats().Size; size != 1 {
+		t.Fatalf("size = %d, want 1", size)
+	}
+}
+
+func TestFileNonceStoreCompactFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nonce.log")
+	store, err := NewFileNonceStore(path, MemoryNonceOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 临时文件路径被目录占用，重写失败
+	if err = os.Mkdir(path+".tmp", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err = store.compact(); err == nil {
+		t.Fatal("compact succeeded")
+	}
+	expireAt := time.Now().Add(time.Minute)
+	if fresh, err := store.Use("key:n1", expireAt); err != nil || !fresh {
+		t.Fatalf("use after failed compact = %v, %v", fresh, err)
+	}
+	if err = store.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Remove(path + ".tmp"); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err := NewFileNonceStore(path, MemoryNonceOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	if fresh, _ := reopened.Use("key:n1", expireAt); fresh {
+		t.Fatal("nonce written after failed compact lost")
+	}
+}
+
+func TestFileNonceStoreReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nonce.log")
+	store, err := NewFileNonceStore(path, MemoryNonceOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expireAt := time.Now().Add(time.Minute)
+	if fresh, _ := store.Use("key:n1", expireAt); !fresh {
+		t.Fatal("first use rejected")
+	}
+	if err = store.Close(); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err := NewFileNonceStore(path, MemoryNonceOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	if fresh, _ := reopened.Use("key:n1", expireAt); fresh {
+		t.Fatal("replay accepted after reload")
+	}
+}
@@ -0,0 +1,287 @@
+package sapiclient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Option
+// @Description: NewClient的选项
+type Option func(c *sApiClient) error
+
+// WithCredentials
+//
+//	@Description: 设置appKey与appSecret
+//	@Author zzh 2026-10-19 21:02:10
+//	@param appKey
+//	@param appSecret
+//	@return Option
+func WithCredentials(appKey, appSecret string) Option {
+	return func(c *sApiClient) error {
+		if appKey == "" || appSecret == "" {
+			return errors.New("appKey或者appSecret不能为空")
+		}
+		c.appKey, c.appSecret = appKey, appSecret
+		return nil
+	}
+}
+
+// WithCredentialsProvider
+//
+//	@Description: 设置凭证来源，每次请求从中获取appKey与appSecret
+//	@Author zzh 2026-10-19 21:03:22
+//	@param provider
+//	@return Option
+func WithCredentialsProvider(provider CredentialsProvider) Option {
+	return func(c *sApiClient) error {
+		if provider == nil {
+			return errors.New("凭证来源不能为空")
+		}
+		c.credentials = provider
+		return nil
+	}
+}
+
+// WithBaseURL
+//
+//	@Description: 设置服务地址
+//	@Author zzh 2026-10-19 21:04:05
+//	@param serverUrl
+//	@return Option
+func WithBaseURL(serverUrl string) Option {
+	return func(c *sApiClient) error {
+		if err := checkServerUrl(serverUrl); err != nil {
+			return errors.New("serverUrl " + err.Error())
+		}
+		c.sapiServerUrl = serverUrl
+		return nil
+	}
+}
+
+// WithEndpoint
+//
+//	@Description: 为指定service设置单独的服务地址
+//	@Author zzh 2026-10-19 21:04:48
+//	@param service
+//	@param serverUrl
+//	@return Option
+func WithEndpoint(service, serverUrl string) Option {
+	return func(c *sApiClient) error {
+		if err := checkServerUrl(serverUrl); err != nil {
+			return errors.New(service + "服务地址" + err.Error())
+		}
+		c.SetEndpoint(service, serverUrl)
+		return nil
+	}
+}
+
+// WithServerIp
+//
+//	@Description: 指定服务ip
+//	@Author zzh 2026-10-19 21:05:30
+//	@param serverIp
+//	@return Option
+func WithServerIp(serverIp string) Option {
+	return func(c *sApiClient) error {
+		c.sapiServerIp = serverIp
+		return nil
+	}
+}
+
+// WithRequestMethod
+//
+//	@Description: 指定请求方法，仅支持GET或POST
+//	@Author zzh 2026-10-19 21:06:11
+//	@param requestMethod
+//	@return Option
+func WithRequestMethod(requestMethod string) Option {
+	return func(c *sApiClient) error {
+		requestMethod = strings.ToUpper(requestMethod)
+		if requestMethod != http.MethodGet && requestMethod != http.MethodPost {
+			return errors.New("requestMethod仅支持GET或POST")
+		}
+		c.requestMethod = requestMethod
+		return nil
+	}
+}
+
+// WithTimeout
+//
+//	@Description: 设置超时时间
+//	@Author zzh 2026-10-19 21:06:52
+//	@param timeout 秒
+//	@return Option
+func WithTimeout(timeout int) Option {
+	return func(c *sApiClient) error {
+		if timeout < 0 {
+			return errors.New("timeout不能为负数")
+		}
+		c.ClientOptions.Timeout = timeout
+		return nil
+	}
+}
+
+// WithRetry
+//
+//	@Description: 设置重试次数与重试等待时间
+//	@Author zzh 2026-10-19 21:07:35
+//	@param retryCount
+//	@param retryWaitTime 秒
+//	@return Option
+func WithRetry(retryCount, retryWaitTime int) Option {
+	return func(c *sApiClient) error {
+		if retryCount < 0 || retryWaitTime < 0 {
+			return errors.New("retryCount与retryWaitTime不能为负数")
+		}
+		c.ClientOptions.RetryCount = retryCount
+		c.ClientOptions.RetryWaitTime = retryWaitTime
+		return nil
+	}
+}
+
+// WithHeaders
+//
+//	@Description: 设置每次请求附带的请求头
+//	@Author zzh 2026-10-19 21:08:16
+//	@param headers
+//	@return Option
+func WithHeaders(headers map[string]string) Option {
+	return func(c *sApiClient) error {
+		c.ClientOptions.Headers = make(map[string]string, len(headers))
+		for key, val := range headers {
+			c.ClientOptions.Headers[key] = val
+		}
+		return nil
+	}
+}
+
+// WithTransport
+//
+//	@Description: 设置transport，如录制回放Recorder
+//	@Author zzh 2026-10-19 21:08:58
+//	@param transport
+//	@return Option
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *sApiClient) error {
+		c.transport = transport
+		return nil
+	}
+}
+
+// WithLogger
+//
+//	@Description: 设置请求日志
+//	@Author zzh 2026-10-19 21:09:40
+//	@param logger
+//	@return Option
+func WithLogger(logger Logger) Option {
+	return func(c *sApiClient) error {
+		c.logger = logger
+		return nil
+	}
+}
+
+// WithDebug
+//
+//	@Description: 输出完整的请求与响应内容
+//	@Author zzh 2026-10-19 21:10:21
+//	@return Option
+func WithDebug() Option {
+	return func(c *sApiClient) error {
+		c.debug = true
+		return nil
+	}
+}
+
+// WithSigner
+//
+//	@Description: 设置签名方式
+//	@Author zzh 2026-10-19 21:11:02
+//	@param signer
+//	@return Option
+func WithSigner(signer Signer) Option {
+	return func(c *sApiClient) error {
+		if signer == nil {
+			return errors.New("签名方式不能为空")
+		}
+		c.signer = signer
+		return nil
+	}
+}
+
+// WithBodyDigest
+//
+//	@Description: 发送请求体摘要并纳入签名，需要使用hmac-sha256签名
+//	@Author zzh 2026-10-19 21:11:44
+//	@return Option
+func WithBodyDigest() Option {
+	return func(c *sApiClient) error {
+		c.bodyDigest = true
+		return nil
+	}
+}
+
+// WithResponseVerify
+//
+//	@Description: 校验响应签名
+//	@Author zzh 2026-10-19 21:12:25
+//	@param serverKey 为空时使用appSecret
+//	@return Option
+func WithResponseVerify(serverKey string) Option {
+	return func(c *sApiClient) error {
+		c.responseVerify = true
+		c.responseKey = serverKey
+		return nil
+	}
+}
+
+// WithEncryption
+//
+//	@Description: 开启报文加密
+//	@Author zzh 2026-10-19 21:13:06
+//	@param keys 为nil时由appSecret派生密钥
+//	@return Option
+func WithEncryption(keys *EncryptKeys) Option {
+	return func(c *sApiClient) error {
+		if keys != nil && len(keys.Keys) > 0 {
+			if _, ok := keys.Keys[keys.CurrentKeyId]; !ok {
+				return fmt.Errorf("%w: %s", ErrEncryptKeyNotFound, keys.CurrentKeyId)
+			}
+		}
+		c.encrypt = true
+		c.encryptKeys = keys
+		return nil
+	}
+}
+
+// WithClockSkewCorrection
+//
+//	@Description: 使用测得的服务端时间偏差修正签名时间
+//	@Author zzh 2026-10-19 21:13:47
+//	@param timeEndpoint 服务端时间接口，为空时只根据响应头修正
+//	@return Option
+func WithClockSkewCorrection(timeEndpoint string) Option {
+	return func(c *sApiClient) error {
+		c.clock.enabled = true
+		c.timeEndpoint = timeEndpoint
+		return nil
+	}
+}
+
+// WithProfile
+//
+//	@Description: 使用已读取的profile配置，之后的选项覆盖profile中的配置
+//	@Author zzh 2026-10-19 21:14:30
+//	@param profile
+//	@return Option
+func WithProfile(profile *ProfileConfig) Option {
+	return func(c *sApiClient) error {
+		if profile == nil {
+			return errors.New("profile配置不能为空")
+		}
+		c.applyProfile(profile)
+		return nil
+	}
+}
@@ -0,0 +1,331 @@
+package sapiclient
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+const (
+	//Endpoints中表示直连的代理地址
+	PROXY_DIRECT = "direct"
+)
+
+// ProxyConfig
+// @Description: 代理配置，支持http://、https://（均通过CONNECT转发https请求）与socks5://代理
+type ProxyConfig struct {
+	URL             string            //默认代理地址，可包含user:password
+	Username        string            //代理认证用户名，覆盖URL中的用户信息
+	Password        string            //代理认证密码
+	NoProxy         []string          //不走代理的地址：host、.domain、*.domain、IP、CIDR，可带端口，*表示全部
+	Endpoints       map[string]string //service -> 代理地址，direct表示直连，优先于URL与NoProxy
+	FromEnvironment bool              //URL为空时使用HTTP_PROXY、HTTPS_PROXY、NO_PROXY环境变量
+}
+
+// enabled
+//
+//	@Description: 是否配置了代理
+//	@receiver p
+//	@Author zzh 2026-10-19 22:02:11
+//	@return bool
+func (p *ProxyConfig) enabled() bool {
+	return p != nil && (p.URL != "" || p.FromEnvironment || len(p.Endpoints) > 0)
+}
+
+// serviceContextKey 请求context中保存service的key，供ProxyTransport按service选择代理
+type serviceContextKey struct{}
+
+// ProxyTransport
+// @Description: 按service与目标地址选择代理的RoundTripper，每个代理使用独立的连接池
+type ProxyTransport struct {
+	base       *http.Transport
+	defaultUrl *url.URL
+	endpoints  map[string]*url.URL //service -> 代理，nil表示直连
+	noProxy    []noProxyRule
+	fromEnv    bool
+	mu         sync.Mutex
+	transports map[string]*http.Transport //代理地址 -> transport，直连为空字符串
+}
+
+// NewProxyTransport
+//
+//	@Description: 创建代理transport
+//	@Author zzh 2026-10-19 22:05:36
+//	@param config
+//	@param base 连接配置，为nil时使用http.DefaultTransport的副本，base本身的Proxy设置会被忽略
+//	@return *ProxyTransport
+//	@return error
+func NewProxyTransport(config ProxyConfig, base *http.Transport) (*ProxyTransport, error) {
+	if base == nil {
+		base = http.DefaultTransport.(*http.Transport).Clone()
+	}
+	p := &ProxyTransport{
+		base:       base,
+		endpoints:  make(map[string]*url.URL, len(config.Endpoints)),
+		fromEnv:    config.FromEnvironment,
+		transports: make(map[string]*http.Transport),
+	}
+	var err error
+	if config.URL != "" {
+		if p.defaultUrl, err = parseProxyUrl(config.URL, config.Username, config.Password); err != nil {
+			return nil, err
+		}
+	}
+	for service, proxyUrl := range config.Endpoints {
+		if strings.ToLower(proxyUrl) == PROXY_DIRECT {
+			p.endpoints[strings.ToLower(service)] = nil
+			continue
+		}
+		if p.endpoints[strings.ToLower(service)], err = parseProxyUrl(proxyUrl, config.Username, config.Password); err != nil {
+			return nil, errors.New(service + ": " + err.Error())
+		}
+	}
+	if p.noProxy, err = parseNoProxy(config.NoProxy); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// parseProxyUrl
+//
+//	@Description: 解析并校验代理地址，socks5h视为socks5（由代理解析域名）
+//	@Author zzh 2026-10-19 22:08:02
+//	@param proxyUrl
+//	@param username 不为空时覆盖地址中的用户信息
+//	@param password
+//	@return *url.URL
+//	@return error
+func parseProxyUrl(proxyUrl, username, password string) (*url.URL, error) {
+	parsed, err := url.Parse(proxyUrl)
+	if err != nil || parsed.Host == "" {
+		return nil, errors.New("代理地址格式不正确: " + proxyUrl)
+	}
+	switch parsed.Scheme {
+	case "http", "https", "socks5":
+	case "socks5h":
+		parsed.Scheme = "socks5"
+	default:
+		return nil, errors.New("不支持的代理类型: " + parsed.Scheme)
+	}
+	if username != "" {
+		parsed.User = url.UserPassword(username, password)
+	}
+	return parsed, nil
+}
+
+// RoundTrip
+//
+//	@Description: 选择代理后发送请求
+//	@receiver p
+//	@Author zzh 2026-10-19 22:10:25
+//	@param req
+//	@return *http.Response
+//	@return error
+func (p *ProxyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	proxyUrl, err := p.ProxyFor(req)
+	if err != nil {
+		return nil, err
+	}
+	return p.transportFor(proxyUrl).RoundTrip(req)
+}
+
+// ProxyFor
+//
+//	@Description: 返回请求使用的代理，nil表示直连。顺序：service单独配置 > NoProxy > 默认代理 > 环境变量
+//	@receiver p
+//	@Author zzh 2026-10-19 22:13:47
+//	@param req
+//	@return *url.URL
+//	@return error
+func (p *ProxyTransport) ProxyFor(req *http.Request) (*url.URL, error) {
+	if service, ok := req.Context().Value(serviceContextKey{}).(string); ok {
+		if proxyUrl, ok := p.endpoints[strings.ToLower(service)]; ok {
+			return proxyUrl, nil
+		}
+	}
+	if matchNoProxy(p.noProxy, req.URL) {
+		return nil, nil
+	}
+	if p.defaultUrl != nil {
+		return p.defaultUrl, nil
+	}
+	if p.fromEnv {
+		return http.ProxyFromEnvironment(req)
+	}
+	return nil, nil
+}
+
+// transportFor
+//
+//	@Description: 返回代理对应的transport，首次使用时由base复制
+//	@receiver p
+//	@Author zzh 2026-10-19 22:15:20
+//	@param proxyUrl
+//	@return *http.Transport
+func (p *ProxyTransport) transportFor(proxyUrl *url.URL) *http.Transport {
+	key := ""
+	if proxyUrl != nil {
+		key = proxyUrl.String()
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if transport, ok := p.transports[key]; ok {
+		return transport
+	}
+	transport := p.base.Clone()
+	transport.Proxy = nil
+	if proxyUrl != nil {
+		transport.Proxy = http.ProxyURL(proxyUrl)
+	}
+	p.transports[key] = transport
+	return transport
+}
+
+// CloseIdleConnections
+//
+//	@Description: 关闭所有代理连接池中的空闲连接
+//	@receiver p
+//	@Author zzh 2026-10-19 22:16:42
+func (p *ProxyTransport) CloseIdleConnections() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, transport := range p.transports {
+		transport.CloseIdleConnections()
+	}
+}
+
+// noProxyRule
+// @Description: 单条不走代理的规则
+type noProxyRule struct {
+	all       bool       //*
+	ipNet     *net.IPNet //CIDR或IP
+	domain    string     //以.开头的域名后缀
+	matchHost bool       //域名本身是否匹配
+	port      string     //为空时匹配所有端口
+}
+
+// parseNoProxy
+//
+//	@Description: 解析NO_PROXY规则：example.com匹配自身及子域名，.example.com与*.example.com只匹配子域名
+//	@Author zzh 2026-10-19 22:20:08
+//	@param patterns
+//	@return []noProxyRule
+//	@return error
+func parseNoProxy(patterns []string) ([]noProxyRule, error) {
+	rules := make([]noProxyRule, 0, len(patterns))
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == "" {
+			continue
+		}
+		if pattern == "*" {
+			rules = append(rules, noProxyRule{all: true})
+			continue
+		}
+		if _, ipNet, err := net.ParseCIDR(pattern); err == nil {
+			rules = append(rules, noProxyRule{ipNet: ipNet})
+			continue
+		}
+		rule := noProxyRule{}
+		host := pattern
+		if h, port, err := net.SplitHostPort(pattern); err == nil {
+			host, rule.port = h, port
+		}
+		if ip := net.ParseIP(strings.Trim(host, "[]")); ip != nil {
+			bits := 8 * net.IPv4len
+			if ip.To4() == nil {
+				bits = 8 * net.IPv6len
+			}
+			rule.ipNet = &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+			rules = append(rules, rule)
+			continue
+		}
+		host = strings.TrimPrefix(host, "*")
+		if host == "" || host == "." {
+			return nil, errors.New("NoProxy规则格式不正确: " + pattern)
+		}
+		if host[0] != '.' {
+			rule.matchHost = true
+			host = "." + host
+		}
+		rule.domain = host
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// matchNoProxy
+//
+//	@Description: 目标地址是否命中NO_PROXY规则
+//	@Author zzh 2026-10-19 22:23:31
+//	@param rules
+//	@param target
+//	@return bool
+func matchNoProxy(rules []noProxyRule, target *url.URL) bool {
+	host, port := strings.ToLower(target.Hostname()), target.Port()
+	if port == "" {
+		port = "80"
+		if target.Scheme == "https" {
+			port = "443"
+		}
+	}
+	ip := net.ParseIP(host)
+	for _, rule := range rules {
+		if rule.all {
+			return true
+		}
+		if rule.port != "" && rule.port != port {
+			continue
+		}
+		if rule.ipNet != nil {
+			if ip != nil && rule.ipNet.Contains(ip) {
+				return true
+			}
+			continue
+		}
+		if strings.HasSuffix(host, rule.domain) || (rule.matchHost && host == rule.domain[1:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// WithProxy
+//
+//	@Description: 通过代理访问服务，连接配置取自之前设置的*http.Transport，未设置时使用默认配置
+//	@Author zzh 2026-10-19 22:26:54
+//	@param config
+//	@return Option
+func WithProxy(config ProxyConfig) Option {
+	return func(c *sApiClient) error {
+		var base *http.Transport
+		if c.transport != nil {
+			transport, ok := c.transport.(*http.Transport)
+			if !ok {
+				return errors.New("自定义transport不是*http.Transport，无法设置代理")
+			}
+			base = transport
+		}
+		transport, err := NewProxyTransport(config, base)
+		if err != nil {
+			return err
+		}
+		c.transport = transport
+		return nil
+	}
+}
+
+// withServiceContext
+//
+//	@Description: 在请求context中记录service，供ProxyTransport选择代理
+//	@Author zzh 2026-10-19 22:28:10
+//	@param ctx
+//	@param service
+//	@return context.Context
+func withServiceContext(ctx context.Context, service string) context.Context {
+	return context.WithValue(ctx, serviceContextKey{}, service)
+}
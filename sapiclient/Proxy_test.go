@@ -0,0 +1,43 @@
+package sapiclient
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestMatchNoProxy(t *testing.T) {
+	rules, err := parseNoProxy([]string{"example.com", ".internal.net", "*.corp.io", "10.0.0.0/8", "192.168.1.10", "[::1]:8080", "api.local:8443"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for target, want := range map[string]bool{
+		"http://example.com/":          true,
+		"https://api.example.com/":     true,
+		"http://notexample.com/":       false,
+		"http://internal.net/":         false,
+		"http://a.internal.net/":       true,
+		"http://corp.io/":              false,
+		"http://b.corp.io/":            true,
+		"http://10.1.2.3/":             true,
+		"http://11.1.2.3/":             false,
+		"http://192.168.1.10:9000/":    true,
+		"http://192.168.1.11/":         false,
+		"http://[::1]:8080/":           true,
+		"http://[::1]:8081/":           false,
+		"https://API.local:8443/":      true,
+		"https://api.local/":           false,
+		"http://example.com.evil.net/": false,
+	} {
+		parsed, _ := url.Parse(target)
+		if got := matchNoProxy(rules, parsed); got != want {
+			t.Fatalf("matchNoProxy(%s) = %v, want %v", target, got, want)
+		}
+	}
+	all, _ := parseNoProxy([]string{" * "})
+	if parsed, _ := url.Parse("http://anything/"); !matchNoProxy(all, parsed) {
+		t.Fatal("* did not match")
+	}
+	if _, err = parseNoProxy([]string{"*."}); err == nil {
+		t.Fatal("invalid pattern accepted")
+	}
+}
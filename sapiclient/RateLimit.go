@@ -0,0 +1,104 @@
+package sapiclient
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// RateLimitConfig
+// @Description: 客户端限流配置，超过速率的请求等待令牌后再发送
+type RateLimitConfig struct {
+	RequestsPerSecond int //每秒允许发起的请求数，0表示不限流
+	Burst             int //允许的突发请求数，0表示与RequestsPerSecond相同
+}
+
+// rateLimiter
+// @Description: 令牌桶限流，客户端与其副本共享
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration //生成一个令牌的时间
+	burst    float64       //令牌桶容量
+	tokens   float64       //当前令牌数，为负数时表示已预约的令牌
+	last     time.Time     //上次计算令牌的时间
+}
+
+// newRateLimiter
+//
+//	@Description: 按配置创建限流器，未开启限流时返回nil
+//	@Author zzh 2026-10-20 04:50:12
+//	@param config
+//	@return *rateLimiter
+func newRateLimiter(config RateLimitConfig) *rateLimiter {
+	if config.RequestsPerSecond <= 0 {
+		return nil
+	}
+	burst := config.Burst
+	if burst <= 0 {
+		burst = config.RequestsPerSecond
+	}
+	return &rateLimiter{
+		interval: time.Second / time.Duration(config.RequestsPerSecond),
+		burst:    float64(burst),
+		tokens:   float64(burst),
+		last:     time.Now(),
+	}
+}
+
+// wait
+//
+//	@Description: 取得一个令牌，没有可用令牌时等待，ctx取消时归还预约的令牌并返回ctx的错误
+//	@receiver l 为nil时不限流
+//	@Author zzh 2026-10-20 04:51:40
+//	@param ctx
+//	@return error
+func (l *rateLimiter) wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += float64(now.Sub(l.last)) / float64(l.interval)
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+	l.tokens--
+	delay := time.Duration(0)
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens * float64(l.interval))
+	}
+	l.mu.Unlock()
+	if delay == 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// WithRateLimit
+//
+//	@Description: 限制每秒发起的请求数，超过速率的请求等待后发送，重试不额外占用令牌
+//	@Author zzh 2026-10-20 04:53:05
+//	@param requestsPerSecond
+//	@param burst 允许的突发请求数，0表示与requestsPerSecond相同
+//	@return Option
+func WithRateLimit(requestsPerSecond, burst int) Option {
+	return func(c *sApiClient) error {
+		if requestsPerSecond <= 0 || burst < 0 {
+			return errors.New("每秒请求数应大于0，突发请求数不能为负数")
+		}
+		c.limiter = newRateLimiter(RateLimitConfig{RequestsPerSecond: requestsPerSecond, Burst: burst})
+		return nil
+	}
+}
@@ -0,0 +1,218 @@
+package sapiclient
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	//响应签名请求头
+	RESPONSE_SIGN_HEADER = "resp-sign"
+	//响应签名时间请求头
+	RESPONSE_TIME_HEADER = "resp-time"
+)
+
+// ErrResponseSignature 响应签名校验失败
+var ErrResponseSignature = errors.New("响应签名校验失败")
+
+// ResponseSign
+//
+//	@Description: 生成响应签名，签名内容为nonce、time、body的sha256以换行连接，使用key做hmac-sha256
+//	@Author zzh 2026-10-19 16:02:44
+//	@param key appSecret或单独配置的服务端密钥
+//	@param nonce 对应请求的nonce
+//	@param timestamp 响应时间 秒
+//	@param body 响应体
+//	@return string
+func ResponseSign(key, nonce, timestamp string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+	return responseSignOfHash(key, nonce, timestamp, bodyHash[:])
+}
+
+// responseSignOfHash
+//
+//	@Description: 按响应体的sha256生成响应签名，用于边读取边计算摘要的流式响应
+//	@Author zzh 2026-10-20 01:31:05
+//	@param key
+//	@param nonce
+//	@param timestamp
+//	@param bodyHash
+//	@return string
+func responseSignOfHash(key, nonce, timestamp string, bodyHash []byte) string {
+	return HMACSHA256Sign(key, nonce+"\n"+timestamp+"\n"+hex.EncodeToString(bodyHash))
+}
+
+// SetResponseSignHeaders
+//
+//	@Description: 服务端为响应生成签名并写入响应头
+//	@Author zzh 2026-10-19 16:04:30
+//	@param header
+//	@param key
+//	@param nonce
+//	@param body
+func SetResponseSignHeaders(header http.Header, key, nonce string, body []byte) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	header.Set(RESPONSE_TIME_HEADER, timestamp)
+	header.Set(RESPONSE_SIGN_HEADER, ResponseSign(key, nonce, timestamp, body))
+}
+
+// VerifyResponseSign
+//
+//	@Description: 校验响应签名，resp-time与本地时间相差超过DEFAULT_TIME_WINDOW时同样返回ErrResponseSignature
+//	@Author zzh 2026-10-19 16:06:12
+//	@param header
+//	@param key
+//	@param nonce
+//	@param body
+//	@return error
+func VerifyResponseSign(header http.Header, key, nonce string, body []byte) error {
+	return verifyResponseSignAt(header, key, nonce, body, time.Now())
+}
+
+// verifyResponseSignAt
+//
+//	@Description: 以now为当前时间校验响应签名与响应时间
+//	@Author zzh 2026-10-20 12:40:18
+//	@param header
+//	@param key
+//	@param nonce
+//	@param body
+//	@param now 当前时间，客户端开启时间校正时为校正后的时间
+//	@return error
+func verifyResponseSignAt(header http.Header, key, nonce string, body []byte, now time.Time) error {
+	sign := header.Get(RESPONSE_SIGN_HEADER)
+	timestamp := header.Get(RESPONSE_TIME_HEADER)
+	if sign == "" || timestamp == "" {
+		return ErrResponseSignature
+	}
+	if !hmac.Equal([]byte(ResponseSign(key, nonce, timestamp, body)), []byte(sign)) {
+		return ErrResponseSignature
+	}
+	return checkResponseTime(timestamp, now)
+}
+
+// checkResponseTime
+//
+//	@Description: 检查响应时间是否在DEFAULT_TIME_WINDOW内，避免接受被截留后延迟转发的响应
+//	@Author zzh 2026-10-20 12:42:05
+//	@param timestamp resp-time 秒
+//	@param now
+//	@return error
+func checkResponseTime(timestamp string, now time.Time) error {
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: 响应时间格式不正确", ErrResponseSignature)
+	}
+	if skew := now.Sub(time.Unix(unix, 0)); skew > DEFAULT_TIME_WINDOW || skew < -DEFAULT_TIME_WINDOW {
+		return fmt.Errorf("%w: 响应时间超出允许范围", ErrResponseSignature)
+	}
+	return nil
+}
+
+// ResponseSignMiddleware
+//
+//	@Description: 服务端响应签名中间件，缓存响应体后按请求nonce生成签名；
+//	serverKey为空时使用SecretStore中请求appKey对应的appSecret
+//	@Author zzh 2026-10-19 16:09:37
+//	@param store
+//	@param serverKey
+//	@return func(http.Handler) http.Handler
+func ResponseSignMiddleware(store SecretStore, serverKey string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := serverKey
+			if key == "" && store != nil {
+				key, _ = store.GetSecret(r.Header.Get("appkey"))
+			}
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			recorder := &bufferedResponseWriter{header: make(http.Header), statusCode: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+			for name, values := range recorder.header {
+				w.Header()[name] = values
+			}
+			SetResponseSignHeaders(w.Header(), key, r.Header.Get("nonce"), recorder.body.Bytes())
+			w.WriteHeader(recorder.statusCode)
+			_, _ = w.Write(recorder.body.Bytes())
+		})
+	}
+}
+
+// bufferedResponseWriter
+// @Description: 缓存响应内容的ResponseWriter
+type bufferedResponseWriter struct {
+	header     http.Header
+	statusCode int
+	body       bytes.Buffer
+}
+
+// Header
+//
+//	@Description: 响应头
+//	@receiver b
+//	@Author zzh 2026-10-19 16:11:05
+//	@return http.Header
+func (b *bufferedResponseWriter) Header() http.Header {
+	return b.header
+}
+
+// Write
+//
+//	@Description: 写入响应体
+//	@receiver b
+//	@Author zzh 2026-10-19 16:11:48
+//	@param data
+//	@return int
+//	@return error
+func (b *bufferedResponseWriter) Write(data []byte) (int, error) {
+	return b.body.Write(data)
+}
+
+// WriteHeader
+//
+//	@Description: 记录状态码
+//	@receiver b
+//	@Author zzh 2026-10-19 16:12:30
+//	@param statusCode
+func (b *bufferedResponseWriter) WriteHeader(statusCode int) {
+	b.statusCode = statusCode
+}
+
+// SetResponseVerify
+//
+//	@Description: 开启响应签名校验，serverKey为空时使用appSecret
+//	@receiver c
+//	@Author zzh 2026-10-19 16:14:02
+//	@param enabled
+//	@param serverKey
+//	@return *sApiClient
+func (c *sApiClient) SetResponseVerify(enabled bool, serverKey string) *sApiClient {
+	c.responseVerify = enabled
+	c.responseKey = serverKey
+	return c
+}
+
+// verifyResponse
+//
+//	@Description: 开启响应签名校验时校验响应
+//	@receiver c
+//	@Author zzh 2026-10-19 16:15:47
+//	@param req
+//	@param header
+//	@param body
+//	@return error
+func (c *sApiClient) verifyResponse(req *preparedRequest, header http.Header, body []byte) error {
+	if req.verifyKey == "" {
+		return nil
+	}
+	return verifyResponseSignAt(header, req.verifyKey, req.headers["nonce"], body, c.clock.now())
+}
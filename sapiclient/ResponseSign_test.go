@@ -0,0 +1,70 @@
+package sapiclient
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestVerifyResponseSign(t *testing.T) {
+	body := []byte(`{"code":200,"msg":"success"}`)
+	header := http.Header{}
+	SetResponseSignHeaders(header, "server-key", "n1", body)
+	if err := VerifyResponseSign(header, "server-key", "n1", body); err != nil {
+		t.Fatalf("verify = %v", err)
+	}
+	for name, verify := range map[string]func() error{
+		"tampered body": func() error { return VerifyResponseSign(header, "server-key", "n1", []byte(`{"code":500}`)) },
+		"other nonce":   func() error { return VerifyResponseSign(header, "server-key", "n2", body) },
+		"wrong key":     func() error { return VerifyResponseSign(header, "other-key", "n1", body) },
+		"missing sign":  func() error { return VerifyResponseSign(http.Header{}, "server-key", "n1", body) },
+	} {
+		if err := verify(); !errors.Is(err, ErrResponseSignature) {
+			t.Fatalf("%s err = %v, want ErrResponseSignature", name, err)
+		}
+	}
+	// 签名有效但响应时间超出时间窗口
+	stale := http.Header{}
+	timestamp := strconv.FormatInt(time.Now().Add(-DEFAULT_TIME_WINDOW-time.Minute).Unix(), 10)
+	stale.Set(RESPONSE_TIME_HEADER, timestamp)
+	stale.Set(RESPONSE_SIGN_HEADER, ResponseSign("server-key", "n1", timestamp, body))
+	if err := VerifyResponseSign(stale, "server-key", "n1", body); !errors.Is(err, ErrResponseSignature) {
+		t.Fatalf("stale response err = %v, want ErrResponseSignature", err)
+	}
+	if err := verifyResponseSignAt(stale, "server-key", "n1", body, time.Now().Add(-DEFAULT_TIME_WINDOW)); err != nil {
+		t.Fatalf("response within corrected clock err = %v", err)
+	}
+}
+
+func TestClientResponseVerify(t *testing.T) {
+	var responseAge int64 //响应时间早于当前时间的秒数
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := []byte(`{"code":200,"msg":"success","data":"ok"}`)
+		timestamp := strconv.FormatInt(time.Now().Unix()-atomic.LoadInt64(&responseAge), 10)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set(RESPONSE_TIME_HEADER, timestamp)
+		w.Header().Set(RESPONSE_SIGN_HEADER, ResponseSign("server-key", r.Header.Get("nonce"), timestamp, body))
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+	c, err := newClient(WithCredentials("key", "secret"), WithBaseURL(server.URL), WithResponseVerify("server-key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response, callErr := c.Call("demo", "echo", nil); callErr != nil || response.Data != "ok" {
+		t.Fatalf("verified call = %+v, %v", response, callErr)
+	}
+	c.SetResponseVerify(true, "")
+	if _, err = c.Call("demo", "echo", nil); !errors.Is(err, ErrResponseSignature) {
+		t.Fatalf("appSecret key err = %v, want ErrResponseSignature", err)
+	}
+	c.SetResponseVerify(true, "server-key")
+	atomic.StoreInt64(&responseAge, 3600)
+	if _, err = c.Call("demo", "echo", nil); !errors.Is(err, ErrResponseSignature) {
+		t.Fatalf("stale response err = %v, want ErrResponseSignature", err)
+	}
+}
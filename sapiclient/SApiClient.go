@@ -1,17 +1,16 @@
 package sapiclient
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/go-resty/resty/v2"
-	"github.com/spf13/viper"
 	"net/http"
 	"net/url"
-	"os"
-	"path"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -36,6 +35,31 @@ type sApiClient struct {
 	RawResponseParams string      //响应参数
 	RawStatusCode     int         //响应状态码
 	ClientOptions     *ClientOptions
+	transport         http.RoundTripper   //自定义transport，如录制回放
+	signer            Signer              //签名方式，默认LegacyMD5Signer
+	bodyDigest        bool                //是否发送请求体摘要并纳入签名
+	responseVerify    bool                //是否校验响应签名
+	responseKey       string              //响应签名密钥，为空时使用appSecret
+	encrypt           bool                //是否开启报文加密
+	encryptKeys       *EncryptKeys        //报文加密密钥，为nil时由appSecret派生
+	credentials       CredentialsProvider //凭证来源，为nil时使用appKey与appSecret
+	clock             *clockSkew          //服务端时间偏差
+	sidecars          *sidecarTransports  //unix socket与h2c连接使用的transport
+	compressSize      int                 //请求体超过该字节数时gzip压缩，0表示不压缩
+	maxDecompress     int64               //响应解压后的大小上限，0表示使用默认值
+	maxRequest        int64               //请求体大小上限，0表示不限制
+	maxResponse       int64               //响应体大小上限，0表示不限制
+	sizeLimits        methodLimits        //service/method -> 大小限制
+	upload            *multipartBody      //本次调用上传的文件，只在单次调用的副本上设置
+	timeEndpoint      string              //服务端时间接口
+	endpoints         map[string]string   //service -> 服务地址，未配置的service使用sapiServerUrl
+	logger            Logger              //请求日志
+	debug             bool                //是否输出完整的请求与响应内容
+	limiter           *rateLimiter        //限流，为nil时不限流
+	breakers          *circuitBreakers    //熔断，为nil时不熔断
+	mu                sync.RWMutex        //配置热加载时保护客户端配置
+	profile           *ProfileConfig      //当前生效的profile配置
+	cfgPath           []string            //创建客户端时使用的配置文件路径
 }
 
 // ClientOptions
@@ -51,53 +75,20 @@ type ClientOptions struct {
 // ResponseData
 // @Description: 响应结果数据
 type ResponseData struct {
-	Code int
-	Msg  string
-	Data interface{}
+	Code int         `json:"code"`
+	Msg  string      `json:"msg"`
+	Data interface{} `json:"data"`
 }
 
 // New
 //
-//	@Description: 创建一个SApiClient client方法
+//	@Description: 创建一个SApiClient client方法，读取配置文件中的默认profile，
+//	等同于NewClient(WithProfile(profile))，但不要求配置中包含appKey与appSecret
 //	@Author zzh 2023-10-31 17:57:20
 //	@param ctx
 //	@return *sApiClient
 func New(cfgPath ...string) (c *sApiClient, err error) {
-	// 获取项目目录
-	workDir, _ := os.Getwd()
-	viperObject := viper.New()
-	cfg := CFG_PATH
-	if len(cfgPath) > 0 {
-		cfg = cfgPath[0]
-	}
-	appKey, appSecret, serverUrl := "", "", ""
-	filePath := path.Join(workDir, cfg)
-	_, err = os.Stat(filePath)
-	if err == nil && !os.IsNotExist(err) {
-		viperObject.SetConfigFile(filePath)
-		if err = viperObject.ReadInConfig(); err != nil {
-			err = errors.New("配置文件读取失败: " + err.Error())
-			return
-		}
-		if viperObject.IsSet("sapi.appKey") {
-			appKey = viperObject.GetString("sapi.appKey")
-		}
-		if viperObject.IsSet("sapi.appSecret") {
-			appSecret = viperObject.GetString("sapi.appSecret")
-		}
-		if viperObject.IsSet("sapi.serverUrl") {
-			serverUrl = viperObject.GetString("sapi.serverUrl")
-		}
-	}
-	if serverUrl == "" {
-		serverUrl = S_API_URL
-	}
-	return &sApiClient{
-		appKey:        appKey,
-		appSecret:     appSecret,
-		sapiServerUrl: serverUrl,
-		ClientOptions: &ClientOptions{},
-	}, nil
+	return NewProfile("", cfgPath...)
 }
 
 // DoRequest
@@ -109,83 +100,393 @@ func New(cfgPath ...string) (c *sApiClient, err error) {
 //	@return responseData
 //	@return err
 func (c *sApiClient) DoRequest(body map[string]interface{}) (responseData *ResponseData, err error) {
-	if c.appKey == "" || c.appSecret == "" {
-		err = errors.New("appKey或者appSecret不能为空")
+	response, err := c.do(context.Background(), body)
+	if response != nil {
+		c.RawResponseHeader = response.Header
+		c.RawResponseParams = string(response.Body)
+		c.RawStatusCode = response.StatusCode
+		responseData = response.ResponseData
+	}
+	return
+}
+
+// do
+//
+//	@Description: 发起请求并返回完整响应，收到响应时response不为nil
+//	@receiver c
+//	@Author zzh 2026-10-19 21:25:12
+//	@param ctx
+//	@param body
+//	@return response
+//	@return err
+func (c *sApiClient) do(ctx context.Context, body map[string]interface{}) (response *Response, err error) {
+	req, res, sentAt, err := c.send(ctx, body, false)
+	if req == nil {
 		return
 	}
-	if c.service == "" {
-		err = errors.New("service不能为空")
+	defer func() {
+		c.logRequest(req, res.StatusCode(), sentAt, err)
+	}()
+	if err != nil {
 		return
 	}
-	if c.method == "" {
-		err = errors.New("method不能为空")
+	response = &Response{StatusCode: res.StatusCode(), Header: res.Header(), Body: res.Body()}
+	if res.IsError() {
+		response.ResponseData = res.Error().(*ResponseData)
+		body, responseData, decryptErr := c.decryptErrorResponse(req, res.Header(), res.Body())
+		if decryptErr != nil {
+			err = decryptErr
+			return
+		}
+		if responseData != nil {
+			response.Body, response.ResponseData = body, responseData
+		}
+		if skewErr := c.clock.clockSkewError(res.StatusCode(), response.ResponseData); skewErr != nil {
+			err = skewErr
+			return
+		}
+		jsonErr, _ := json.Marshal(response.ResponseData)
+		err = errors.New(string(jsonErr))
+		return
+	}
+	if err = c.verifyResponse(req, res.Header(), res.Body()); err != nil {
+		return
+	}
+	if response.Body, err = c.decryptResponse(req, res.Header(), res.Body()); err != nil {
+		return
+	}
+	if err = json.Unmarshal(response.Body, &response.ResponseData); err != nil {
+		return
+	}
+	err = c.clock.clockSkewError(res.StatusCode(), response.ResponseData)
+	return
+}
+
+// send
+//
+//	@Description: 生成请求并发送，返回的req为nil时表示请求未能生成。stream为true时不读取响应体，
+//	由调用方读取并关闭res.RawBody()
+//	@receiver c
+//	@Author zzh 2026-10-20 01:27:36
+//	@param ctx
+//	@param body
+//	@param stream
+//	@return req
+//	@return res 不为nil
+//	@return sentAt
+//	@return err
+func (c *sApiClient) send(ctx context.Context, body map[string]interface{}, stream bool) (req *preparedRequest, res *resty.Response, sentAt time.Time, err error) {
+	c.mu.RLock()
+	limiter, breakers := c.limiter, c.breakers
+	c.mu.RUnlock()
+	// 先等待限流再签名，避免签名时间因等待而过期
+	if err = limiter.wait(ctx); err != nil {
+		return nil, nil, sentAt, err
+	}
+	probe, err := breakers.allow(c.service)
+	if err != nil {
+		return nil, nil, sentAt, err
+	}
+	defer func() {
+		if sentAt.IsZero() {
+			// 请求未能生成，不计入熔断统计
+			breakers.release(c.service, probe)
+			return
+		}
+		breakers.record(ctx, c.service, probe, res.StatusCode(), err)
+	}()
+	// 配置热加载时只影响之后的请求，请求参数与resty客户端在读锁内生成
+	c.mu.RLock()
+	req, err = c.prepareRequest(body)
+	if err != nil {
+		c.mu.RUnlock()
+		return nil, nil, sentAt, err
+	}
+	client := c.newRestyClient(req.sidecar, req.maxResponse, stream)
+	replay := isCassetteReplay(c.transport)
+	c.mu.RUnlock()
+	if replay {
+		// 回放的响应签名在录制时已脱敏，且不是服务端实时返回的，不校验
+		req.verifyKey = ""
+	}
+	clientReq := client.R().SetContext(withServiceContext(ctx, c.service)).SetHeaders(req.headers).SetError(&ResponseData{}).
+		SetDoNotParseResponse(stream)
+	sentAt = time.Now()
+	//目前只支持get和post请求并且get的参数在url中post的参数在body中
+	if c.upload != nil {
+		client.SetPreRequestHook(func(_ *resty.Client, r *http.Request) error {
+			// 大小已知时发送Content-Length，否则使用chunked
+			r.ContentLength = c.upload.size
+			return nil
+		})
+		res, err = clientReq.SetQueryParamsFromValues(req.query).SetBody(c.upload.reader()).Post(req.url)
+	} else if req.httpMethod == http.MethodGet {
+		res, err = clientReq.SetQueryParamsFromValues(req.query).Get(req.url)
+	} else {
+		res, err = clientReq.SetBody(req.body).Post(req.url)
+	}
+	if res == nil {
+		res = &resty.Response{}
+	}
+	if err != nil {
+		urlParse, _ := url.Parse(req.url)
+		if tlsErr := tlsError(err, urlParse.Host); errors.Is(tlsErr, ErrTLS) {
+			err = tlsErr
+			return
+		}
+		var limitErr *DecompressionLimitError
+		if errors.As(err, &limitErr) {
+			err = limitErr
+			return
+		}
+		var sizeErr *SizeLimitError
+		if errors.As(err, &sizeErr) {
+			sizeErr.Service, sizeErr.Method = c.service, c.method
+			err = sizeErr
+			return
+		}
+		var urlErr *url.Error
+		if errors.Is(err, ErrCassetteUnmatched) && errors.As(err, &urlErr) {
+			// 保留回放未匹配的错误，供errors.Is(err, ErrCassetteUnmatched)判断
+			err = urlErr.Err
+			return
+		}
+		err = errors.New(err.Error())
 		return
 	}
-	if c.sapiServerIp != "" {
-		urlParse, _ := url.Parse(c.sapiServerUrl)
-		c.sapiServerUrl = urlParse.Scheme + "://" + c.sapiServerIp + urlParse.Path
+	if !replay {
+		// 回放的响应头Date为录制时的时间，不作为偏差样本
+		c.clock.observeHeader(res.Header(), sentAt, time.Now())
 	}
+	return
+}
+
+// preparedRequest
+// @Description: 已签名待发送的请求
+type preparedRequest struct {
+	appKey      string            //本次请求使用的appKey
+	appSecret   string            //本次请求使用的appSecret
+	httpMethod  string            //HTTP请求方法
+	url         string            //完整请求地址
+	pathUrl     string            //sapi/<service>/<method>
+	query       url.Values        //get请求参数，上传时为普通字段
+	body        []byte            //post请求体
+	headers     map[string]string //请求头，包含签名
+	verifyKey   string            //响应签名校验密钥，为空时不校验
+	encrypt     bool              //是否开启报文加密
+	encryptKeys *EncryptKeys      //报文加密密钥
+	logger      Logger            //请求日志
+	sidecar     string            //连接目标，unix:<socket路径>或h2c:<host>，为空时使用普通transport
+	maxResponse int64             //响应体大小上限，0表示不限制
+}
+
+// prepareRequest
+//
+//	@Description: 校验配置、序列化参数并签名
+//	@receiver c
+//	@Author zzh 2026-10-19 14:48:12
+//	@param body
+//	@return *preparedRequest
+//	@return error
+func (c *sApiClient) prepareRequest(body map[string]interface{}) (*preparedRequest, error) {
+	credentials, err := c.currentCredentials()
+	if err != nil {
+		return nil, err
+	}
+	if credentials.AppKey == "" || credentials.AppSecret == "" {
+		return nil, errors.New("appKey或者appSecret不能为空")
+	}
+	if c.service == "" {
+		return nil, errors.New("service不能为空")
+	}
+	if c.method == "" {
+		return nil, errors.New("method不能为空")
+	}
+	serverUrl, sidecar := c.serverUrlFor(c.service)
 
 	pathUrl := "sapi/" + c.service + "/" + c.method
-	c.sapiServerUrl = strings.TrimRight(c.sapiServerUrl, "/") + "/"
-	urlReq := c.sapiServerUrl + pathUrl
+	serverUrl = strings.TrimRight(serverUrl, "/") + "/"
+	limits := c.sizeLimitsFor(c.service, c.method)
+	req := &preparedRequest{
+		appKey:      credentials.AppKey,
+		appSecret:   credentials.AppSecret,
+		httpMethod:  http.MethodPost,
+		url:         serverUrl + pathUrl,
+		pathUrl:     pathUrl,
+		query:       url.Values{},
+		encrypt:     c.encrypt,
+		encryptKeys: c.encryptKeys,
+		logger:      c.logger,
+		sidecar:     sidecar,
+		maxResponse: limits.MaxResponseSize,
+	}
+	if c.responseVerify {
+		req.verifyKey = c.responseKey
+		if req.verifyKey == "" {
+			req.verifyKey = credentials.AppSecret
+		}
+	}
 	headers := map[string]string{
 		"Accept":      "text/plain;charset=utf-8",
 		"Content-Typ": "application/x-www-form-urlencoded",
 		"charset":     "utf-8",
 	}
-	headerOptions := make(map[string]string, 0)
-	if c.ClientOptions.Headers != nil {
-		headerOptions = c.ClientOptions.Headers
+	// 复制客户端请求头，本次请求生成的请求头不写回客户端，避免带入之后的请求
+	headerOptions := make(map[string]string, len(c.ClientOptions.Headers)+8)
+	for key, val := range c.ClientOptions.Headers {
+		headerOptions[key] = val
 	}
 	headerOptions["client-version"] = VERSION_CLIENT
-	headerOptions["time"] = strconv.Itoa(int(time.Now().Unix()))
-	if c.ClientOptions.Nonce == "" {
+	headerOptions["time"] = strconv.Itoa(int(c.clock.now().Unix()))
+	headerOptions["nonce"] = c.ClientOptions.Nonce
+	if headerOptions["nonce"] == "" {
 		headerOptions["nonce"] = Alnum()
 	}
-	headerOptions["appkey"] = c.appKey
-	headerOptions["sign"] = SEncryptSign(c.appKey, c.appSecret, pathUrl, headerOptions["nonce"], headerOptions["time"])
+	headerOptions["appkey"] = req.appKey
+	if c.upload != nil {
+		c.upload.limit = limits.MaxRequestSize
+		if c.upload.limit > 0 && c.upload.size > c.upload.limit {
+			return nil, &SizeLimitError{Kind: SIZE_LIMIT_REQUEST, Service: c.service, Method: c.method,
+				Size: c.upload.size, Limit: c.upload.limit}
+		}
+		headerOptions["Content-Type"] = c.upload.contentType
+		headerOptions[PAYLOAD_HASH_HEADER] = UNSIGNED_PAYLOAD
+		// 文件内容不参与签名，普通字段放在query中参与签名，避免被改写
+		for k, v := range body {
+			req.query.Set(k, fmt.Sprintf("%v", v))
+		}
+	} else if c.requestMethod != "" && strings.ToLower(c.requestMethod) == "get" {
+		req.httpMethod = http.MethodGet
+		for k, v := range body {
+			req.query.Set(k, fmt.Sprintf("%v", v))
+		}
+	} else {
+		bodyBytes, err := json.Marshal(body)
+		if err != nil {
+			return nil, errors.New("请求参数序列化失败: " + err.Error())
+		}
+		req.body = bodyBytes
+		headerOptions["Content-Type"] = "application/json"
+	}
 	for key, val := range headerOptions {
 		headers[key] = val
 	}
-	c.ClientOptions.Headers = headers
-	client := resty.New()
-	if c.ClientOptions.Timeout != 0 {
-		client = client.SetTimeout(time.Duration(c.ClientOptions.Timeout) * time.Second)
-	}
-	if c.ClientOptions.RetryCount != 0 {
-		client = client.SetRetryCount(c.ClientOptions.RetryCount)
-		if c.ClientOptions.RetryCount != 0 {
-			client = client.SetRetryWaitTime(time.Duration(c.ClientOptions.RetryCount))
-		} else {
-			client = client.SetRetryWaitTime(1 * time.Second)
+	if req.encrypt {
+		if err := c.encryptRequest(req, headers); err != nil {
+			return nil, err
 		}
 	}
-	clientReq := client.R().SetHeaders(headers)
-	res := &resty.Response{}
-	//目前只支持get和post请求并且get的参数在url中post的参数在body中
-	if c.requestMethod != "" && strings.ToLower(c.requestMethod) == "get" {
-		for k, v := range body {
-			clientReq.SetQueryParam(k, fmt.Sprintf("%v", v))
+	if c.compressSize > 0 && len(req.body) > c.compressSize {
+		if compressed, ok := compressBody(req.body); ok {
+			req.body = compressed
+			headers[CONTENT_ENCODING_HEADER] = ENCODING_GZIP
 		}
-		res, err = clientReq.Get(urlReq)
-	} else {
-		res, err = clientReq.SetBody(body).Post(urlReq)
 	}
+	if c.signer.Version() == SIGN_VERSION_MD5 && ((c.bodyDigest && c.upload == nil) || headers[CONTENT_ENCODING_HEADER] != "") {
+		// md5签名与SEncryptSign保持一致，不覆盖请求体摘要与Content-Encoding
+		return nil, fmt.Errorf("%w: %s", ErrLegacyUnsupported, "请求体摘要与压缩")
+	}
+	if limits.MaxRequestSize > 0 && int64(len(req.body)) > limits.MaxRequestSize {
+		return nil, &SizeLimitError{Kind: SIZE_LIMIT_REQUEST, Service: c.service, Method: c.method,
+			Size: int64(len(req.body)), Limit: limits.MaxRequestSize}
+	}
+	signReq := &SignRequest{
+		AppKey:    req.appKey,
+		AppSecret: req.appSecret,
+		Method:    req.httpMethod,
+		Path:      pathUrl,
+		Query:     req.query,
+		Body:      req.body,
+		Nonce:     headers["nonce"],
+		Timestamp: headers["time"],
+		Header:    http.Header{},
+	}
+	// 上传时请求体不参与签名（UNSIGNED-PAYLOAD），签名时请求体尚未读取，不发送摘要
+	if c.bodyDigest && c.upload == nil {
+		headers[CONTENT_DIGEST_HEADER] = ContentDigest(signReq)
+	}
+	for key, val := range headers {
+		signReq.Header.Set(key, val)
+	}
+	signHeaders, err := c.signer.Sign(signReq)
 	if err != nil {
-		err = errors.New(err.Error())
-		return
+		return nil, fmt.Errorf("请求签名失败: %w", err)
 	}
-	if res.IsError() {
-		jsonErr, _ := json.Marshal(res.Error())
-		err = errors.New(string(jsonErr))
-		return
+	for key, val := range signHeaders {
+		headers[key] = val
 	}
-	c.RawResponseHeader = res.RawResponse.Header
-	c.RawResponseParams = res.String()
-	c.RawStatusCode = res.StatusCode()
-	err = json.Unmarshal(res.Body(), &responseData)
-	return
+	req.headers = headers
+	return req, nil
+}
+
+// serverUrlFor
+//
+//	@Description: 返回service使用的服务地址，已配置endpoint时使用endpoint，指定服务ip时替换host
+//	@receiver c
+//	@Author zzh 2026-10-20 05:20:36
+//	@param service
+//	@return string http地址
+//	@return string 连接目标，unix:<socket路径>或h2c:<host>，为空时使用普通transport
+func (c *sApiClient) serverUrlFor(service string) (string, string) {
+	serverUrl := c.sapiServerUrl
+	if endpoint, ok := c.endpoints[strings.ToLower(service)]; ok {
+		serverUrl = endpoint
+	}
+	if c.sapiServerIp != "" && !strings.HasPrefix(serverUrl, SCHEME_UNIX+":") {
+		urlParse, _ := url.Parse(serverUrl)
+		serverUrl = urlParse.Scheme + "://" + c.sapiServerIp + urlParse.Path
+	}
+	return sidecarUrl(serverUrl)
+}
+
+// newRestyClient
+//
+//	@Description: 按客户端配置创建resty客户端
+//	@receiver c
+//	@Author zzh 2026-10-19 14:52:40
+//	@param sidecar 连接目标，unix:<socket路径>或h2c:<host>，为空时使用客户端的transport
+//	@param maxResponse 响应体大小上限，0表示不限制
+//	@param stream 是否流式读取响应，流式调用未设置解压后大小上限时不使用DEFAULT_MAX_DECOMPRESSED_SIZE
+//	@return *resty.Client
+func (c *sApiClient) newRestyClient(sidecar string, maxResponse int64, stream bool) *resty.Client {
+	client := resty.New()
+	transport := client.GetClient().Transport
+	if sidecar != "" {
+		var err error
+		if transport, err = c.sidecars.transportFor(c.transport, sidecar); err != nil {
+			transport = errorTransport{err: err}
+		}
+	} else if c.transport != nil {
+		transport = c.transport
+	}
+	maxDecompress := c.maxDecompress
+	if maxDecompress == 0 && !stream {
+		maxDecompress = DEFAULT_MAX_DECOMPRESSED_SIZE
+	}
+	client = client.SetTransport(&decompressTransport{next: transport, maxSize: maxDecompress, maxBody: maxResponse})
+	if c.debug {
+		logger := c.logger
+		if logger == nil {
+			logger = NewStdLogger("")
+		}
+		client = client.SetDebug(true).SetLogger(restyLogger{logger: logger})
+	}
+	if c.ClientOptions.Timeout != 0 {
+		client = client.SetTimeout(time.Duration(c.ClientOptions.Timeout) * time.Second)
+	}
+	if c.ClientOptions.RetryCount != 0 {
+		client = client.SetRetryCount(c.ClientOptions.RetryCount).AddRetryCondition(func(_ *resty.Response, err error) bool {
+			// 超过大小限制时重试的结果相同
+			return err != nil && !errors.Is(err, ErrSizeLimit) && !errors.Is(err, ErrDecompressionLimit)
+		})
+		// 每次重试等待相同的时间，未设置时等待1秒
+		retryWaitTime := time.Duration(c.ClientOptions.RetryWaitTime) * time.Second
+		if retryWaitTime == 0 {
+			retryWaitTime = 1 * time.Second
+		}
+		client = client.SetRetryWaitTime(retryWaitTime).SetRetryMaxWaitTime(retryWaitTime)
+	}
+	return client
 }
 
 // SetClientCfg
@@ -243,6 +544,26 @@ func (c *sApiClient) SetSapiServerIp(sapiServerIp string) *sApiClient {
 	return c
 }
 
+// SetEndpoint
+//
+//	@Description: 为指定service设置单独的服务地址，serverUrl为空时恢复使用默认地址
+//	@receiver c
+//	@Author zzh 2026-10-19 18:50:21
+//	@param service
+//	@param serverUrl
+//	@return *sApiClient
+func (c *sApiClient) SetEndpoint(service, serverUrl string) *sApiClient {
+	if c.endpoints == nil {
+		c.endpoints = make(map[string]string)
+	}
+	if serverUrl == "" {
+		delete(c.endpoints, strings.ToLower(service))
+	} else {
+		c.endpoints[strings.ToLower(service)] = serverUrl
+	}
+	return c
+}
+
 // SetRequestMethod
 //
 //	@Description: 指定HTTP请求方法
@@ -292,3 +613,15 @@ func (c *sApiClient) SetTimeOut(timeOut int) *sApiClient {
 	c.ClientOptions.Timeout = timeOut
 	return c
 }
+
+// SetTransport
+//
+//	@Description: 设置自定义transport，如录制回放NewRecorder
+//	@receiver c
+//	@Author zzh 2026-10-19 10:52:37
+//	@param transport
+//	@return *sApiClient
+func (c *sApiClient) SetTransport(transport http.RoundTripper) *sApiClient {
+	c.transport = transport
+	return c
+}
@@ -0,0 +1,41 @@
+package sapiclient
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// failingTransport 记录每次请求的时间并返回连接错误
+type failingTransport struct {
+	mu       sync.Mutex
+	attempts []time.Time
+}
+
+func (t *failingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.attempts = append(t.attempts, time.Now())
+	return nil, errors.New("connection refused")
+}
+
+func TestRetryWaitTime(t *testing.T) {
+	transport := &failingTransport{}
+	c, err := newClient(WithCredentials("key", "secret"), WithBaseURL("http://127.0.0.1:1"),
+		WithTransport(transport), WithRetry(1, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetService("demo").SetMethod("echo")
+	if _, err = c.DoRequest(map[string]interface{}{}); err == nil {
+		t.Fatal("request succeeded")
+	}
+	if len(transport.attempts) != 2 {
+		t.Fatalf("attempts = %d, want 2", len(transport.attempts))
+	}
+	if wait := transport.attempts[1].Sub(transport.attempts[0]); wait < time.Second || wait > 3*time.Second {
+		t.Fatalf("retry wait = %s, want 1s", wait)
+	}
+}
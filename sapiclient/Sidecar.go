@@ -0,0 +1,100 @@
+package sapiclient
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+const (
+	//通过unix socket访问服务，如unix:///var/run/sapi.sock
+	SCHEME_UNIX = "unix"
+	//通过明文HTTP/2访问服务，如h2c://127.0.0.1:8080
+	SCHEME_H2C = "h2c"
+	//unix socket请求使用的Host
+	SIDECAR_HOST = "localhost"
+)
+
+// ErrSidecarTransport 自定义transport不支持unix与h2c地址
+var ErrSidecarTransport = errors.New("自定义transport不支持unix与h2c地址")
+
+// sidecarUrl
+//
+//	@Description: 将unix与h2c地址转换为http地址，其它地址原样返回
+//	@Author zzh 2026-10-19 23:40:12
+//	@param serverUrl
+//	@return string http地址
+//	@return string 连接目标，unix:<socket路径>或h2c:<host>，为空时使用普通transport
+func sidecarUrl(serverUrl string) (string, string) {
+	urlParse, err := url.Parse(serverUrl)
+	if err != nil {
+		return serverUrl, ""
+	}
+	switch urlParse.Scheme {
+	case SCHEME_UNIX:
+		return "http://" + SIDECAR_HOST + "/", SCHEME_UNIX + ":" + strings.TrimRight(urlParse.Path, "/")
+	case SCHEME_H2C:
+		return "http://" + urlParse.Host + urlParse.Path, SCHEME_H2C + ":" + urlParse.Host
+	}
+	return serverUrl, ""
+}
+
+// sidecarTransports
+// @Description: unix socket与h2c连接使用的transport，按连接目标缓存，客户端与其副本共享
+type sidecarTransports struct {
+	mu         sync.Mutex
+	base       http.RoundTripper          //创建缓存时客户端的transport，变化后清空缓存
+	transports map[string]*http.Transport //连接目标 -> transport
+}
+
+// transportFor
+//
+//	@Description: 返回连接目标使用的transport，连接配置取自客户端的transport，代理设置被忽略；
+//	自定义的transport（如录制回放）无法改为unix socket或h2c连接，返回ErrSidecarTransport
+//	@receiver s
+//	@Author zzh 2026-10-19 23:43:35
+//	@param base 客户端的transport
+//	@param target
+//	@return http.RoundTripper
+//	@return error
+func (s *sidecarTransports) transportFor(base http.RoundTripper, target string) (http.RoundTripper, error) {
+	var baseTransport *http.Transport
+	switch transport := base.(type) {
+	case nil:
+		baseTransport = http.DefaultTransport.(*http.Transport)
+	case *http.Transport:
+		baseTransport = transport
+	case *ProxyTransport:
+		baseTransport = transport.base
+	default:
+		return nil, errors.New(ErrSidecarTransport.Error() + ": " + target)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.transports == nil || s.base != base {
+		s.base, s.transports = base, make(map[string]*http.Transport)
+	}
+	if transport, ok := s.transports[target]; ok {
+		return transport, nil
+	}
+	transport := baseTransport.Clone()
+	transport.Proxy = nil
+	if strings.HasPrefix(target, SCHEME_UNIX+":") {
+		socket := strings.TrimPrefix(target, SCHEME_UNIX+":")
+		dial := transport.DialContext
+		if dial == nil {
+			dial = (&net.Dialer{}).DialContext
+		}
+		transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return dial(ctx, "unix", socket)
+		}
+	} else if err := enableH2C(transport); err != nil {
+		return nil, err
+	}
+	s.transports[target] = transport
+	return transport, nil
+}
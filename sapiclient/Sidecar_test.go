@@ -0,0 +1,52 @@
+package sapiclient
+
+import (
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSidecarUnixSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "sapi.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Skip("unix socket unavailable: " + err.Error())
+	}
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":200,"msg":"success","data":"` + r.URL.Path + `"}`))
+	})}
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer server.Close()
+	c, err := newClient(WithCredentials("key", "secret"), WithBaseURL("unix://"+socket))
+	if err != nil {
+		t.Fatal(err)
+	}
+	responseData, err := c.Call("demo", "echo", nil)
+	if err != nil || responseData.Data != "/sapi/demo/echo" {
+		t.Fatalf("response = %+v, %v", responseData, err)
+	}
+}
+
+func TestSidecarCustomTransport(t *testing.T) {
+	transport := &failingTransport{}
+	for _, serverUrl := range []string{"unix:///var/run/sapi.sock", "h2c://127.0.0.1:8080"} {
+		c, err := newClient(WithCredentials("key", "secret"), WithBaseURL(serverUrl), WithTransport(transport))
+		if err != nil {
+			if strings.HasPrefix(serverUrl, SCHEME_H2C) {
+				continue // 低于Go 1.24编译时不支持h2c
+			}
+			t.Fatal(err)
+		}
+		if _, err = c.Call("demo", "echo", nil); err == nil || !strings.Contains(err.Error(), ErrSidecarTransport.Error()) {
+			t.Fatalf("%s err = %v, want ErrSidecarTransport", serverUrl, err)
+		}
+	}
+	if len(transport.attempts) != 0 {
+		t.Fatalf("custom transport used %d times", len(transport.attempts))
+	}
+}
@@ -0,0 +1,317 @@
+package sapiclient
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+const (
+	//签名版本请求头
+	SIGN_VERSION_HEADER = "sign-version"
+	//参与签名的请求头列表
+	SIGNED_HEADERS_HEADER = "signed-headers"
+	//md5签名版本，兼容旧服务
+	SIGN_VERSION_MD5 = "1"
+	//hmac-sha256签名版本
+	SIGN_VERSION_HMAC_SHA256 = "2"
+)
+
+// ErrSignInvalid 签名校验失败
+var ErrSignInvalid = errors.New("签名校验失败")
+
+// ErrLegacyUnsupported md5签名无法覆盖请求体摘要、压缩与分段下载，需要使用hmac-sha256签名
+var ErrLegacyUnsupported = errors.New("md5签名不支持该请求，请使用hmac-sha256签名")
+
+// SignRequest
+// @Description: 参与签名的请求信息
+type SignRequest struct {
+	AppKey    string
+	AppSecret string
+	Method    string      //HTTP请求方法
+	Path      string      //sapi/<service>/<method>
+	Query     url.Values  //query参数
+	Body      []byte      //原始请求体
+	Nonce     string      //随机字符串
+	Timestamp string      //签名时间 秒
+	Header    http.Header //请求头
+}
+
+// Signer
+// @Description: 请求签名方式，同时负责服务端校验
+type Signer interface {
+	// Version 签名版本，通过sign-version请求头传递
+	Version() string
+	// Sign 生成签名，返回需要附加的请求头
+	Sign(req *SignRequest) (map[string]string, error)
+	// Verify 校验请求头中的签名
+	Verify(req *SignRequest) error
+}
+
+// LegacyMD5Signer
+// @Description: 原有的双重md5签名，签名内容为appKey+lower(path)+nonce+time，与SEncryptSign完全一致。
+// 请求带有Content-Digest、Content-Encoding、Range或If-Range时签名与校验均返回ErrLegacyUnsupported
+type LegacyMD5Signer struct{}
+
+// Version
+//
+//	@Description: 签名版本
+//	@receiver s
+//	@Author zzh 2026-10-19 15:02:10
+//	@return string
+func (s LegacyMD5Signer) Version() string {
+	return SIGN_VERSION_MD5
+}
+
+// Sign
+//
+//	@Description: 生成md5签名
+//	@receiver s
+//	@Author zzh 2026-10-19 15:03:24
+//	@param req
+//	@return map[string]string
+//	@return error
+func (s LegacyMD5Signer) Sign(req *SignRequest) (map[string]string, error) {
+	if err := checkLegacyHeaders(req.Header); err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		"sign":              SEncryptSign(req.AppKey, req.AppSecret, req.Path, req.Nonce, req.Timestamp),
+		SIGN_VERSION_HEADER: SIGN_VERSION_MD5,
+	}, nil
+}
+
+// Verify
+//
+//	@Description: 校验md5签名
+//	@receiver s
+//	@Author zzh 2026-10-19 15:04:41
+//	@param req
+//	@return error
+func (s LegacyMD5Signer) Verify(req *SignRequest) error {
+	if err := checkLegacyHeaders(req.Header); err != nil {
+		return err
+	}
+	sign := SEncryptSign(req.AppKey, req.AppSecret, req.Path, req.Nonce, req.Timestamp)
+	if subtle.ConstantTimeCompare([]byte(sign), []byte(req.Header.Get("sign"))) != 1 {
+		return ErrSignInvalid
+	}
+	return nil
+}
+
+// checkLegacyHeaders
+//
+//	@Description: md5签名不覆盖请求头，请求带有需要签名保护的请求头时返回ErrLegacyUnsupported，
+//	避免这些请求头被篡改而签名仍然有效
+//	@Author zzh 2026-10-20 00:10:25
+//	@param header
+//	@return error
+func checkLegacyHeaders(header http.Header) error {
+	for _, name := range []string{CONTENT_DIGEST_HEADER, CONTENT_ENCODING_HEADER, RANGE_HEADER, IF_RANGE_HEADER} {
+		if header.Get(name) != "" {
+			return fmt.Errorf("%w: %s", ErrLegacyUnsupported, name)
+		}
+	}
+	return nil
+}
+
+// HMACSHA256Signer
+// @Description: v2签名，使用appSecret对规范化请求串做hmac-sha256，Content-Digest、Content-Encoding、payload-hash、Range与If-Range存在时总是参与签名
+type HMACSHA256Signer struct {
+	SignedHeaders []string //额外参与签名的请求头
+}
+
+// Version
+//
+//	@Description: 签名版本
+//	@receiver s
+//	@Author zzh 2026-10-19 15:06:02
+//	@return string
+func (s HMACSHA256Signer) Version() string {
+	return SIGN_VERSION_HMAC_SHA256
+}
+
+// Sign
+//
+//	@Description: 生成hmac-sha256签名
+//	@receiver s
+//	@Author zzh 2026-10-19 15:07:19
+//	@param req
+//	@return map[string]string
+//	@return error
+func (s HMACSHA256Signer) Sign(req *SignRequest) (map[string]string, error) {
+	alwaysSigned := alwaysSignedHeaders()
+	signedHeaders := make([]string, 0, len(s.SignedHeaders)+len(alwaysSigned))
+	for _, name := range alwaysSigned {
+		if req.Header.Get(name) != "" {
+			signedHeaders = append(signedHeaders, name)
+		}
+	}
+	for _, name := range s.SignedHeaders {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != "" && !containsString(alwaysSigned, name) && req.Header.Get(name) != "" {
+			signedHeaders = append(signedHeaders, name)
+		}
+	}
+	sort.Strings(signedHeaders)
+	return map[string]string{
+		"sign":                HMACSHA256Sign(req.AppSecret, CanonicalSignString(req, signedHeaders)),
+		SIGN_VERSION_HEADER:   SIGN_VERSION_HMAC_SHA256,
+		SIGNED_HEADERS_HEADER: strings.Join(signedHeaders, ";"),
+	}, nil
+}
+
+// Verify
+//
+//	@Description: 校验hmac-sha256签名，参与签名的请求头以signed-headers为准
+//	@receiver s
+//	@Author zzh 2026-10-19 15:09:45
+//	@param req
+//	@return error
+func (s HMACSHA256Signer) Verify(req *SignRequest) error {
+	if err := verifyContentDigest(req); err != nil {
+		return err
+	}
+	signedHeaders := make([]string, 0)
+	for _, name := range strings.Split(req.Header.Get(SIGNED_HEADERS_HEADER), ";") {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			signedHeaders = append(signedHeaders, name)
+		}
+	}
+	sort.Strings(signedHeaders)
+	for _, name := range alwaysSignedHeaders() {
+		if req.Header.Get(name) != "" && !containsString(signedHeaders, name) {
+			return ErrSignInvalid
+		}
+	}
+	sign := HMACSHA256Sign(req.AppSecret, CanonicalSignString(req, signedHeaders))
+	if !hmac.Equal([]byte(sign), []byte(strings.ToLower(req.Header.Get("sign")))) {
+		return ErrSignInvalid
+	}
+	return nil
+}
+
+// alwaysSignedHeaders
+//
+//	@Description: v2签名中存在时总是参与签名的请求头，已小写
+//	@Author zzh 2026-10-20 02:01:18
+//	@return []string
+func alwaysSignedHeaders() []string {
+	return []string{strings.ToLower(CONTENT_DIGEST_HEADER), strings.ToLower(CONTENT_ENCODING_HEADER), PAYLOAD_HASH_HEADER,
+		strings.ToLower(RANGE_HEADER), strings.ToLower(IF_RANGE_HEADER)}
+}
+
+// SignerForVersion
+//
+//	@Description: 根据签名版本返回对应的签名方式，未传版本时视为md5签名
+//	@Author zzh 2026-10-19 15:11:30
+//	@param version
+//	@return Signer
+//	@return bool
+func SignerForVersion(version string) (Signer, bool) {
+	switch version {
+	case "", SIGN_VERSION_MD5:
+		return LegacyMD5Signer{}, true
+	case SIGN_VERSION_HMAC_SHA256:
+		return HMACSHA256Signer{}, true
+	}
+	return nil, false
+}
+
+// CanonicalSignString
+//
+//	@Description: 生成v2规范化签名串，各部分以换行连接：
+//	请求方法、/path、规范化query、body的sha256、appKey、nonce、time、参与签名的请求头(name:value)。
+//	payload-hash请求头为UNSIGNED-PAYLOAD时以UNSIGNED-PAYLOAD代替body的sha256
+//	@Author zzh 2026-10-19 15:15:08
+//	@param req
+//	@param signedHeaders 已小写并排序的请求头
+//	@return string
+func CanonicalSignString(req *SignRequest, signedHeaders []string) string {
+	payloadHash := UNSIGNED_PAYLOAD
+	if req.Header.Get(PAYLOAD_HASH_HEADER) != UNSIGNED_PAYLOAD {
+		bodyHash := sha256.Sum256(req.Body)
+		payloadHash = hex.EncodeToString(bodyHash[:])
+	}
+	parts := []string{
+		strings.ToUpper(req.Method),
+		"/" + strings.TrimLeft(req.Path, "/"),
+		CanonicalQuery(req.Query),
+		payloadHash,
+		req.AppKey,
+		req.Nonce,
+		req.Timestamp,
+	}
+	for _, name := range signedHeaders {
+		parts = append(parts, name+":"+strings.TrimSpace(req.Header.Get(name)))
+	}
+	return strings.Join(parts, "\n")
+}
+
+// CanonicalQuery
+//
+//	@Description: 规范化query参数，key按字节序排序，同名参数按值排序，
+//	key与value按RFC3986编码（与PHP rawurlencode一致）
+//	@Author zzh 2026-10-19 15:18:33
+//	@param query
+//	@return string
+func CanonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		values := append([]string(nil), query[key]...)
+		sort.Strings(values)
+		for _, val := range values {
+			pairs = append(pairs, RawUrlEncode(key)+"="+RawUrlEncode(val))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// RawUrlEncode
+//
+//	@Description: 按RFC3986编码，除字母数字和-_.~外全部编码为%XX
+//	@Author zzh 2026-10-19 15:20:02
+//	@param s
+//	@return string
+func RawUrlEncode(s string) string {
+	const hexChars = "0123456789ABCDEF"
+	var builder strings.Builder
+	for i := 0; i < len(s); i++ {
+		b := s[i]
+		if ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z') || ('0' <= b && b <= '9') || b == '-' || b == '_' || b == '.' || b == '~' {
+			builder.WriteByte(b)
+			continue
+		}
+		builder.WriteByte('%')
+		builder.WriteByte(hexChars[b>>4])
+		builder.WriteByte(hexChars[b&15])
+	}
+	return builder.String()
+}
+
+// SetSigner
+//
+//	@Description: 设置签名方式，默认LegacyMD5Signer
+//	@receiver c
+//	@Author zzh 2026-10-19 15:24:50
+//	@param signer
+//	@return *sApiClient
+func (c *sApiClient) SetSigner(signer Signer) *sApiClient {
+	if signer == nil {
+		signer = LegacyMD5Signer{}
+	}
+	c.signer = signer
+	return c
+}
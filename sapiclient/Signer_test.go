@@ -0,0 +1,77 @@
+package sapiclient
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func TestLegacyMD5SignerMatchesSEncryptSign(t *testing.T) {
+	req := &SignRequest{AppKey: "key", AppSecret: "secret", Method: http.MethodPost, Path: "sapi/Demo/Echo",
+		Body: []byte(`{"data":"hello"}`), Nonce: "nonce", Timestamp: "1700000000", Header: http.Header{}}
+	headers, err := LegacyMD5Signer{}.Sign(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := SEncryptSign("key", "secret", "sapi/Demo/Echo", "nonce", "1700000000"); headers["sign"] != want {
+		t.Fatalf("sign = %s, want %s", headers["sign"], want)
+	}
+	req.Header.Set("sign", headers["sign"])
+	if err = (LegacyMD5Signer{}).Verify(req); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{CONTENT_DIGEST_HEADER, CONTENT_ENCODING_HEADER, RANGE_HEADER, IF_RANGE_HEADER} {
+		req.Header.Set(name, "x")
+		if _, err = (LegacyMD5Signer{}).Sign(req); !errors.Is(err, ErrLegacyUnsupported) {
+			t.Fatalf("%s sign err = %v", name, err)
+		}
+		if err = (LegacyMD5Signer{}).Verify(req); !errors.Is(err, ErrLegacyUnsupported) {
+			t.Fatalf("%s verify err = %v", name, err)
+		}
+		req.Header.Del(name)
+	}
+}
+
+func TestHMACSHA256SignerSignsHeaders(t *testing.T) {
+	req := &SignRequest{AppKey: "key", AppSecret: "secret", Method: http.MethodGet, Path: "sapi/demo/export",
+		Nonce: "nonce", Timestamp: "1700000000", Header: http.Header{}}
+	req.Header.Set(RANGE_HEADER, "bytes=100-")
+	headers, err := HMACSHA256Signer{}.Sign(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, val := range headers {
+		req.Header.Set(key, val)
+	}
+	if err = (HMACSHA256Signer{}).Verify(req); err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set(RANGE_HEADER, "bytes=0-")
+	if err = (HMACSHA256Signer{}).Verify(req); !errors.Is(err, ErrSignInvalid) {
+		t.Fatalf("changed Range err = %v, want ErrSignInvalid", err)
+	}
+}
+
+func TestLegacySignerRejectsUnsignedFeatures(t *testing.T) {
+	c, err := newClient(WithCredentials("key", "secret"), WithBaseURL("http://127.0.0.1:1"), WithBodyDigest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetService("demo").SetMethod("echo")
+	if _, err = c.DoRequest(map[string]interface{}{"data": "hello"}); !errors.Is(err, ErrLegacyUnsupported) {
+		t.Fatalf("body digest err = %v", err)
+	}
+	if _, err = c.Download(context.Background(), "demo", "export", nil, ioutil.Discard, DownloadOptions{}); !errors.Is(err, ErrLegacyUnsupported) {
+		t.Fatalf("download err = %v", err)
+	}
+	if c, err = newClient(WithCredentials("key", "secret"), WithBaseURL("http://127.0.0.1:1")); err != nil {
+		t.Fatal(err)
+	}
+	options := CallOptions{Headers: map[string]string{RANGE_HEADER: "bytes=0-"}}
+	if _, err = c.CallWithOptions(context.Background(), "demo", "echo", nil, options); !errors.Is(err, ErrLegacyUnsupported) {
+		t.Fatalf("signer err = %v", err)
+	}
+}
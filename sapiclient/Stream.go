@@ -0,0 +1,414 @@
+package sapiclient
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	//流式调用读取错误响应体的大小上限
+	STREAM_ERROR_BODY_LIMIT = 1 << 20
+)
+
+// ErrStream 流式响应解析失败，可通过errors.Is(err, ErrStream)判断
+var ErrStream = errors.New("流式响应解析失败")
+
+// StreamError
+// @Description: 流式响应解析失败，Err为读取或解析的原始错误（如SizeLimitError、ctx取消）
+type StreamError struct {
+	Index  int   //出错的data数组项序号，从0开始，-1表示不在data数组中
+	Offset int64 //出错时已解析的响应体字节数
+	Err    error
+}
+
+// Error
+//
+//	@Description: 错误信息
+//	@receiver e
+//	@Author zzh 2026-10-20 01:02:10
+//	@return string
+func (e *StreamError) Error() string {
+	position := "偏移" + strconv.FormatInt(e.Offset, 10) + "字节"
+	if e.Index >= 0 {
+		position = "data第" + strconv.Itoa(e.Index) + "项，" + position
+	}
+	return ErrStream.Error() + ": " + position + ": " + e.Err.Error()
+}
+
+// Is
+//
+//	@Description: 支持errors.Is(err, ErrStream)
+//	@receiver e
+//	@Author zzh 2026-10-20 01:02:44
+//	@param target
+//	@return bool
+func (e *StreamError) Is(target error) bool {
+	return target == ErrStream
+}
+
+// Unwrap
+//
+//	@Description: 返回原始错误
+//	@receiver e
+//	@Author zzh 2026-10-20 01:03:05
+//	@return error
+func (e *StreamError) Unwrap() error {
+	return e.Err
+}
+
+// Stream
+// @Description: 流式响应，逐项读取data数组，内存占用与单项大小相关而与数组长度无关。
+// 用法：for s.Next() { s.Decode(&item) }，结束后检查s.Err()，最后调用Close
+type Stream struct {
+	StatusCode int         //响应状态码
+	Header     http.Header //响应头
+	decoder    *json.Decoder
+	body       io.Closer
+	finish     func() error //读取完毕后的校验，如响应签名
+	code       int
+	msg        string
+	index      int  //下一项的序号
+	pending    bool //当前项未被读取
+	inData     bool //正在读取data数组
+	dataSeen   bool //已读取到data
+	done       bool //已读取完毕或已关闭
+	err        error
+}
+
+// NewStream
+//
+//	@Description: 按响应格式{"code":..,"msg":..,"data":[..]}解析响应体，读取到data数组的开头时返回，
+//	data为null或不存在时没有数据项，data不是数组时返回错误。可用于解析已保存的响应，或在测试中构造Stream
+//	@Author zzh 2026-10-20 01:05:30
+//	@param body
+//	@return *Stream
+//	@return error
+func NewStream(body io.ReadCloser) (*Stream, error) {
+	return newStream(body, body, nil)
+}
+
+// newStream
+//
+//	@Description: 创建流式响应并读取到data数组的开头
+//	@Author zzh 2026-10-20 01:06:12
+//	@param reader 解析的内容
+//	@param body 关闭Stream时关闭
+//	@param finish 读取完毕后的校验，可为nil
+//	@return *Stream
+//	@return error
+func newStream(reader io.Reader, body io.Closer, finish func() error) (*Stream, error) {
+	s := &Stream{StatusCode: http.StatusOK, Header: http.Header{}, decoder: json.NewDecoder(reader), body: body, finish: finish}
+	if tok, err := s.decoder.Token(); err != nil || tok != json.Delim('{') {
+		if err == nil {
+			err = errors.New("响应不是JSON对象")
+		}
+		s.fail(-1, err)
+		return nil, s.err
+	}
+	s.readEnvelope()
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s, nil
+}
+
+// readEnvelope
+//
+//	@Description: 读取响应的code、msg等字段，读取到data数组的开头或响应结束时返回
+//	@receiver s
+//	@Author zzh 2026-10-20 01:08:40
+func (s *Stream) readEnvelope() {
+	for {
+		tok, err := s.decoder.Token()
+		if err != nil {
+			s.fail(-1, err)
+			return
+		}
+		if tok == json.Delim('}') {
+			s.end()
+			return
+		}
+		key, _ := tok.(string)
+		switch {
+		case strings.EqualFold(key, "code"):
+			err = s.decoder.Decode(&s.code)
+		case strings.EqualFold(key, "msg"):
+			err = s.decoder.Decode(&s.msg)
+		case strings.EqualFold(key, "data") && !s.dataSeen:
+			s.dataSeen = true
+			if tok, err = s.decoder.Token(); err == nil {
+				if tok == json.Delim('[') {
+					s.inData = true
+					return
+				}
+				if tok != nil {
+					err = errors.New("data不是数组")
+				}
+			}
+		default:
+			var skip json.RawMessage
+			err = s.decoder.Decode(&skip)
+		}
+		if err != nil {
+			s.fail(-1, err)
+			return
+		}
+	}
+}
+
+// end
+//
+//	@Description: 响应读取完毕，检查之后没有多余内容并执行校验
+//	@receiver s
+//	@Author zzh 2026-10-20 01:10:18
+func (s *Stream) end() {
+	if _, err := s.decoder.Token(); err != io.EOF {
+		if err == nil {
+			err = errors.New("响应结束后存在多余内容")
+		}
+		s.fail(-1, err)
+		return
+	}
+	if s.finish != nil {
+		s.err = s.finish()
+	}
+	_ = s.Close()
+}
+
+// fail
+//
+//	@Description: 记录错误并关闭响应体
+//	@receiver s
+//	@Author zzh 2026-10-20 01:11:02
+//	@param index
+//	@param err
+func (s *Stream) fail(index int, err error) {
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	s.err = &StreamError{Index: index, Offset: s.decoder.InputOffset(), Err: err}
+	_ = s.Close()
+}
+
+// Next
+//
+//	@Description: 移动到data数组的下一项，没有更多数据项或出错时返回false，之后需要检查Err。
+//	未读取的当前项会被跳过
+//	@receiver s
+//	@Author zzh 2026-10-20 01:12:35
+//	@return bool
+func (s *Stream) Next() bool {
+	if s.done || s.err != nil {
+		return false
+	}
+	if s.pending {
+		var skip json.RawMessage
+		if err := s.decoder.Decode(&skip); err != nil {
+			s.fail(s.index, err)
+			return false
+		}
+		s.pending = false
+		s.index++
+	}
+	if !s.inData {
+		return false
+	}
+	if s.decoder.More() {
+		s.pending = true
+		return true
+	}
+	if _, err := s.decoder.Token(); err != nil {
+		s.fail(s.index, err)
+		return false
+	}
+	s.inData = false
+	s.readEnvelope()
+	return false
+}
+
+// Decode
+//
+//	@Description: 将当前项解析到v，类型不匹配时返回错误但可以继续读取之后的数据项，其它错误会中止读取
+//	@receiver s
+//	@Author zzh 2026-10-20 01:14:20
+//	@param v
+//	@return error
+func (s *Stream) Decode(v interface{}) error {
+	if !s.pending {
+		if s.err != nil {
+			return s.err
+		}
+		return errors.New("没有可读取的数据项，需要先调用Next")
+	}
+	index := s.index
+	s.pending = false
+	s.index++
+	if err := s.decoder.Decode(v); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return &StreamError{Index: index, Offset: s.decoder.InputOffset(), Err: err}
+		}
+		s.fail(index, err)
+		return s.err
+	}
+	return nil
+}
+
+// Code
+//
+//	@Description: 响应的code，在data之前返回时创建Stream后即可读取，否则需要读取完data数组
+//	@receiver s
+//	@Author zzh 2026-10-20 01:15:48
+//	@return int
+func (s *Stream) Code() int {
+	return s.code
+}
+
+// Msg
+//
+//	@Description: 响应的msg，读取时机与Code相同
+//	@receiver s
+//	@Author zzh 2026-10-20 01:16:10
+//	@return string
+func (s *Stream) Msg() string {
+	return s.msg
+}
+
+// Err
+//
+//	@Description: 读取过程中的错误，响应签名在读取完毕后校验，因此处理数据项的结果应在Err返回nil后再提交
+//	@receiver s
+//	@Author zzh 2026-10-20 01:16:52
+//	@return error
+func (s *Stream) Err() error {
+	return s.err
+}
+
+// Close
+//
+//	@Description: 关闭响应体，可重复调用，未读取完的数据项被丢弃
+//	@receiver s
+//	@Author zzh 2026-10-20 01:17:30
+//	@return error
+func (s *Stream) Close() error {
+	if s.done {
+		return nil
+	}
+	s.done = true
+	s.pending, s.inData = false, false
+	if s.body == nil {
+		return nil
+	}
+	return s.body.Close()
+}
+
+// CallStream
+//
+//	@Description: 流式调用服务方法，适用于data为大数组的导出类方法。响应体大小限制同样生效，
+//	导出方法可单独放宽；不支持报文加密。返回的Stream使用完后需要调用Close
+//	@receiver c
+//	@Author zzh 2026-10-20 01:20:14
+//	@param ctx
+//	@param service
+//	@param method
+//	@param body
+//	@param options
+//	@return *Stream
+//	@return error
+func (c *sApiClient) CallStream(ctx context.Context, service, method string, body map[string]interface{}, options CallOptions) (*Stream, error) {
+	clone := c.cloneForCall(service, method, options)
+	if clone.encrypt {
+		return nil, fmt.Errorf("%w: 流式调用不支持报文加密", ErrEncrypt)
+	}
+	return clone.stream(ctx, body)
+}
+
+// stream
+//
+//	@Description: 发送请求并创建流式响应
+//	@receiver c
+//	@Author zzh 2026-10-20 01:22:40
+//	@param ctx
+//	@param body
+//	@return s
+//	@return err
+func (c *sApiClient) stream(ctx context.Context, body map[string]interface{}) (s *Stream, err error) {
+	req, res, sentAt, err := c.send(ctx, body, true)
+	if req == nil {
+		return nil, err
+	}
+	defer func() {
+		c.logRequest(req, res.StatusCode(), sentAt, err)
+	}()
+	if err != nil {
+		return nil, err
+	}
+	rawBody := res.RawBody()
+	if res.IsError() {
+		content, _ := ioutil.ReadAll(io.LimitReader(rawBody, STREAM_ERROR_BODY_LIMIT))
+		_ = rawBody.Close()
+		responseData := &ResponseData{}
+		_ = json.Unmarshal(content, responseData)
+		if err = c.clock.clockSkewError(res.StatusCode(), responseData); err != nil {
+			return nil, err
+		}
+		jsonErr, _ := json.Marshal(responseData)
+		return nil, errors.New(string(jsonErr))
+	}
+	reader, finish, err := c.streamVerifier(req, res.Header(), rawBody)
+	if err != nil {
+		_ = rawBody.Close()
+		return nil, err
+	}
+	if s, err = newStream(reader, rawBody, finish); err != nil {
+		return nil, err
+	}
+	s.StatusCode, s.Header = res.StatusCode(), res.Header()
+	if err = c.clock.clockSkewError(s.StatusCode, &ResponseData{Code: s.code, Msg: s.msg}); err != nil {
+		_ = s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// streamVerifier
+//
+//	@Description: 开启响应签名校验时边读取边计算响应体摘要，读取完毕后校验签名
+//	@receiver c
+//	@Author zzh 2026-10-20 01:24:55
+//	@param req
+//	@param header
+//	@param body
+//	@return io.Reader
+//	@return func() error
+//	@return error
+func (c *sApiClient) streamVerifier(req *preparedRequest, header http.Header, body io.Reader) (io.Reader, func() error, error) {
+	if req.verifyKey == "" {
+		return body, nil, nil
+	}
+	sign, timestamp := header.Get(RESPONSE_SIGN_HEADER), header.Get(RESPONSE_TIME_HEADER)
+	if sign == "" || timestamp == "" {
+		return nil, nil, ErrResponseSignature
+	}
+	if err := checkResponseTime(timestamp, c.clock.now()); err != nil {
+		return nil, nil, err
+	}
+	bodyHash := sha256.New()
+	finish := func() error {
+		expected := responseSignOfHash(req.verifyKey, req.headers["nonce"], timestamp, bodyHash.Sum(nil))
+		if !hmac.Equal([]byte(expected), []byte(sign)) {
+			return ErrResponseSignature
+		}
+		return nil
+	}
+	return io.TeeReader(body, bodyHash), finish, nil
+}
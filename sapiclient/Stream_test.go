@@ -0,0 +1,137 @@
+package sapiclient
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// streamItems 逐项读取数据项，返回读取到的id
+func streamItems(s *Stream) []int {
+	ids := make([]int, 0)
+	for s.Next() {
+		item := struct{ Id int }{}
+		if s.Decode(&item) == nil {
+			ids = append(ids, item.Id)
+		}
+	}
+	return ids
+}
+
+func TestStreamMalformedItem(t *testing.T) {
+	s, err := NewStream(ioutil.NopCloser(strings.NewReader(`{"code":200,"msg":"ok","data":[{"id":1},{"id":"x"},{"id":3},{"id":}]}`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 类型不匹配的数据项被跳过，语法错误中止读取
+	if ids := streamItems(s); len(ids) != 2 || ids[0] != 1 || ids[1] != 3 {
+		t.Fatalf("ids = %v", ids)
+	}
+	var streamErr *StreamError
+	if !errors.Is(s.Err(), ErrStream) || !errors.As(s.Err(), &streamErr) || streamErr.Index != 3 {
+		t.Fatalf("err = %v, want StreamError at item 3", s.Err())
+	}
+	if s.Next() {
+		t.Fatal("Next after error returned true")
+	}
+	if _, err = NewStream(ioutil.NopCloser(strings.NewReader(`{"code":200,"data":{"id":1}}`))); !errors.Is(err, ErrStream) {
+		t.Fatalf("object data err = %v, want ErrStream", err)
+	}
+}
+
+func TestStreamErrorsMidStream(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/sapi/demo/truncated":
+			// 声明的长度大于实际发送的内容，连接在第3项中途断开
+			w.Header().Set("Content-Length", "1000")
+			_, _ = w.Write([]byte(`{"code":200,"msg":"ok","data":[{"id":1},{"id":2},{"id"`))
+		case "/sapi/demo/stalled":
+			_, _ = w.Write([]byte(`{"code":200,"msg":"ok","data":[{"id":1},`))
+			w.(http.Flusher).Flush()
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		default:
+			// 分块发送，响应头中没有Content-Length
+			_, _ = w.Write([]byte(`{"code":200,"msg":"ok","data":[{"id":0}`))
+			w.(http.Flusher).Flush()
+			for i := 1; i < 100; i++ {
+				_, _ = w.Write([]byte(`,{"id":` + strconv.Itoa(i) + `}`))
+			}
+			_, _ = w.Write([]byte(`]}`))
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+	c, err := newClient(WithCredentials("key", "secret"), WithBaseURL(server.URL),
+		WithMethodSizeLimits("demo", "limited", SizeLimits{MaxResponseSize: 200}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := c.CallStream(context.Background(), "demo", "truncated", nil, CallOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var streamErr *StreamError
+	if ids := streamItems(s); len(ids) != 2 || !errors.As(s.Err(), &streamErr) || streamErr.Index != 2 {
+		t.Fatalf("truncated ids = %v, err = %v", ids, s.Err())
+	}
+	// 超过响应体大小限制时中止读取，之前的数据项正常返回
+	if s, err = c.CallStream(context.Background(), "demo", "limited", nil, CallOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	var sizeErr *SizeLimitError
+	if ids := streamItems(s); len(ids) == 0 || len(ids) == 100 || !errors.Is(s.Err(), ErrStream) || !errors.As(s.Err(), &sizeErr) {
+		t.Fatalf("limited ids = %d, err = %v", len(ids), s.Err())
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if s, err = c.CallStream(ctx, "demo", "stalled", nil, CallOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if !s.Next() || s.Decode(&struct{}{}) != nil {
+		t.Fatalf("first item err = %v", s.Err())
+	}
+	time.AfterFunc(50*time.Millisecond, cancel)
+	if ids := streamItems(s); len(ids) != 0 || !errors.Is(s.Err(), ErrStream) || !errors.Is(s.Err(), context.Canceled) {
+		t.Fatalf("canceled stream ids = %v, err = %v", ids, s.Err())
+	}
+	if err = s.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStreamResponseSignMismatch(t *testing.T) {
+	body := `{"code":200,"msg":"ok","data":[{"id":1},{"id":2}]}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set(RESPONSE_TIME_HEADER, timestamp)
+		w.Header().Set(RESPONSE_SIGN_HEADER, ResponseSign("server-key", r.Header.Get("nonce"), timestamp, []byte(body)))
+		// 签名后篡改数据项
+		_, _ = w.Write([]byte(strings.Replace(body, `"id":2`, `"id":9`, 1)))
+	}))
+	defer server.Close()
+	c, err := newClient(WithCredentials("key", "secret"), WithBaseURL(server.URL), WithResponseVerify("server-key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := c.CallStream(context.Background(), "demo", "export", nil, CallOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 数据项在校验签名前已返回，签名在读取完毕后校验
+	if ids := streamItems(s); len(ids) != 2 || !errors.Is(s.Err(), ErrResponseSignature) {
+		t.Fatalf("ids = %v, err = %v", ids, s.Err())
+	}
+}
@@ -0,0 +1,419 @@
+package sapiclient
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	//SPKI固定的前缀，pin格式为sha256/<base64>
+	TLS_PIN_PREFIX = "sha256/"
+)
+
+// TLSError的原因
+const (
+	TLS_REASON_UNKNOWN_AUTHORITY = "证书不受信任"
+	TLS_REASON_HOSTNAME          = "证书与域名不匹配"
+	TLS_REASON_INVALID           = "证书无效或已过期"
+	TLS_REASON_PIN               = "证书公钥与固定的公钥不匹配"
+	TLS_REASON_CLIENT_CERT       = "客户端证书加载失败"
+)
+
+// ErrTLS TLS校验失败，可通过errors.Is(err, ErrTLS)判断
+var ErrTLS = errors.New("TLS校验失败")
+
+// TLSError
+// @Description: TLS校验失败
+type TLSError struct {
+	Host   string //服务地址host
+	Reason string //失败原因，TLS_REASON_*
+	Err    error  //原始错误
+}
+
+// Error
+//
+//	@Description: 错误信息
+//	@receiver e
+//	@Author zzh 2026-10-19 23:05:12
+//	@return string
+func (e *TLSError) Error() string {
+	msg := ErrTLS.Error() + ": " + e.Reason
+	if e.Host != "" {
+		msg += " " + e.Host
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Is
+//
+//	@Description: 支持errors.Is(err, ErrTLS)
+//	@receiver e
+//	@Author zzh 2026-10-19 23:05:48
+//	@param target
+//	@return bool
+func (e *TLSError) Is(target error) bool {
+	return target == ErrTLS
+}
+
+// Unwrap
+//
+//	@Description: 返回原始错误
+//	@receiver e
+//	@Author zzh 2026-10-19 23:06:20
+//	@return error
+func (e *TLSError) Unwrap() error {
+	return e.Err
+}
+
+// TLSConfig
+// @Description: TLS配置，均为零值时使用系统默认配置
+type TLSConfig struct {
+	CAFiles    []string //根证书文件（PEM，可包含多个证书），设置后只信任这些根证书
+	CertFile   string   //客户端证书，文件变化后在之后建立的连接中重新读取
+	KeyFile    string   //客户端证书私钥
+	MinVersion string   //最低TLS版本：1.0、1.1、1.2、1.3，默认1.2
+	ServerName string   //校验证书使用的域名，使用serverIp时需要设置
+	Pins       []string //证书链中任一证书的公钥（SPKI）sha256，格式为sha256/<base64>
+	BackupPins []string //备用公钥，更换证书前预先配置，设置Pins时必须设置
+}
+
+// enabled
+//
+//	@Description: 是否配置了TLS
+//	@receiver t
+//	@Author zzh 2026-10-19 23:08:02
+//	@return bool
+func (t *TLSConfig) enabled() bool {
+	return len(t.CAFiles) > 0 || t.CertFile != "" || t.KeyFile != "" || t.MinVersion != "" ||
+		t.ServerName != "" || len(t.Pins) > 0 || len(t.BackupPins) > 0
+}
+
+// check
+//
+//	@Description: 校验需要多个配置项共同决定的配置
+//	@receiver t
+//	@Author zzh 2026-10-19 23:09:35
+//	@return string 出错的配置项
+//	@return error
+func (t *TLSConfig) check() (string, error) {
+	if t.CertFile != "" && t.KeyFile == "" {
+		return "certFile", errors.New("需要同时设置keyFile")
+	}
+	if t.KeyFile != "" && t.CertFile == "" {
+		return "keyFile", errors.New("需要同时设置certFile")
+	}
+	if t.CertFile != "" {
+		if _, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile); err != nil {
+			return "certFile", errors.New(TLS_REASON_CLIENT_CERT + ": " + err.Error())
+		}
+	}
+	if len(t.Pins) > 0 && len(t.BackupPins) == 0 {
+		return "pins", errors.New("需要同时设置backupPins，避免更换证书后无法连接")
+	}
+	if len(t.BackupPins) > 0 && len(t.Pins) == 0 {
+		return "backupPins", errors.New("需要同时设置pins")
+	}
+	for _, backup := range t.BackupPins {
+		for _, pin := range t.Pins {
+			if backup == pin {
+				return "backupPins", errors.New("备用公钥不能与pins相同: " + backup)
+			}
+		}
+	}
+	return "", nil
+}
+
+// newTLSConfig
+//
+//	@Description: 按配置创建tls.Config，证书链由标准库校验，公钥固定在校验通过后检查
+//	@receiver t
+//	@Author zzh 2026-10-19 23:12:48
+//	@return *tls.Config
+//	@return error
+func (t *TLSConfig) newTLSConfig() (*tls.Config, error) {
+	if _, err := t.check(); err != nil {
+		return nil, err
+	}
+	config := &tls.Config{MinVersion: tls.VersionTLS12, ServerName: t.ServerName}
+	if t.MinVersion != "" {
+		version, err := parseTLSVersion(t.MinVersion)
+		if err != nil {
+			return nil, err
+		}
+		config.MinVersion = version
+	}
+	if len(t.CAFiles) > 0 {
+		pool, err := loadCAFiles(t.CAFiles)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = pool
+	}
+	if t.CertFile != "" {
+		reloader := &certReloader{certFile: t.CertFile, keyFile: t.KeyFile}
+		if _, err := reloader.load(); err != nil {
+			return nil, err
+		}
+		config.GetClientCertificate = reloader.GetClientCertificate
+	}
+	if len(t.Pins) > 0 {
+		pins := make(map[string]bool, len(t.Pins)+len(t.BackupPins))
+		for _, pin := range append(append([]string{}, t.Pins...), t.BackupPins...) {
+			digest, err := parsePin(pin)
+			if err != nil {
+				return nil, err
+			}
+			pins[digest] = true
+		}
+		config.VerifyConnection = func(state tls.ConnectionState) error {
+			return verifyPins(state, pins)
+		}
+	}
+	return config, nil
+}
+
+// parseTLSVersion
+//
+//	@Description: 解析TLS版本
+//	@Author zzh 2026-10-19 23:14:30
+//	@param version 1.0、1.1、1.2、1.3
+//	@return uint16
+//	@return error
+func parseTLSVersion(version string) (uint16, error) {
+	switch version {
+	case "1.0":
+		return tls.VersionTLS10, nil
+	case "1.1":
+		return tls.VersionTLS11, nil
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	}
+	return 0, errors.New("TLS版本仅支持1.0、1.1、1.2、1.3")
+}
+
+// loadCAFiles
+//
+//	@Description: 读取根证书文件
+//	@Author zzh 2026-10-19 23:15:52
+//	@param files
+//	@return *x509.CertPool
+//	@return error
+func loadCAFiles(files []string) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, errors.New("根证书读取失败: " + err.Error())
+		}
+		if !pool.AppendCertsFromPEM(content) {
+			return nil, errors.New("根证书文件中没有PEM格式的证书: " + file)
+		}
+	}
+	return pool, nil
+}
+
+// parsePin
+//
+//	@Description: 解析公钥固定，返回sha256摘要的base64
+//	@Author zzh 2026-10-19 23:17:20
+//	@param pin sha256/<base64>，也可以省略前缀
+//	@return string
+//	@return error
+func parsePin(pin string) (string, error) {
+	encoded := pin
+	if len(pin) > len(TLS_PIN_PREFIX) && pin[:len(TLS_PIN_PREFIX)] == TLS_PIN_PREFIX {
+		encoded = pin[len(TLS_PIN_PREFIX):]
+	}
+	digest, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(digest) != sha256.Size {
+		return "", errors.New("公钥固定应为sha256/<base64编码的sha256摘要>: " + pin)
+	}
+	return base64.StdEncoding.EncodeToString(digest), nil
+}
+
+// SPKIPin
+//
+//	@Description: 计算证书的公钥固定，可用于生成pins配置
+//	@Author zzh 2026-10-19 23:18:45
+//	@param cert
+//	@return string sha256/<base64>
+func SPKIPin(cert *x509.Certificate) string {
+	digest := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return TLS_PIN_PREFIX + base64.StdEncoding.EncodeToString(digest[:])
+}
+
+// verifyPins
+//
+//	@Description: 校验通过的证书链中任一证书的公钥命中固定的公钥即通过
+//	@Author zzh 2026-10-19 23:20:12
+//	@param state
+//	@param pins
+//	@return error
+func verifyPins(state tls.ConnectionState, pins map[string]bool) error {
+	for _, chain := range state.VerifiedChains {
+		for _, cert := range chain {
+			if pins[SPKIPin(cert)[len(TLS_PIN_PREFIX):]] {
+				return nil
+			}
+		}
+	}
+	return &TLSError{Host: state.ServerName, Reason: TLS_REASON_PIN}
+}
+
+// certReloader
+// @Description: 客户端证书，握手时发现文件变化则重新读取，读取失败时继续使用之前的证书
+type certReloader struct {
+	certFile string
+	keyFile  string
+	mu       sync.Mutex
+	cert     *tls.Certificate
+	modTime  time.Time //证书与私钥文件中较晚的修改时间
+}
+
+// GetClientCertificate
+//
+//	@Description: 供tls.Config使用，返回当前的客户端证书
+//	@receiver r
+//	@Author zzh 2026-10-19 23:23:40
+//	@param info
+//	@return *tls.Certificate
+//	@return error
+func (r *certReloader) GetClientCertificate(info *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	cert := r.cert
+	changed := cert == nil || r.latestModTime().After(r.modTime)
+	r.mu.Unlock()
+	if !changed {
+		return cert, nil
+	}
+	reloaded, err := r.load()
+	if err != nil {
+		if cert != nil {
+			// 证书与私钥可能尚未全部更新，保留之前的证书，之后的握手再重新读取
+			return cert, nil
+		}
+		return nil, err
+	}
+	return reloaded, nil
+}
+
+// load
+//
+//	@Description: 读取证书与私钥
+//	@receiver r
+//	@Author zzh 2026-10-19 23:25:18
+//	@return *tls.Certificate
+//	@return error
+func (r *certReloader) load() (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	modTime := r.latestModTime()
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return nil, &TLSError{Reason: TLS_REASON_CLIENT_CERT, Err: err}
+	}
+	r.cert, r.modTime = &cert, modTime
+	return r.cert, nil
+}
+
+// latestModTime
+//
+//	@Description: 证书与私钥文件中较晚的修改时间，需要在持有锁时调用
+//	@receiver r
+//	@Author zzh 2026-10-19 23:26:40
+//	@return time.Time
+func (r *certReloader) latestModTime() time.Time {
+	var latest time.Time
+	for _, file := range []string{r.certFile, r.keyFile} {
+		if info, err := os.Stat(file); err == nil && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest
+}
+
+// tlsError
+//
+//	@Description: 将标准库的证书校验错误转换为TLSError，其它错误原样返回
+//	@Author zzh 2026-10-19 23:28:55
+//	@param err
+//	@param host
+//	@return error
+func tlsError(err error, host string) error {
+	var target *TLSError
+	if errors.As(err, &target) {
+		if target.Host == "" {
+			target.Host = host
+		}
+		return target
+	}
+	var unknownAuthority x509.UnknownAuthorityError
+	if errors.As(err, &unknownAuthority) {
+		return &TLSError{Host: host, Reason: TLS_REASON_UNKNOWN_AUTHORITY, Err: unknownAuthority}
+	}
+	var hostnameErr x509.HostnameError
+	if errors.As(err, &hostnameErr) {
+		return &TLSError{Host: host, Reason: TLS_REASON_HOSTNAME, Err: hostnameErr}
+	}
+	var invalid x509.CertificateInvalidError
+	if errors.As(err, &invalid) {
+		return &TLSError{Host: host, Reason: TLS_REASON_INVALID, Err: invalid}
+	}
+	return err
+}
+
+// errorTransport
+// @Description: 配置无法生效时使用，所有请求返回该错误，避免在缺少TLS校验的情况下发送请求
+type errorTransport struct {
+	err error
+}
+
+// RoundTrip
+//
+//	@Description: 返回配置错误
+//	@receiver t
+//	@Author zzh 2026-10-19 23:30:20
+//	@param req
+//	@return *http.Response
+//	@return error
+func (t errorTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return nil, t.err
+}
+
+// WithTLS
+//
+//	@Description: 设置TLS，连接配置取自之前设置的*http.Transport，需要在WithProxy之前设置
+//	@Author zzh 2026-10-19 23:32:05
+//	@param config
+//	@return Option
+func WithTLS(config TLSConfig) Option {
+	return func(c *sApiClient) error {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		if c.transport != nil {
+			base, ok := c.transport.(*http.Transport)
+			if !ok {
+				return errors.New("自定义transport不是*http.Transport，无法设置TLS")
+			}
+			transport = base.Clone()
+		}
+		tlsConfig, err := config.newTLSConfig()
+		if err != nil {
+			return err
+		}
+		transport.TLSClientConfig = tlsConfig
+		c.transport = transport
+		return nil
+	}
+}
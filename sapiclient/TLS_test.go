@@ -0,0 +1,74 @@
+package sapiclient
+
+import (
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTLSPins(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":200,"msg":"success"}`))
+	}))
+	defer server.Close()
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := ioutil.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	serverPin := SPKIPin(server.Certificate())
+	otherPin := TLS_PIN_PREFIX + "47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="
+	call := func(config TLSConfig) error {
+		c, err := newClient(WithCredentials("key", "secret"), WithBaseURL(server.URL), WithTLS(config))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = c.Call("demo", "echo", nil)
+		return err
+	}
+	if err := call(TLSConfig{CAFiles: []string{caFile}, Pins: []string{serverPin}, BackupPins: []string{otherPin}}); err != nil {
+		t.Fatalf("pinned call = %v", err)
+	}
+	// 更换证书后命中备用公钥
+	if err := call(TLSConfig{CAFiles: []string{caFile}, Pins: []string{otherPin}, BackupPins: []string{serverPin}}); err != nil {
+		t.Fatalf("backup pin call = %v", err)
+	}
+	for name, tt := range map[string]struct {
+		config TLSConfig
+		reason string
+	}{
+		"pin mismatch":      {TLSConfig{CAFiles: []string{caFile}, Pins: []string{otherPin}, BackupPins: []string{"sha256/" + strings.Repeat("A", 43) + "="}}, TLS_REASON_PIN},
+		"unknown authority": {TLSConfig{Pins: []string{serverPin}, BackupPins: []string{otherPin}}, TLS_REASON_UNKNOWN_AUTHORITY},
+	} {
+		err := call(tt.config)
+		var tlsErr *TLSError
+		if !errors.Is(err, ErrTLS) || !errors.As(err, &tlsErr) || tlsErr.Reason != tt.reason || tlsErr.Host != server.Listener.Addr().String() {
+			t.Fatalf("%s err = %v, want TLSError %s", name, err, tt.reason)
+		}
+	}
+}
+
+func TestTLSConfigCheck(t *testing.T) {
+	pin := TLS_PIN_PREFIX + "47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="
+	for _, tt := range []struct {
+		config TLSConfig
+		key    string
+	}{
+		{TLSConfig{Pins: []string{pin}}, "pins"},
+		{TLSConfig{BackupPins: []string{pin}}, "backupPins"},
+		{TLSConfig{Pins: []string{pin}, BackupPins: []string{pin}}, "backupPins"},
+		{TLSConfig{CertFile: "client.pem"}, "certFile"},
+	} {
+		if key, err := tt.config.check(); key != tt.key || err == nil {
+			t.Fatalf("check(%+v) = %s, %v, want %s", tt.config, key, err, tt.key)
+		}
+	}
+	if _, err := (&TLSConfig{Pins: []string{"sha256/short"}, BackupPins: []string{pin}}).newTLSConfig(); err == nil {
+		t.Fatal("invalid pin accepted")
+	}
+}
@@ -1,7 +1,10 @@
 package sapiclient
 
 import (
+	"crypto/hmac"
 	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"strings"
 )
@@ -33,3 +36,32 @@ func Md5Encrypt(data string) string {
 	md5Val := fmt.Sprintf("%x", gmd5H.Sum(nil))
 	return md5Val
 }
+
+// HMACSHA256Sign
+//
+//	@Description: hmac-sha256生成签名，返回小写十六进制
+//	@Author zzh 2026-10-19 15:22:16
+//	@param secret
+//	@param data
+//	@return string
+func HMACSHA256Sign(secret, data string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(data))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// containsString
+//
+//	@Description: 切片中是否包含指定字符串
+//	@Author zzh 2026-10-20 00:12:40
+//	@param list
+//	@param s
+//	@return bool
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,310 @@
+package sapiclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	//请求体摘要请求头，值为UNSIGNED_PAYLOAD时请求体不参与签名
+	PAYLOAD_HASH_HEADER = "payload-hash"
+	//请求体不参与签名的标记，用于上传等流式请求
+	UNSIGNED_PAYLOAD = "UNSIGNED-PAYLOAD"
+	//无法推断文件类型时使用的内容类型
+	DEFAULT_UPLOAD_CONTENT_TYPE = "application/octet-stream"
+)
+
+// UploadPart
+// @Description: 上传的一个文件
+type UploadPart struct {
+	Field       string    //表单字段名
+	FileName    string    //文件名
+	ContentType string    //内容类型，为空时按文件名后缀推断，无法推断时为application/octet-stream
+	Reader      io.Reader //文件内容，实现io.Closer时上传结束后关闭
+	Size        int64     //内容大小 字节，用于计算进度与Content-Length，小于等于0表示未知
+}
+
+// UploadOptions
+// @Description: 上传选项
+type UploadOptions struct {
+	CallOptions                         //单次调用选项，RequestMethod对上传无效
+	Progress    func(sent, total int64) //进度回调，sent为已发送的请求体字节数，total未知时为-1，可能在其它goroutine中调用
+}
+
+// FilePart
+//
+//	@Description: 打开文件作为上传的一部分，文件在上传结束后关闭
+//	@Author zzh 2026-10-20 01:40:12
+//	@param field 表单字段名
+//	@param path 文件路径
+//	@return UploadPart
+//	@return error
+func FilePart(field, path string) (UploadPart, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return UploadPart{}, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return UploadPart{}, err
+	}
+	return UploadPart{Field: field, FileName: filepath.Base(path), Reader: file, Size: info.Size()}, nil
+}
+
+// multipartBody
+// @Description: 边读取文件边生成的multipart请求体
+type multipartBody struct {
+	parts       []UploadPart
+	boundary    string
+	contentType string
+	size        int64 //请求体大小，-1表示未知
+	limit       int64 //请求体大小上限，0表示不限制
+	progress    func(sent, total int64)
+	closeOnce   sync.Once
+	mu          sync.Mutex
+	err         error //生成请求体时的错误，如读取文件失败
+}
+
+// newMultipartBody
+//
+//	@Description: 校验上传内容，文件大小均已知时计算请求体大小
+//	@Author zzh 2026-10-20 01:42:35
+//	@param parts
+//	@param progress
+//	@return *multipartBody
+//	@return error
+func newMultipartBody(parts []UploadPart, progress func(sent, total int64)) (*multipartBody, error) {
+	b := &multipartBody{parts: make([]UploadPart, len(parts)), progress: progress}
+	copy(b.parts, parts)
+	for i := range b.parts {
+		part := &b.parts[i]
+		if part.Field == "" || part.Reader == nil {
+			b.close()
+			return nil, errors.New("上传文件的Field与Reader不能为空")
+		}
+		if part.ContentType == "" {
+			part.ContentType = mime.TypeByExtension(filepath.Ext(part.FileName))
+		}
+		if part.ContentType == "" {
+			part.ContentType = DEFAULT_UPLOAD_CONTENT_TYPE
+		}
+	}
+	counter := &countingWriter{}
+	writer := multipart.NewWriter(counter)
+	b.boundary = writer.Boundary()
+	b.contentType = writer.FormDataContentType()
+	size := int64(0)
+	// 以空内容写出一遍，得到除文件内容以外的大小
+	err := b.write(writer, func(part *UploadPart, w io.Writer) error {
+		if part.Size <= 0 {
+			size = -1
+		} else if size >= 0 {
+			size += part.Size
+		}
+		return nil
+	})
+	if err != nil {
+		b.close()
+		return nil, err
+	}
+	b.size = -1
+	if size >= 0 {
+		b.size = counter.n + size
+	}
+	return b, nil
+}
+
+// write
+//
+//	@Description: 按顺序写出文件，普通字段在签名的query中发送，不写入请求体
+//	@receiver b
+//	@Author zzh 2026-10-20 01:45:02
+//	@param writer
+//	@param content 写出文件内容
+//	@return error
+func (b *multipartBody) write(writer *multipart.Writer, content func(part *UploadPart, w io.Writer) error) error {
+	for i := range b.parts {
+		part := &b.parts[i]
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{"name": part.Field, "filename": part.FileName}))
+		header.Set("Content-Type", part.ContentType)
+		w, err := writer.CreatePart(header)
+		if err != nil {
+			return err
+		}
+		if err = content(part, w); err != nil {
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+// reader
+//
+//	@Description: 在新的goroutine中生成请求体，返回的reader被关闭时停止生成
+//	@receiver b
+//	@Author zzh 2026-10-20 01:47:30
+//	@return io.ReadCloser
+func (b *multipartBody) reader() io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		defer b.close()
+		writer := multipart.NewWriter(pw)
+		_ = writer.SetBoundary(b.boundary)
+		err := b.write(writer, func(part *UploadPart, w io.Writer) error {
+			if part.Size <= 0 {
+				_, err := io.Copy(w, part.Reader)
+				return b.setErr(err)
+			}
+			n, err := io.Copy(w, io.LimitReader(part.Reader, part.Size+1))
+			if err == nil && n != part.Size {
+				err = errors.New(part.FileName + "的内容大小与Size不一致")
+			}
+			return b.setErr(err)
+		})
+		_ = pw.CloseWithError(err)
+	}()
+	return &uploadReader{ReadCloser: pr, body: b}
+}
+
+// setErr
+//
+//	@Description: 记录生成请求体时的错误，reader被关闭导致的写入失败不记录
+//	@receiver b
+//	@Author zzh 2026-10-20 01:49:12
+//	@param err
+//	@return error
+func (b *multipartBody) setErr(err error) error {
+	if err == nil || errors.Is(err, io.ErrClosedPipe) {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.err == nil {
+		b.err = err
+	}
+	return err
+}
+
+// failure
+//
+//	@Description: 生成请求体时的错误
+//	@receiver b
+//	@Author zzh 2026-10-20 01:49:50
+//	@return error
+func (b *multipartBody) failure() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.err
+}
+
+// close
+//
+//	@Description: 关闭实现了io.Closer的文件内容，可重复调用
+//	@receiver b
+//	@Author zzh 2026-10-20 01:50:31
+func (b *multipartBody) close() {
+	b.closeOnce.Do(func() {
+		for _, part := range b.parts {
+			if closer, ok := part.Reader.(io.Closer); ok {
+				_ = closer.Close()
+			}
+		}
+	})
+}
+
+// uploadReader
+// @Description: 统计已发送的字节数，报告进度并检查大小上限
+type uploadReader struct {
+	io.ReadCloser
+	body *multipartBody
+	sent int64
+}
+
+// Read
+//
+//	@Description: 读取请求体
+//	@receiver r
+//	@Author zzh 2026-10-20 01:52:08
+//	@param p
+//	@return int
+//	@return error
+func (r *uploadReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.sent += int64(n)
+	if limit := r.body.limit; limit > 0 && r.sent > limit {
+		limitErr := r.body.setErr(&SizeLimitError{Kind: SIZE_LIMIT_REQUEST, Size: r.sent, Limit: limit})
+		_ = r.ReadCloser.Close()
+		return 0, limitErr
+	}
+	if n > 0 && r.body.progress != nil {
+		r.body.progress(r.sent, r.body.size)
+	}
+	return n, err
+}
+
+// countingWriter
+// @Description: 只统计写入字节数的writer
+type countingWriter struct {
+	n int64
+}
+
+// Write
+//
+//	@Description: 统计写入的字节数
+//	@receiver w
+//	@Author zzh 2026-10-20 01:53:15
+//	@param p
+//	@return int
+//	@return error
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
+
+// Upload
+//
+//	@Description: 以multipart/form-data上传文件，fields为普通字段，在query中发送并参与签名，请求体只包含文件。
+//	请求体边读取边发送，不参与签名（v2签名使用UNSIGNED-PAYLOAD），其余请求信息与普通调用相同；不支持报文加密与压缩，上传失败时不重试。parts中实现io.Closer的Reader在上传结束后关闭
+//	@receiver c
+//	@Author zzh 2026-10-20 01:55:40
+//	@param ctx
+//	@param service
+//	@param method
+//	@param fields
+//	@param parts
+//	@param options
+//	@return *Response
+//	@return error
+func (c *sApiClient) Upload(ctx context.Context, service, method string, fields map[string]interface{}, parts []UploadPart, options UploadOptions) (*Response, error) {
+	upload, err := newMultipartBody(parts, options.Progress)
+	if err != nil {
+		return nil, err
+	}
+	defer upload.close()
+	options.RequestMethod = ""
+	clone := c.cloneForCall(service, method, options.CallOptions)
+	if clone.encrypt {
+		return nil, fmt.Errorf("%w: 上传不支持报文加密", ErrEncrypt)
+	}
+	clone.upload = upload
+	clone.ClientOptions.RetryCount = 0
+	response, err := clone.do(ctx, fields)
+	if uploadErr := upload.failure(); uploadErr != nil {
+		var sizeErr *SizeLimitError
+		if errors.As(uploadErr, &sizeErr) {
+			sizeErr.Service, sizeErr.Method = service, method
+		}
+		return response, uploadErr
+	}
+	return response, err
+}
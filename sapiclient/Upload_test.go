@@ -0,0 +1,114 @@
+package sapiclient
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUploadWithBodyDigest(t *testing.T) {
+	for _, signer := range []Signer{LegacyMD5Signer{}, HMACSHA256Signer{}} {
+		t.Run(signer.Version(), func(t *testing.T) {
+			var digest, content string
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				digest = r.Header.Get(CONTENT_DIGEST_HEADER)
+				file, _, err := r.FormFile("file")
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				data, _ := ioutil.ReadAll(file)
+				content = string(data)
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"code":200,"msg":"success"}`))
+			})
+			server := httptest.NewServer(VerifyMiddleware(VerifyOptions{
+				SecretStore:       StaticSecretStore{"key": "secret"},
+				RequireBodyDigest: true,
+				UnsignedPayload:   true,
+			})(handler))
+			defer server.Close()
+			c, err := newClient(WithCredentials("key", "secret"), WithBaseURL(server.URL), WithSigner(signer), WithBodyDigest())
+			if err != nil {
+				t.Fatal(err)
+			}
+			part := UploadPart{Field: "file", FileName: "a.txt", Reader: strings.NewReader("hello"), Size: 5}
+			response, err := c.Upload(context.Background(), "doc", "upload", nil, []UploadPart{part}, UploadOptions{})
+			if err != nil || response.ResponseData.Code != http.StatusOK {
+				t.Fatalf("upload = %+v, %v", response, err)
+			}
+			if digest != "" || content != "hello" {
+				t.Fatalf("digest = %q, content = %q", digest, content)
+			}
+		})
+	}
+}
+
+func TestVerifyRequestUnsignedPayloadDigest(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/sapi/doc/upload", strings.NewReader("hello"))
+	r.Header.Set("appkey", "key")
+	r.Header.Set("nonce", "nonce")
+	r.Header.Set("time", strconv.FormatInt(time.Now().Unix(), 10))
+	r.Header.Set("sign", "sign")
+	r.Header.Set(PAYLOAD_HASH_HEADER, UNSIGNED_PAYLOAD)
+	r.Header.Set(CONTENT_DIGEST_HEADER, ContentDigest(&SignRequest{Method: http.MethodPost}))
+	_, err := VerifyRequest(r, &VerifyOptions{SecretStore: StaticSecretStore{"key": "secret"}, UnsignedPayload: true})
+	if !isVerifyCode(err, CODE_SIGN_INVALID) {
+		t.Fatalf("err = %v, want CODE_SIGN_INVALID", err)
+	}
+}
+
+// rewriteQueryTransport 改写请求query中的字段，模拟中间人篡改
+type rewriteQueryTransport struct {
+	key, val string
+}
+
+func (t rewriteQueryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.key != "" {
+		query := req.URL.Query()
+		query.Set(t.key, t.val)
+		req.URL.RawQuery = query.Encode()
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestUploadFieldsSigned(t *testing.T) {
+	var index, bodyIndex string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		index, bodyIndex = r.URL.Query().Get("index"), r.PostForm.Get("index")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":200,"msg":"success"}`))
+	})
+	server := httptest.NewServer(VerifyMiddleware(VerifyOptions{
+		SecretStore:     StaticSecretStore{"key": "secret"},
+		UnsignedPayload: true,
+	})(handler))
+	defer server.Close()
+	fields := map[string]interface{}{"uploadId": "upload-1", "index": 2, "checksum": "abc"}
+	for _, transport := range []rewriteQueryTransport{{}, {key: "index", val: "0"}, {key: "checksum", val: "def"}} {
+		c, err := newClient(WithCredentials("key", "secret"), WithBaseURL(server.URL), WithSigner(HMACSHA256Signer{}), WithTransport(transport))
+		if err != nil {
+			t.Fatal(err)
+		}
+		part := UploadPart{Field: "chunk", FileName: "chunk", Reader: strings.NewReader("hello"), Size: 5}
+		response, err := c.Upload(context.Background(), "doc", "upload", fields, []UploadPart{part}, UploadOptions{})
+		if transport.key == "" {
+			if err != nil || response.ResponseData.Code != http.StatusOK || index != "2" || bodyIndex != "" {
+				t.Fatalf("upload = %+v, %v, index = %q, body index = %q", response, err, index, bodyIndex)
+			}
+			continue
+		}
+		if response == nil || response.ResponseData == nil || response.ResponseData.Code != CODE_SIGN_INVALID {
+			t.Fatalf("rewritten %s accepted: %+v, %v", transport.key, response, err)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package sapiclient
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	//配置文件变化后等待的时间，合并编辑器保存时产生的多次写入
+	CONFIG_RELOAD_DEBOUNCE = 100 * time.Millisecond
+)
+
+// ReloadEvent
+// @Description: 配置热加载结果
+type ReloadEvent struct {
+	Profile string   //profile名称，配置文件读取失败时为空
+	Changes []string //生效的配置项，如appSecret、serverUrl，不包含具体值
+	Err     error    //读取或校验失败时的错误，此时继续使用原配置
+}
+
+// ConfigWatcher
+// @Description: 监听配置文件变化
+type ConfigWatcher struct {
+	watcher *fsnotify.Watcher
+	once    sync.Once
+	done    chan struct{}
+}
+
+// watchConfigFile
+//
+//	@Description: 监听配置文件所在目录，文件被修改、重新创建或符号链接指向变化（Kubernetes ConfigMap）时调用onChange
+//	@Author zzh 2026-10-19 20:05:12
+//	@param filePath
+//	@param onChange
+//	@return *ConfigWatcher
+//	@return error
+func watchConfigFile(filePath string, onChange func()) (*ConfigWatcher, error) {
+	if filePath == "" {
+		return nil, errors.New("客户端未从配置文件创建，无法监听配置变化")
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, errors.New("配置文件监听失败: " + err.Error())
+	}
+	configFile := filepath.Clean(filePath)
+	if err = watcher.Add(filepath.Dir(configFile)); err != nil {
+		_ = watcher.Close()
+		return nil, errors.New("配置文件监听失败: " + err.Error())
+	}
+	w := &ConfigWatcher{watcher: watcher, done: make(chan struct{})}
+	realFile, _ := filepath.EvalSymlinks(configFile)
+	go func() {
+		var timer *time.Timer
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				currentFile, _ := filepath.EvalSymlinks(configFile)
+				if (filepath.Clean(event.Name) == configFile && (event.Has(fsnotify.Write) || event.Has(fsnotify.Create))) ||
+					(currentFile != "" && currentFile != realFile) {
+					realFile = currentFile
+					if timer != nil {
+						timer.Stop()
+					}
+					timer = time.AfterFunc(CONFIG_RELOAD_DEBOUNCE, onChange)
+				}
+			case _, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+			case <-w.done:
+				if timer != nil {
+					timer.Stop()
+				}
+				return
+			}
+		}
+	}()
+	return w, nil
+}
+
+// Close
+//
+//	@Description: 停止监听
+//	@receiver w
+//	@Author zzh 2026-10-19 20:07:40
+//	@return error
+func (w *ConfigWatcher) Close() error {
+	var err error
+	w.once.Do(func() {
+		close(w.done)
+		err = w.watcher.Close()
+	})
+	return err
+}
+
+// WatchConfig
+//
+//	@Description: 监听创建客户端时使用的配置文件，变化时重新读取并校验，通过后替换之后请求使用的配置，
+//	进行中的请求不受影响；校验失败时保留原配置。onReload可以为nil
+//	@receiver c
+//	@Author zzh 2026-10-19 20:10:26
+//	@param onReload
+//	@return *ConfigWatcher
+//	@return error
+func (c *sApiClient) WatchConfig(onReload func(ReloadEvent)) (*ConfigWatcher, error) {
+	c.mu.RLock()
+	profile, cfgPath := c.profile, c.cfgPath
+	c.mu.RUnlock()
+	if profile == nil {
+		return nil, errors.New("客户端未从配置文件创建，无法监听配置变化")
+	}
+	file, err := loadConfig(cfgPath...)
+	if err != nil {
+		return nil, err
+	}
+	return watchConfigFile(file.path, func() {
+		event := c.reload(cfgPath, profile.Name)
+		if onReload != nil {
+			onReload(event)
+		}
+	})
+}
+
+// reload
+//
+//	@Description: 重新读取配置并应用
+//	@receiver c
+//	@Author zzh 2026-10-19 20:12:55
+//	@param cfgPath
+//	@param name
+//	@return ReloadEvent
+func (c *sApiClient) reload(cfgPath []string, name string) ReloadEvent {
+	file, err := loadConfig(cfgPath...)
+	if err != nil {
+		return ReloadEvent{Profile: name, Err: err}
+	}
+	profile, err := file.resolveProfile(name)
+	if err != nil {
+		return ReloadEvent{Profile: name, Err: err}
+	}
+	return ReloadEvent{Profile: name, Changes: c.applyProfile(profile)}
+}
+
+// WatchConfig
+//
+//	@Description: 监听配置文件，变化时重新加载所有已创建的客户端，每个profile回调一次；
+//	配置文件读取失败时回调一次，Profile为空
+//	@receiver r
+//	@Author zzh 2026-10-19 20:15:31
+//	@param onReload 可以为nil
+//	@return *ConfigWatcher
+//	@return error
+func (r *Registry) WatchConfig(onReload func(ReloadEvent)) (*ConfigWatcher, error) {
+	r.mu.Lock()
+	err := r.load()
+	var filePath string
+	if err == nil {
+		filePath = r.file.path
+	}
+	r.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return watchConfigFile(filePath, func() {
+		for _, event := range r.reload() {
+			if onReload != nil {
+				onReload(event)
+			}
+		}
+	})
+}
+
+// reload
+//
+//	@Description: 重新读取配置并应用到已创建的客户端
+//	@receiver r
+//	@Author zzh 2026-10-19 20:17:08
+//	@return []ReloadEvent
+func (r *Registry) reload() []ReloadEvent {
+	file, err := loadConfig(r.cfgPath...)
+	if err != nil {
+		return []ReloadEvent{{Err: err}}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.file = file
+	events := make([]ReloadEvent, 0, len(r.clients))
+	for name, c := range r.clients {
+		profile, err := file.resolveProfile(name)
+		if err != nil {
+			events = append(events, ReloadEvent{Profile: name, Err: err})
+			continue
+		}
+		events = append(events, ReloadEvent{Profile: name, Changes: c.applyProfile(profile)})
+	}
+	return events
+}
@@ -0,0 +1,103 @@
+package sapiclient
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// waitReload 等待一次热加载回调
+func waitReload(t *testing.T, events <-chan ReloadEvent) ReloadEvent {
+	t.Helper()
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("config reload timed out")
+	}
+	return ReloadEvent{}
+}
+
+func TestWatchConfigReload(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":200,"msg":"success","data":"` + r.Header.Get("appkey") + `"}`))
+	}))
+	defer server.Close()
+	config := `
+[sapi]
+appSecret = "secret"
+serverUrl = "` + server.URL + `"
+`
+	path := writeConfig(t, config+`appKey = "old"`)
+	c, err := NewProfile("", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := make(chan ReloadEvent, 8)
+	watcher, err := c.WatchConfig(func(event ReloadEvent) { events <- event })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer watcher.Close()
+	if err = ioutil.WriteFile(path, []byte(config+`appKey = "new"`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	event := waitReload(t, events)
+	if event.Err != nil || len(event.Changes) != 1 || event.Changes[0] != "appKey" {
+		t.Fatalf("reload event = %+v", event)
+	}
+	if response, callErr := c.Call("demo", "echo", nil); callErr != nil || response.Data != "new" {
+		t.Fatalf("call after reload = %+v, %v", response, callErr)
+	}
+	// 校验失败时保留原配置
+	if err = ioutil.WriteFile(path, []byte(config+`appKey = "broken"
+timeout = "slow"`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if event = waitReload(t, events); event.Err == nil {
+		t.Fatalf("invalid config reload event = %+v", event)
+	}
+	if response, callErr := c.Call("demo", "echo", nil); callErr != nil || response.Data != "new" {
+		t.Fatalf("call after invalid reload = %+v, %v", response, callErr)
+	}
+}
+
+func TestRegistryWatchConfig(t *testing.T) {
+	path := writeConfig(t, `
+[sapi.profiles.billing]
+appKey = "billing"
+`)
+	registry := NewRegistry(path)
+	c, err := registry.Get("billing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := make(chan ReloadEvent, 8)
+	watcher, err := registry.WatchConfig(func(event ReloadEvent) { events <- event })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer watcher.Close()
+	if err = ioutil.WriteFile(path, []byte(`
+[sapi.profiles.billing]
+appKey = "billing-v2"
+`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	event := waitReload(t, events)
+	if event.Profile != "billing" || event.Err != nil || len(event.Changes) != 1 || event.Changes[0] != "appKey" {
+		t.Fatalf("reload event = %+v", event)
+	}
+	c.mu.RLock()
+	appKey := c.appKey
+	c.mu.RUnlock()
+	if appKey != "billing-v2" {
+		t.Fatalf("appKey = %s", appKey)
+	}
+	if err = watcher.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,498 @@
+package sapimock
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zhenhua1/go-sapiclient/sapiclient"
+)
+
+// ErrNotProgrammed 调用的服务方法未设置响应
+var ErrNotProgrammed = errors.New("sapimock: 未设置响应")
+
+// Result
+// @Description: 一次调用的结果，Err不为nil时返回错误
+type Result struct {
+	Response *sapiclient.ResponseData
+	Err      error
+}
+
+// Reply
+//
+//	@Description: 返回响应数据
+//	@Author zzh 2026-10-19 21:40:12
+//	@param response
+//	@return Result
+func Reply(response *sapiclient.ResponseData) Result {
+	return Result{Response: response}
+}
+
+// Ok
+//
+//	@Description: 返回code为200、data为指定内容的响应
+//	@Author zzh 2026-10-19 21:40:50
+//	@param data
+//	@return Result
+func Ok(data interface{}) Result {
+	return Result{Response: &sapiclient.ResponseData{Code: http.StatusOK, Msg: "success", Data: data}}
+}
+
+// Fail
+//
+//	@Description: 返回错误
+//	@Author zzh 2026-10-19 21:41:28
+//	@param err
+//	@return Result
+func Fail(err error) Result {
+	return Result{Err: err}
+}
+
+// HandlerFunc 根据调用内容生成结果
+type HandlerFunc func(call *Call) (*sapiclient.ResponseData, error)
+
+// Call
+// @Description: 记录的一次调用
+type Call struct {
+	Service  string
+	Method   string
+	Body     map[string]interface{}
+	Options  sapiclient.CallOptions
+	Files    []File //Upload上传的文件
+	CalledAt time.Time
+}
+
+// File
+// @Description: Upload上传的一个文件
+type File struct {
+	Field       string
+	FileName    string
+	ContentType string
+	Content     []byte
+}
+
+// program
+// @Description: 服务方法的结果序列，用完后重复最后一个结果
+type program struct {
+	results []Result
+	next    int
+	handler HandlerFunc
+}
+
+// Client
+// @Description: sapiclient.Client的模拟实现，记录所有调用并按服务方法返回预设结果
+type Client struct {
+	mu       sync.Mutex
+	programs map[string]*program
+	calls    []*Call
+}
+
+var _ sapiclient.Client = (*Client)(nil)
+
+// New
+//
+//	@Description: 创建模拟客户端
+//	@Author zzh 2026-10-19 21:43:05
+//	@return *Client
+func New() *Client {
+	return &Client{programs: make(map[string]*program)}
+}
+
+// On
+//
+//	@Description: 设置服务方法依次返回的结果，用完后重复最后一个结果；method为*时匹配该service的所有方法
+//	@receiver m
+//	@Author zzh 2026-10-19 21:45:30
+//	@param service
+//	@param method
+//	@param results
+//	@return *Client
+func (m *Client) On(service, method string, results ...Result) *Client {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.programs[key(service, method)] = &program{results: results}
+	return m
+}
+
+// Handle
+//
+//	@Description: 设置服务方法的处理函数，替换之前通过On设置的结果
+//	@receiver m
+//	@Author zzh 2026-10-19 21:46:48
+//	@param service
+//	@param method
+//	@param handler
+//	@return *Client
+func (m *Client) Handle(service, method string, handler HandlerFunc) *Client {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.programs[key(service, method)] = &program{handler: handler}
+	return m
+}
+
+// Calls
+//
+//	@Description: 返回所有调用记录
+//	@receiver m
+//	@Author zzh 2026-10-19 21:47:35
+//	@return []*Call
+func (m *Client) Calls() []*Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	calls := make([]*Call, len(m.calls))
+	copy(calls, m.calls)
+	return calls
+}
+
+// CallsTo
+//
+//	@Description: 返回指定服务方法的调用记录
+//	@receiver m
+//	@Author zzh 2026-10-19 21:48:16
+//	@param service
+//	@param method
+//	@return []*Call
+func (m *Client) CallsTo(service, method string) []*Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	calls := make([]*Call, 0)
+	for _, call := range m.calls {
+		if key(call.Service, call.Method) == key(service, method) {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Reset
+//
+//	@Description: 清空预设结果与调用记录
+//	@receiver m
+//	@Author zzh 2026-10-19 21:48:58
+func (m *Client) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.programs = make(map[string]*program)
+	m.calls = nil
+}
+
+// Call
+//
+//	@Description: 记录调用并返回预设结果
+//	@receiver m
+//	@Author zzh 2026-10-19 21:49:40
+//	@param service
+//	@param method
+//	@param body
+//	@return *sapiclient.ResponseData
+//	@return error
+func (m *Client) Call(service, method string, body map[string]interface{}) (*sapiclient.ResponseData, error) {
+	return m.CallContext(context.Background(), service, method, body)
+}
+
+// CallContext
+//
+//	@Description: 记录调用并返回预设结果，ctx已取消时返回ctx的错误
+//	@receiver m
+//	@Author zzh 2026-10-19 21:50:22
+//	@param ctx
+//	@param service
+//	@param method
+//	@param body
+//	@return *sapiclient.ResponseData
+//	@return error
+func (m *Client) CallContext(ctx context.Context, service, method string, body map[string]interface{}) (*sapiclient.ResponseData, error) {
+	response, err := m.CallWithOptions(ctx, service, method, body, sapiclient.CallOptions{})
+	if response == nil {
+		return nil, err
+	}
+	return response.ResponseData, err
+}
+
+// CallWithOptions
+//
+//	@Description: 记录调用并返回预设结果，响应状态码固定为200
+//	@receiver m
+//	@Author zzh 2026-10-19 21:51:15
+//	@param ctx
+//	@param service
+//	@param method
+//	@param body
+//	@param options
+//	@return *sapiclient.Response
+//	@return error
+func (m *Client) CallWithOptions(ctx context.Context, service, method string, body map[string]interface{}, options sapiclient.CallOptions) (*sapiclient.Response, error) {
+	return m.call(ctx, &Call{Service: service, Method: method, Body: body, Options: options, CalledAt: time.Now()})
+}
+
+// Upload
+//
+//	@Description: 读取上传的文件并记录调用，返回预设结果。进度在读取完所有文件后回调一次
+//	@receiver m
+//	@Author zzh 2026-10-20 02:05:30
+//	@param ctx
+//	@param service
+//	@param method
+//	@param fields
+//	@param parts
+//	@param options
+//	@return *sapiclient.Response
+//	@return error
+func (m *Client) Upload(ctx context.Context, service, method string, fields map[string]interface{}, parts []sapiclient.UploadPart, options sapiclient.UploadOptions) (*sapiclient.Response, error) {
+	call := &Call{Service: service, Method: method, Body: fields, Options: options.CallOptions, CalledAt: time.Now()}
+	sent := int64(0)
+	for _, part := range parts {
+		content, err := ioutil.ReadAll(part.Reader)
+		if closer, ok := part.Reader.(io.Closer); ok {
+			_ = closer.Close()
+		}
+		if err != nil {
+			return nil, err
+		}
+		sent += int64(len(content))
+		call.Files = append(call.Files, File{Field: part.Field, FileName: part.FileName, ContentType: part.ContentType, Content: content})
+	}
+	if options.Progress != nil {
+		options.Progress(sent, sent)
+	}
+	return m.call(ctx, call)
+}
+
+// ChunkedUpload
+//
+//	@Description: 读取整个文件并记录为一次调用，Body为options.Fields，返回预设结果。不分片也不写入状态文件
+//	@receiver m
+//	@Author zzh 2026-10-20 02:50:12
+//	@param ctx
+//	@param service
+//	@param method
+//	@param path
+//	@param options
+//	@return *sapiclient.ResponseData
+//	@return error
+func (m *Client) ChunkedUpload(ctx context.Context, service, method, path string, options sapiclient.ChunkedUploadOptions) (*sapiclient.ResponseData, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	fileName := options.FileName
+	if fileName == "" {
+		fileName = filepath.Base(path)
+	}
+	call := &Call{Service: service, Method: method, Body: options.Fields, Options: options.Call, CalledAt: time.Now()}
+	call.Files = []File{{Field: sapiclient.CHUNK_FIELD_FILE, FileName: fileName, Content: content}}
+	if options.Progress != nil {
+		options.Progress(int64(len(content)), int64(len(content)))
+	}
+	response, err := m.call(ctx, call)
+	if response == nil {
+		return nil, err
+	}
+	return response.ResponseData, err
+}
+
+// Download
+//
+//	@Description: 记录调用并将预设结果的data写入w，data需为[]byte或string；code不为200时返回与真实客户端相同的DownloadError。
+//	不分段也不续传，进度在写入后回调一次
+//	@receiver m
+//	@Author zzh 2026-10-20 03:45:10
+//	@param ctx
+//	@param service
+//	@param method
+//	@param body
+//	@param w
+//	@param options
+//	@return *sapiclient.DownloadResult
+//	@return error
+func (m *Client) Download(ctx context.Context, service, method string, body map[string]interface{}, w io.Writer, options sapiclient.DownloadOptions) (*sapiclient.DownloadResult, error) {
+	content, result, err := m.download(ctx, service, method, body, options)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = w.Write(content); err != nil {
+		return nil, &sapiclient.DownloadError{StatusCode: http.StatusOK, Err: err}
+	}
+	return result, nil
+}
+
+// DownloadFile
+//
+//	@Description: 记录调用并将预设结果的data写入文件，data需为[]byte或string。不写入状态文件
+//	@receiver m
+//	@Author zzh 2026-10-20 03:46:02
+//	@param ctx
+//	@param service
+//	@param method
+//	@param body
+//	@param path
+//	@param options
+//	@return *sapiclient.DownloadResult
+//	@return error
+func (m *Client) DownloadFile(ctx context.Context, service, method string, body map[string]interface{}, path string, options sapiclient.DownloadOptions) (*sapiclient.DownloadResult, error) {
+	content, result, err := m.download(ctx, service, method, body, options)
+	if err != nil {
+		return nil, err
+	}
+	if err = ioutil.WriteFile(path, content, 0644); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// download
+//
+//	@Description: 记录调用，取出预设结果中的下载内容并校验sha256
+//	@receiver m
+//	@Author zzh 2026-10-20 03:47:15
+//	@param ctx
+//	@param service
+//	@param method
+//	@param body
+//	@param options
+//	@return []byte
+//	@return *sapiclient.DownloadResult
+//	@return error
+func (m *Client) download(ctx context.Context, service, method string, body map[string]interface{}, options sapiclient.DownloadOptions) ([]byte, *sapiclient.DownloadResult, error) {
+	response, err := m.CallWithOptions(ctx, service, method, body, options.CallOptions)
+	if err != nil {
+		return nil, nil, err
+	}
+	data := response.ResponseData
+	if data == nil || data.Code != http.StatusOK {
+		if data == nil {
+			data = &sapiclient.ResponseData{}
+		}
+		return nil, nil, &sapiclient.DownloadError{StatusCode: http.StatusOK, Code: data.Code, Msg: data.Msg}
+	}
+	var content []byte
+	switch val := data.Data.(type) {
+	case []byte:
+		content = val
+	case string:
+		content = []byte(val)
+	default:
+		return nil, nil, errors.New("sapimock: data不是下载内容: " + service + "/" + method)
+	}
+	sum := sha256.Sum256(content)
+	checksum := hex.EncodeToString(sum[:])
+	if options.Checksum != "" && !strings.EqualFold(options.Checksum, checksum) {
+		return nil, nil, &sapiclient.DownloadError{Offset: int64(len(content)), StatusCode: http.StatusOK, Err: sapiclient.ErrChecksumMismatch}
+	}
+	if options.Progress != nil {
+		options.Progress(int64(len(content)), int64(len(content)))
+	}
+	header := http.Header{"Content-Type": []string{sapiclient.DEFAULT_UPLOAD_CONTENT_TYPE}}
+	header.Set(sapiclient.DOWNLOAD_CHECKSUM_HEADER, checksum)
+	return content, &sapiclient.DownloadResult{StatusCode: http.StatusOK, Header: header, Size: int64(len(content)), Checksum: checksum, Requests: 1}, nil
+}
+
+// call
+//
+//	@Description: 记录调用并返回预设结果，响应状态码固定为200
+//	@receiver m
+//	@Author zzh 2026-10-20 02:06:12
+//	@param ctx
+//	@param call
+//	@return *sapiclient.Response
+//	@return error
+func (m *Client) call(ctx context.Context, call *Call) (*sapiclient.Response, error) {
+	service, method := call.Service, call.Method
+	m.mu.Lock()
+	m.calls = append(m.calls, call)
+	result, handler := m.nextResult(service, method)
+	m.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if handler != nil {
+		result.Response, result.Err = handler(call)
+	}
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	content, err := json.Marshal(result.Response)
+	if err != nil {
+		return nil, err
+	}
+	return &sapiclient.Response{
+		ResponseData: result.Response,
+		StatusCode:   http.StatusOK,
+		Header:       http.Header{"Content-Type": []string{"application/json"}},
+		Body:         content,
+	}, nil
+}
+
+// CallStream
+//
+//	@Description: 记录调用并以流式响应返回预设结果，data不是数组时返回错误
+//	@receiver m
+//	@Author zzh 2026-10-20 01:33:40
+//	@param ctx
+//	@param service
+//	@param method
+//	@param body
+//	@param options
+//	@return *sapiclient.Stream
+//	@return error
+func (m *Client) CallStream(ctx context.Context, service, method string, body map[string]interface{}, options sapiclient.CallOptions) (*sapiclient.Stream, error) {
+	response, err := m.CallWithOptions(ctx, service, method, body, options)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := sapiclient.NewStream(ioutil.NopCloser(bytes.NewReader(response.Body)))
+	if err != nil {
+		return nil, err
+	}
+	stream.Header = response.Header
+	return stream, nil
+}
+
+// nextResult
+//
+//	@Description: 取出服务方法的下一个结果，需要在持有锁时调用
+//	@receiver m
+//	@Author zzh 2026-10-19 21:53:02
+//	@param service
+//	@param method
+//	@return Result
+//	@return HandlerFunc
+func (m *Client) nextResult(service, method string) (Result, HandlerFunc) {
+	p, ok := m.programs[key(service, method)]
+	if !ok {
+		p, ok = m.programs[key(service, "*")]
+	}
+	if !ok || (p.handler == nil && len(p.results) == 0) {
+		return Result{Err: fmt.Errorf("%w: %s/%s", ErrNotProgrammed, service, method)}, nil
+	}
+	if p.handler != nil {
+		return Result{}, p.handler
+	}
+	result := p.results[p.next]
+	if p.next < len(p.results)-1 {
+		p.next++
+	}
+	return result, nil
+}
+
+// key
+//
+//	@Description: 服务方法的key，不区分大小写
+//	@Author zzh 2026-10-19 21:53:48
+//	@param service
+//	@param method
+//	@return string
+func key(service, method string) string {
+	return strings.ToLower(service + "/" + method)
+}
@@ -0,0 +1,65 @@
+package sapimock
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/zhenhua1/go-sapiclient/sapiclient"
+)
+
+func TestClientResultSequence(t *testing.T) {
+	timeout := errors.New("timeout")
+	m := New().On("demo", "echo", Fail(timeout), Ok("first"), Ok("last"))
+	if _, err := m.Call("demo", "echo", nil); err != timeout {
+		t.Fatalf("call 1 err = %v, want %v", err, timeout)
+	}
+	// 结果用完后重复最后一个结果
+	for i, want := range []string{"first", "last", "last"} {
+		data, err := m.Call("Demo", "Echo", map[string]interface{}{"n": i})
+		if err != nil || data.Data != want {
+			t.Fatalf("call %d = %+v, %v, want %s", i+2, data, err, want)
+		}
+	}
+	calls := m.CallsTo("demo", "echo")
+	if len(calls) != 4 || calls[3].Body["n"] != 2 {
+		t.Fatalf("calls = %+v", calls)
+	}
+	// 重新设置结果时从头开始
+	m.On("demo", "echo", Reply(&sapiclient.ResponseData{Code: http.StatusBadRequest, Msg: "bad"}))
+	if data, err := m.Call("demo", "echo", nil); err != nil || data.Code != http.StatusBadRequest {
+		t.Fatalf("reprogrammed call = %+v, %v", data, err)
+	}
+}
+
+func TestClientWildcardAndHandler(t *testing.T) {
+	m := New().On("demo", "*", Ok("any")).On("demo", "echo", Ok("echo"))
+	if data, err := m.Call("demo", "other", nil); err != nil || data.Data != "any" {
+		t.Fatalf("wildcard call = %+v, %v", data, err)
+	}
+	if data, err := m.Call("demo", "echo", nil); err != nil || data.Data != "echo" {
+		t.Fatalf("exact call = %+v, %v", data, err)
+	}
+	m.Handle("demo", "echo", func(call *Call) (*sapiclient.ResponseData, error) {
+		return &sapiclient.ResponseData{Code: http.StatusOK, Data: call.Body["name"]}, nil
+	})
+	if data, err := m.Call("demo", "echo", map[string]interface{}{"name": "sapi"}); err != nil || data.Data != "sapi" {
+		t.Fatalf("handler call = %+v, %v", data, err)
+	}
+	if _, err := m.Call("other", "echo", nil); !errors.Is(err, ErrNotProgrammed) {
+		t.Fatalf("unprogrammed err = %v, want ErrNotProgrammed", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := m.CallContext(ctx, "demo", "other", nil); err != context.Canceled {
+		t.Fatalf("canceled call err = %v", err)
+	}
+	if len(m.Calls()) != 5 {
+		t.Fatalf("calls = %d, want 5", len(m.Calls()))
+	}
+	m.Reset()
+	if _, err := m.Call("demo", "echo", nil); !errors.Is(err, ErrNotProgrammed) || len(m.Calls()) != 1 {
+		t.Fatalf("call after reset err = %v", err)
+	}
+}
@@ -0,0 +1,293 @@
+package sapitest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/zhenhua1/go-sapiclient/sapiclient"
+)
+
+// ChunkedUploads
+// @Description: 分片上传协议（见sapiclient.CHUNK_ACTION_*）的参考服务端实现，分片与合并后的文件保存在内存中。
+// 用法：server.Handle("doc", "upload", uploads.Handler())
+type ChunkedUploads struct {
+	mu      sync.Mutex
+	uploads map[string]*chunkedUpload //uploadId -> 进行中的上传
+	files   map[string]*UploadedFile  //uploadId -> 已合并的文件
+	seq     int
+}
+
+// chunkedUpload
+// @Description: 进行中的上传
+type chunkedUpload struct {
+	fileName  string
+	size      int64
+	chunkSize int64
+	checksum  string
+	params    map[string]interface{} //创建上传时的参数
+	parts     [][]byte               //已上传的分片，未上传时为nil
+}
+
+// UploadedFile
+// @Description: 已合并的文件
+type UploadedFile struct {
+	FileName string
+	Content  []byte
+	Params   map[string]interface{} //创建上传时的参数
+}
+
+// NewChunkedUploads
+//
+//	@Description: 创建分片上传的参考服务端
+//	@Author zzh 2026-10-20 02:55:10
+//	@return *ChunkedUploads
+func NewChunkedUploads() *ChunkedUploads {
+	return &ChunkedUploads{uploads: make(map[string]*chunkedUpload), files: make(map[string]*UploadedFile)}
+}
+
+// Handler
+//
+//	@Description: 按action处理分片上传的调用
+//	@receiver u
+//	@Author zzh 2026-10-20 02:56:02
+//	@return HandlerFunc
+func (u *ChunkedUploads) Handler() HandlerFunc {
+	return func(call *Call) (*sapiclient.ResponseData, error) {
+		u.mu.Lock()
+		defer u.mu.Unlock()
+		switch paramString(call, sapiclient.CHUNK_FIELD_ACTION) {
+		case sapiclient.CHUNK_ACTION_INIT:
+			return u.init(call), nil
+		case sapiclient.CHUNK_ACTION_STATUS:
+			return u.status(call), nil
+		case sapiclient.CHUNK_ACTION_CHUNK:
+			return u.chunk(call), nil
+		case sapiclient.CHUNK_ACTION_COMPLETE:
+			return u.complete(call), nil
+		}
+		return fail(http.StatusBadRequest, "未知的action"), nil
+	}
+}
+
+// init
+//
+//	@Description: 创建上传
+//	@receiver u
+//	@Author zzh 2026-10-20 02:57:30
+//	@param call
+//	@return *sapiclient.ResponseData
+func (u *ChunkedUploads) init(call *Call) *sapiclient.ResponseData {
+	size, sizeOk := paramInt(call, "size")
+	chunkSize, chunkSizeOk := paramInt(call, "chunkSize")
+	chunks, chunksOk := paramInt(call, "chunks")
+	checksum := paramString(call, "checksum")
+	if !sizeOk || !chunkSizeOk || !chunksOk || size < 0 || chunkSize <= 0 || checksum == "" {
+		return fail(http.StatusBadRequest, "参数不正确")
+	}
+	if chunks != (size+chunkSize-1)/chunkSize {
+		return fail(http.StatusBadRequest, "分片数量与文件大小不一致")
+	}
+	u.seq++
+	uploadId := "upload-" + strconv.Itoa(u.seq)
+	u.uploads[uploadId] = &chunkedUpload{
+		fileName:  paramString(call, "fileName"),
+		size:      size,
+		chunkSize: chunkSize,
+		checksum:  checksum,
+		params:    call.Params,
+		parts:     make([][]byte, chunks),
+	}
+	return ok(map[string]interface{}{"uploadId": uploadId})
+}
+
+// status
+//
+//	@Description: 返回已上传的分片
+//	@receiver u
+//	@Author zzh 2026-10-20 02:58:48
+//	@param call
+//	@return *sapiclient.ResponseData
+func (u *ChunkedUploads) status(call *Call) *sapiclient.ResponseData {
+	upload, exists := u.uploads[paramString(call, "uploadId")]
+	if !exists {
+		return fail(sapiclient.CHUNK_CODE_NOT_FOUND, "上传不存在")
+	}
+	uploaded := make([]int, 0, len(upload.parts))
+	for index, part := range upload.parts {
+		if part != nil {
+			uploaded = append(uploaded, index)
+		}
+	}
+	return ok(map[string]interface{}{"uploaded": uploaded})
+}
+
+// chunk
+//
+//	@Description: 校验分片的大小与sha256后保存，重复上传时覆盖
+//	@receiver u
+//	@Author zzh 2026-10-20 03:00:15
+//	@param call
+//	@return *sapiclient.ResponseData
+func (u *ChunkedUploads) chunk(call *Call) *sapiclient.ResponseData {
+	upload, exists := u.uploads[paramString(call, "uploadId")]
+	if !exists {
+		return fail(sapiclient.CHUNK_CODE_NOT_FOUND, "上传不存在")
+	}
+	index, indexOk := paramInt(call, "index")
+	if !indexOk || index < 0 || index >= int64(len(upload.parts)) {
+		return fail(http.StatusBadRequest, "分片序号不正确")
+	}
+	var content []byte
+	for _, file := range call.Files {
+		if file.Field == sapiclient.CHUNK_FIELD_FILE {
+			content = file.Content
+		}
+	}
+	expected := upload.chunkSize
+	if offset := index * upload.chunkSize; offset+expected > upload.size {
+		expected = upload.size - offset
+	}
+	if int64(len(content)) != expected {
+		return fail(http.StatusBadRequest, "分片大小不正确")
+	}
+	if sum := sha256.Sum256(content); hex.EncodeToString(sum[:]) != paramString(call, "checksum") {
+		return fail(http.StatusBadRequest, "分片校验失败")
+	}
+	upload.parts[index] = content
+	return ok(nil)
+}
+
+// complete
+//
+//	@Description: 合并分片并校验整个文件的sha256
+//	@receiver u
+//	@Author zzh 2026-10-20 03:01:40
+//	@param call
+//	@return *sapiclient.ResponseData
+func (u *ChunkedUploads) complete(call *Call) *sapiclient.ResponseData {
+	uploadId := paramString(call, "uploadId")
+	upload, exists := u.uploads[uploadId]
+	if !exists {
+		return fail(sapiclient.CHUNK_CODE_NOT_FOUND, "上传不存在")
+	}
+	var buffer bytes.Buffer
+	for index, part := range upload.parts {
+		if part == nil {
+			return fail(http.StatusBadRequest, "第"+strconv.Itoa(index)+"片未上传")
+		}
+		buffer.Write(part)
+	}
+	sum := sha256.Sum256(buffer.Bytes())
+	if checksum := hex.EncodeToString(sum[:]); checksum != upload.checksum || checksum != paramString(call, "checksum") {
+		return fail(http.StatusBadRequest, "文件校验失败")
+	}
+	delete(u.uploads, uploadId)
+	u.files[uploadId] = &UploadedFile{FileName: upload.fileName, Content: buffer.Bytes(), Params: upload.params}
+	return ok(map[string]interface{}{"uploadId": uploadId, "fileName": upload.fileName, "size": buffer.Len()})
+}
+
+// File
+//
+//	@Description: 返回已合并的文件
+//	@receiver u
+//	@Author zzh 2026-10-20 03:02:55
+//	@param uploadId
+//	@return *UploadedFile
+//	@return bool
+func (u *ChunkedUploads) File(uploadId string) (*UploadedFile, bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	file, exists := u.files[uploadId]
+	return file, exists
+}
+
+// Uploaded
+//
+//	@Description: 返回进行中的上传已上传的分片序号
+//	@receiver u
+//	@Author zzh 2026-10-20 03:03:40
+//	@param uploadId
+//	@return []int
+func (u *ChunkedUploads) Uploaded(uploadId string) []int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	uploaded := make([]int, 0)
+	if upload, exists := u.uploads[uploadId]; exists {
+		for index, part := range upload.parts {
+			if part != nil {
+				uploaded = append(uploaded, index)
+			}
+		}
+	}
+	sort.Ints(uploaded)
+	return uploaded
+}
+
+// Drop
+//
+//	@Description: 丢弃进行中的上传，模拟服务端上传过期，之后客户端续传时会重新创建上传
+//	@receiver u
+//	@Author zzh 2026-10-20 03:04:25
+//	@param uploadId
+func (u *ChunkedUploads) Drop(uploadId string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	delete(u.uploads, uploadId)
+}
+
+// paramString
+//
+//	@Description: 读取字符串参数
+//	@Author zzh 2026-10-20 03:05:10
+//	@param call
+//	@param key
+//	@return string
+func paramString(call *Call, key string) string {
+	val, _ := call.Params[key].(string)
+	return val
+}
+
+// paramInt
+//
+//	@Description: 读取整数参数，json请求中为数字，multipart请求中为字符串
+//	@Author zzh 2026-10-20 03:05:48
+//	@param call
+//	@param key
+//	@return int64
+//	@return bool
+func paramInt(call *Call, key string) (int64, bool) {
+	switch val := call.Params[key].(type) {
+	case float64:
+		return int64(val), val == float64(int64(val))
+	case string:
+		n, err := strconv.ParseInt(val, 10, 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// ok
+//
+//	@Description: 成功的响应
+//	@Author zzh 2026-10-20 03:06:20
+//	@param data
+//	@return *sapiclient.ResponseData
+func ok(data interface{}) *sapiclient.ResponseData {
+	return &sapiclient.ResponseData{Code: sapiclient.CHUNK_CODE_SUCCESS, Msg: "success", Data: data}
+}
+
+// fail
+//
+//	@Description: 失败的响应
+//	@Author zzh 2026-10-20 03:06:52
+//	@param code
+//	@param msg
+//	@return *sapiclient.ResponseData
+func fail(code int, msg string) *sapiclient.ResponseData {
+	return &sapiclient.ResponseData{Code: code, Msg: msg}
+}
@@ -0,0 +1,154 @@
+package sapitest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/zhenhua1/go-sapiclient/sapiclient"
+)
+
+// newChunkedUploadTest 启动注册了分片上传参考实现的模拟服务，并写入4500字节的待上传文件
+func newChunkedUploadTest(t *testing.T) (*Server, *ChunkedUploads, string, []byte) {
+	s := NewServer("key", "secret")
+	t.Cleanup(s.Close)
+	uploads := NewChunkedUploads()
+	s.Handle("doc", "upload", uploads.Handler())
+	content := make([]byte, 4500)
+	rand.Read(content)
+	path := filepath.Join(t.TempDir(), "backup.bin")
+	if err := ioutil.WriteFile(path, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return s, uploads, path, content
+}
+
+// interruptAfter 在上传n个分片后注入一次故障，使上传中断
+func interruptAfter(s *Server, n int64, statusCode int) sapiclient.ChunkedUploadOptions {
+	injected := false
+	return sapiclient.ChunkedUploadOptions{
+		ChunkSize:   1000,
+		Concurrency: 1,
+		Retries:     -1,
+		Progress: func(uploaded, total int64) {
+			if !injected && uploaded >= n*1000 {
+				injected = true
+				s.InjectFault("doc", "upload", Fault{StatusCode: statusCode, Times: 1})
+			}
+		},
+	}
+}
+
+// actions 返回分片上传收到的操作序列
+func actions(s *Server) []string {
+	var result []string
+	for _, call := range s.CallsTo("doc", "upload") {
+		action, _ := call.Params[sapiclient.CHUNK_FIELD_ACTION].(string)
+		if index, ok := call.Params["index"].(string); ok {
+			action += ":" + index
+		}
+		result = append(result, action)
+	}
+	return result
+}
+
+func assertActions(t *testing.T, s *Server, want ...string) {
+	t.Helper()
+	got := actions(s)
+	if len(got) != len(want) {
+		t.Fatalf("actions = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("actions = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestChunkedUploadResume(t *testing.T) {
+	s, uploads, path, content := newChunkedUploadTest(t)
+	c, err := s.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.ChunkedUpload(context.Background(), "doc", "upload", path, interruptAfter(s, 3, http.StatusBadGateway))
+	var uploadErr *sapiclient.ChunkedUploadError
+	if !errors.As(err, &uploadErr) || uploadErr.Index != 3 {
+		t.Fatalf("interrupted err = %v", err)
+	}
+	if got := uploads.Uploaded("upload-1"); len(got) != 3 {
+		t.Fatalf("uploaded = %v, want 3 chunks", got)
+	}
+
+	// 查询状态时服务端暂时不可用，重试后续传，不重新创建上传
+	s.Reset()
+	s.InjectFault("doc", "upload", Fault{StatusCode: http.StatusServiceUnavailable, Times: 1})
+	response, err := c.ChunkedUpload(context.Background(), "doc", "upload", path, sapiclient.ChunkedUploadOptions{ChunkSize: 1000, Concurrency: 1, RetryWait: time.Millisecond})
+	if err != nil || response.Code != sapiclient.CHUNK_CODE_SUCCESS {
+		t.Fatalf("resume = %+v, %v", response, err)
+	}
+	assertActions(t, s, sapiclient.CHUNK_ACTION_STATUS, sapiclient.CHUNK_ACTION_STATUS, "chunk:3", "chunk:4", sapiclient.CHUNK_ACTION_COMPLETE)
+	file, ok := uploads.File("upload-1")
+	if !ok || !bytes.Equal(file.Content, content) {
+		t.Fatal("merged file differs from source")
+	}
+	for _, call := range s.CallsTo("doc", "upload") {
+		if !call.SignValid {
+			t.Fatalf("call %v not signed", call.Params)
+		}
+	}
+	if _, err = os.Stat(path + sapiclient.CHUNK_STATE_SUFFIX); !os.IsNotExist(err) {
+		t.Fatal("state file left after complete")
+	}
+}
+
+func TestChunkedUploadKeepsUploadOnRejectedStatus(t *testing.T) {
+	s, uploads, path, _ := newChunkedUploadTest(t)
+	c, err := s.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.ChunkedUpload(context.Background(), "doc", "upload", path, interruptAfter(s, 2, http.StatusBadGateway)); err == nil {
+		t.Fatal("interrupted upload succeeded")
+	}
+	s.Reset()
+	s.InjectFault("doc", "upload", Fault{StatusCode: http.StatusUnauthorized, Times: 1})
+	if _, err = c.ChunkedUpload(context.Background(), "doc", "upload", path, sapiclient.ChunkedUploadOptions{ChunkSize: 1000}); err == nil {
+		t.Fatal("rejected status query succeeded")
+	}
+	assertActions(t, s, sapiclient.CHUNK_ACTION_STATUS)
+	if got := uploads.Uploaded("upload-1"); len(got) != 2 {
+		t.Fatalf("uploaded = %v, want 2 chunks kept", got)
+	}
+	if _, err = os.Stat(path + sapiclient.CHUNK_STATE_SUFFIX); err != nil {
+		t.Fatal("state file removed after rejected status query")
+	}
+}
+
+func TestChunkedUploadRestartsDroppedUpload(t *testing.T) {
+	s, uploads, path, content := newChunkedUploadTest(t)
+	c, err := s.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.ChunkedUpload(context.Background(), "doc", "upload", path, interruptAfter(s, 2, http.StatusBadGateway)); err == nil {
+		t.Fatal("interrupted upload succeeded")
+	}
+	uploads.Drop("upload-1")
+	s.Reset()
+	if _, err = c.ChunkedUpload(context.Background(), "doc", "upload", path, sapiclient.ChunkedUploadOptions{ChunkSize: 1000, Concurrency: 1}); err != nil {
+		t.Fatal(err)
+	}
+	assertActions(t, s, sapiclient.CHUNK_ACTION_STATUS, sapiclient.CHUNK_ACTION_INIT,
+		"chunk:0", "chunk:1", "chunk:2", "chunk:3", "chunk:4", sapiclient.CHUNK_ACTION_COMPLETE)
+	if file, ok := uploads.File("upload-2"); !ok || !bytes.Equal(file.Content, content) {
+		t.Fatal("merged file differs from source")
+	}
+}
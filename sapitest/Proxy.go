@@ -0,0 +1,359 @@
+package sapitest
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// Proxy
+// @Description: 本地代理，用于测试客户端的代理配置，记录经过代理的目标地址
+type Proxy struct {
+	URL      string //代理地址，不包含认证信息
+	Username string //不为空时要求代理认证
+	Password string
+	listener net.Listener
+	server   *http.Server
+	mu       sync.Mutex
+	targets  []string
+	conns    map[net.Conn]struct{} //正在转发的连接，Close时关闭
+	wg       sync.WaitGroup
+}
+
+// NewHTTPProxy
+//
+//	@Description: 启动http代理，支持普通转发与CONNECT隧道
+//	@Author zzh 2026-10-19 22:40:05
+//	@param username 为空时不要求认证
+//	@param password
+//	@return *Proxy
+func NewHTTPProxy(username, password string) *Proxy {
+	p := &Proxy{Username: username, Password: password, listener: listen(), conns: make(map[net.Conn]struct{})}
+	p.URL = "http://" + p.listener.Addr().String()
+	p.server = &http.Server{Handler: http.HandlerFunc(p.serveHTTP)}
+	go func() {
+		_ = p.server.Serve(p.listener)
+	}()
+	return p
+}
+
+// NewSocks5Proxy
+//
+//	@Description: 启动socks5代理，支持CONNECT命令与用户名密码认证
+//	@Author zzh 2026-10-19 22:41:30
+//	@param username 为空时不要求认证
+//	@param password
+//	@return *Proxy
+func NewSocks5Proxy(username, password string) *Proxy {
+	p := &Proxy{Username: username, Password: password, listener: listen(), conns: make(map[net.Conn]struct{})}
+	p.URL = "socks5://" + p.listener.Addr().String()
+	go func() {
+		for {
+			conn, err := p.listener.Accept()
+			if err != nil {
+				return
+			}
+			p.track(conn, true)
+			p.wg.Add(1)
+			go func() {
+				defer p.wg.Done()
+				defer p.track(conn, false)
+				p.serveSocks5(conn)
+			}()
+		}
+	}()
+	return p
+}
+
+// listen
+//
+//	@Description: 监听本地随机端口
+//	@Author zzh 2026-10-19 22:42:15
+//	@return net.Listener
+func listen() net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic("sapitest: 代理监听失败: " + err.Error())
+	}
+	return listener
+}
+
+// Targets
+//
+//	@Description: 返回经过代理的目标地址host:port
+//	@receiver p
+//	@Author zzh 2026-10-19 22:43:02
+//	@return []string
+func (p *Proxy) Targets() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	targets := make([]string, len(p.targets))
+	copy(targets, p.targets)
+	return targets
+}
+
+// Close
+//
+//	@Description: 关闭代理
+//	@receiver p
+//	@Author zzh 2026-10-19 22:43:40
+func (p *Proxy) Close() {
+	if p.server != nil {
+		_ = p.server.Close()
+	} else {
+		_ = p.listener.Close()
+	}
+	p.mu.Lock()
+	for conn := range p.conns {
+		_ = conn.Close()
+	}
+	p.mu.Unlock()
+	p.wg.Wait()
+}
+
+// track
+//
+//	@Description: 记录或移除正在转发的连接
+//	@receiver p
+//	@Author zzh 2026-10-19 22:45:02
+//	@param conn
+//	@param active
+func (p *Proxy) track(conn net.Conn, active bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if active {
+		p.conns[conn] = struct{}{}
+	} else {
+		delete(p.conns, conn)
+	}
+}
+
+// record
+//
+//	@Description: 记录目标地址
+//	@receiver p
+//	@Author zzh 2026-10-19 22:44:18
+//	@param target
+func (p *Proxy) record(target string) {
+	p.mu.Lock()
+	p.targets = append(p.targets, target)
+	p.mu.Unlock()
+}
+
+// serveHTTP
+//
+//	@Description: 处理http代理请求
+//	@receiver p
+//	@Author zzh 2026-10-19 22:46:05
+//	@param w
+//	@param r
+func (p *Proxy) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if p.Username != "" {
+		expected := "Basic " + base64.StdEncoding.EncodeToString([]byte(p.Username+":"+p.Password))
+		if r.Header.Get("Proxy-Authorization") != expected {
+			w.Header().Set("Proxy-Authenticate", `Basic realm="sapitest"`)
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+	}
+	if r.Method == http.MethodConnect {
+		p.record(r.Host)
+		p.tunnel(w, r)
+		return
+	}
+	p.record(r.URL.Host)
+	r.RequestURI = ""
+	r.Header.Del("Proxy-Authorization")
+	r.Header.Del("Proxy-Connection")
+	res, err := http.DefaultTransport.RoundTrip(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+	defer res.Body.Close()
+	for name, values := range res.Header {
+		w.Header()[name] = values
+	}
+	w.WriteHeader(res.StatusCode)
+	_, _ = io.Copy(w, res.Body)
+}
+
+// tunnel
+//
+//	@Description: 建立CONNECT隧道
+//	@receiver p
+//	@Author zzh 2026-10-19 22:48:30
+//	@param w
+//	@param r
+func (p *Proxy) tunnel(w http.ResponseWriter, r *http.Request) {
+	target, err := net.Dial("tcp", r.Host)
+	if err != nil {
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		_ = target.Close()
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	conn, buffered, err := hijacker.Hijack()
+	if err != nil {
+		_ = target.Close()
+		return
+	}
+	p.track(conn, true)
+	defer p.track(conn, false)
+	_, _ = conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+	pipe(conn, buffered.Reader, target)
+}
+
+// serveSocks5
+//
+//	@Description: 处理socks5连接，只支持CONNECT命令
+//	@receiver p
+//	@Author zzh 2026-10-19 22:52:12
+//	@param conn
+func (p *Proxy) serveSocks5(conn net.Conn) {
+	reader := bufio.NewReader(conn)
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(reader, header); err != nil || header[0] != 5 {
+		_ = conn.Close()
+		return
+	}
+	methods := make([]byte, header[1])
+	if _, err := io.ReadFull(reader, methods); err != nil {
+		_ = conn.Close()
+		return
+	}
+	method := byte(0)
+	if p.Username != "" {
+		method = 2
+	}
+	supported := false
+	for _, m := range methods {
+		supported = supported || m == method
+	}
+	if !supported {
+		_, _ = conn.Write([]byte{5, 0xff})
+		_ = conn.Close()
+		return
+	}
+	_, _ = conn.Write([]byte{5, method})
+	if method == 2 && !p.socks5Auth(reader, conn) {
+		_ = conn.Close()
+		return
+	}
+	request := make([]byte, 4)
+	if _, err := io.ReadFull(reader, request); err != nil || request[1] != 1 {
+		_, _ = conn.Write([]byte{5, 7, 0, 1, 0, 0, 0, 0, 0, 0})
+		_ = conn.Close()
+		return
+	}
+	var host string
+	switch request[3] {
+	case 1, 4:
+		size := net.IPv4len
+		if request[3] == 4 {
+			size = net.IPv6len
+		}
+		ip := make([]byte, size)
+		if _, err := io.ReadFull(reader, ip); err != nil {
+			_ = conn.Close()
+			return
+		}
+		host = net.IP(ip).String()
+	case 3:
+		length, err := reader.ReadByte()
+		domain := make([]byte, length)
+		if err != nil {
+			_ = conn.Close()
+			return
+		}
+		if _, err = io.ReadFull(reader, domain); err != nil {
+			_ = conn.Close()
+			return
+		}
+		host = string(domain)
+	default:
+		_, _ = conn.Write([]byte{5, 8, 0, 1, 0, 0, 0, 0, 0, 0})
+		_ = conn.Close()
+		return
+	}
+	port := make([]byte, 2)
+	if _, err := io.ReadFull(reader, port); err != nil {
+		_ = conn.Close()
+		return
+	}
+	address := net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port))))
+	p.record(address)
+	target, err := net.Dial("tcp", address)
+	if err != nil {
+		_, _ = conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+		_ = conn.Close()
+		return
+	}
+	_, _ = conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+	pipe(conn, reader, target)
+}
+
+// socks5Auth
+//
+//	@Description: socks5用户名密码认证
+//	@receiver p
+//	@Author zzh 2026-10-19 22:55:40
+//	@param reader
+//	@param conn
+//	@return bool
+func (p *Proxy) socks5Auth(reader *bufio.Reader, conn net.Conn) bool {
+	version, err := reader.ReadByte()
+	if err != nil || version != 1 {
+		return false
+	}
+	readField := func() string {
+		length, err := reader.ReadByte()
+		if err != nil {
+			return ""
+		}
+		field := make([]byte, length)
+		if _, err = io.ReadFull(reader, field); err != nil {
+			return ""
+		}
+		return string(field)
+	}
+	username, password := readField(), readField()
+	if username != p.Username || password != p.Password {
+		_, _ = conn.Write([]byte{1, 1})
+		return false
+	}
+	_, _ = conn.Write([]byte{1, 0})
+	return true
+}
+
+// pipe
+//
+//	@Description: 在客户端连接与目标连接之间双向转发，任一方向结束后关闭两端
+//	@Author zzh 2026-10-19 22:57:12
+//	@param conn
+//	@param reader conn上已缓冲的数据
+//	@param target
+func pipe(conn net.Conn, reader io.Reader, target net.Conn) {
+	done := make(chan struct{}, 2)
+	go func() {
+		_, _ = io.Copy(target, reader)
+		done <- struct{}{}
+	}()
+	go func() {
+		_, _ = io.Copy(conn, target)
+		done <- struct{}{}
+	}()
+	<-done
+	_ = conn.Close()
+	_ = target.Close()
+	<-done
+}
@@ -0,0 +1,114 @@
+package sapitest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/zhenhua1/go-sapiclient/sapiclient"
+)
+
+// proxyClient 创建通过代理访问baseUrl的客户端
+func proxyClient(t *testing.T, s *Server, baseUrl string, config sapiclient.ProxyConfig, options ...sapiclient.Option) sapiclient.Client {
+	t.Helper()
+	options = append([]sapiclient.Option{sapiclient.WithCredentials(s.AppKey, s.AppSecret), sapiclient.WithBaseURL(baseUrl)}, options...)
+	c, err := sapiclient.NewClient(append(options, sapiclient.WithProxy(config))...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// host 返回地址中的host:port
+func host(t *testing.T, rawUrl string) string {
+	t.Helper()
+	parsed, err := url.Parse(rawUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed.Host
+}
+
+func TestHTTPProxy(t *testing.T) {
+	s := NewServer("key", "secret")
+	defer s.Close()
+	s.HandleResponse("demo", "echo", &sapiclient.ResponseData{Code: http.StatusOK, Msg: "success"})
+	proxy := NewHTTPProxy("user", "pass")
+	defer proxy.Close()
+	c := proxyClient(t, s, s.URL, sapiclient.ProxyConfig{URL: proxy.URL, Username: "user", Password: "pass"})
+	if _, err := c.Call("demo", "echo", nil); err != nil {
+		t.Fatal(err)
+	}
+	// https请求通过CONNECT隧道转发
+	tlsServer := httptest.NewTLSServer(s.server.Config.Handler)
+	defer tlsServer.Close()
+	transport := tlsServer.Client().Transport.(*http.Transport).Clone()
+	c = proxyClient(t, s, tlsServer.URL, sapiclient.ProxyConfig{URL: proxy.URL, Username: "user", Password: "pass"}, sapiclient.WithTransport(transport))
+	if _, err := c.Call("demo", "echo", nil); err != nil {
+		t.Fatal(err)
+	}
+	targets := proxy.Targets()
+	if len(targets) != 2 || targets[0] != host(t, s.URL) || targets[1] != host(t, tlsServer.URL) {
+		t.Fatalf("targets = %v", targets)
+	}
+	if len(s.Calls()) != 2 {
+		t.Fatalf("calls = %d, want 2", len(s.Calls()))
+	}
+	c = proxyClient(t, s, s.URL, sapiclient.ProxyConfig{URL: proxy.URL, Username: "user", Password: "wrong"})
+	if response, err := c.CallWithOptions(context.Background(), "demo", "echo", nil, sapiclient.CallOptions{}); err == nil || response == nil || response.StatusCode != http.StatusProxyAuthRequired {
+		t.Fatalf("wrong password = %+v, %v", response, err)
+	}
+}
+
+func TestSocks5Proxy(t *testing.T) {
+	s := NewServer("key", "secret")
+	defer s.Close()
+	s.HandleResponse("demo", "echo", &sapiclient.ResponseData{Code: http.StatusOK, Msg: "success"})
+	proxy := NewSocks5Proxy("user", "pass")
+	defer proxy.Close()
+	c := proxyClient(t, s, s.URL, sapiclient.ProxyConfig{URL: "socks5h://user:pass@" + host(t, proxy.URL)})
+	if _, err := c.Call("demo", "echo", nil); err != nil {
+		t.Fatal(err)
+	}
+	if targets := proxy.Targets(); len(targets) != 1 || targets[0] != host(t, s.URL) {
+		t.Fatalf("targets = %v", targets)
+	}
+	c = proxyClient(t, s, s.URL, sapiclient.ProxyConfig{URL: proxy.URL, Username: "user", Password: "wrong"})
+	if _, err := c.Call("demo", "echo", nil); err == nil {
+		t.Fatal("socks5 call with wrong password succeeded")
+	}
+}
+
+func TestProxyNoProxyAndEndpoints(t *testing.T) {
+	s := NewServer("key", "secret")
+	defer s.Close()
+	s.HandleResponse("demo", "echo", &sapiclient.ResponseData{Code: http.StatusOK, Msg: "success"})
+	s.HandleResponse("billing", "echo", &sapiclient.ResponseData{Code: http.StatusOK, Msg: "success"})
+	httpProxy, socksProxy := NewHTTPProxy("", ""), NewSocks5Proxy("", "")
+	defer httpProxy.Close()
+	defer socksProxy.Close()
+	// 命中NoProxy时直连
+	c := proxyClient(t, s, s.URL, sapiclient.ProxyConfig{URL: httpProxy.URL, NoProxy: []string{"127.0.0.0/8"}})
+	if _, err := c.Call("demo", "echo", nil); err != nil {
+		t.Fatal(err)
+	}
+	if targets := httpProxy.Targets(); len(targets) != 0 {
+		t.Fatalf("NoProxy targets = %v", targets)
+	}
+	// service单独配置的代理优先于NoProxy与默认代理
+	c = proxyClient(t, s, s.URL, sapiclient.ProxyConfig{
+		URL:       httpProxy.URL,
+		NoProxy:   []string{"127.0.0.1"},
+		Endpoints: map[string]string{"billing": socksProxy.URL, "demo": sapiclient.PROXY_DIRECT},
+	})
+	for _, service := range []string{"demo", "billing"} {
+		if _, err := c.Call(service, "echo", nil); err != nil {
+			t.Fatalf("%s call = %v", service, err)
+		}
+	}
+	if len(httpProxy.Targets()) != 0 || len(socksProxy.Targets()) != 1 {
+		t.Fatalf("http targets = %v, socks5 targets = %v", httpProxy.Targets(), socksProxy.Targets())
+	}
+}
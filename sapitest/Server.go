@@ -0,0 +1,635 @@
+// Package sapitest 提供进程内的sapi模拟服务，便于对sapiclient发起的请求进行测试
+package sapitest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zhenhua1/go-sapiclient/sapiclient"
+)
+
+// HandlerFunc 处理一次模拟调用并返回响应数据
+type HandlerFunc func(call *Call) (*sapiclient.ResponseData, error)
+
+// DownloadFunc 处理一次模拟下载并返回下载内容
+type DownloadFunc func(call *Call) ([]byte, error)
+
+// Call
+// @Description: 模拟服务收到的一次调用
+type Call struct {
+	Service    string                 //服务
+	Method     string                 //服务方法
+	HttpMethod string                 //HTTP请求方法
+	Header     http.Header            //请求头
+	Params     map[string]interface{} //合并后的query与body参数
+	Body       []byte                 //请求体，压缩的请求体为解压后的内容
+	Files      []*File                //multipart请求中的文件
+	SignValid  bool                   //签名是否校验通过
+	TimeValid  bool                   //请求时间是否在允许的时间窗口内
+	Replayed   bool                   //nonce是否重复使用
+	ReceivedAt time.Time              //收到请求的时间
+}
+
+// File
+// @Description: multipart请求中的一个文件
+type File struct {
+	Field       string //表单字段名
+	FileName    string //文件名
+	ContentType string //内容类型
+	Content     []byte //文件内容
+}
+
+// Fault
+// @Description: 注入的故障
+type Fault struct {
+	Latency    time.Duration //响应前等待时间
+	StatusCode int           //返回的HTTP错误状态码
+	Malformed  bool          //返回无法解析的响应体
+	Times      int           //生效次数，0表示一直生效
+	Truncate   int64         //下载响应只发送前Truncate字节的内容后断开连接，0表示不截断
+}
+
+// Server
+// @Description: 进程内sapi模拟服务
+type Server struct {
+	URL       string
+	AppKey    string
+	AppSecret string
+	server    *httptest.Server
+	mu        sync.Mutex
+	handlers  map[string]HandlerFunc
+	downloads map[string]DownloadFunc
+	faults    map[string]*Fault
+	calls     []*Call
+	latency   time.Duration
+	nonces    sapiclient.NonceStore
+	window    time.Duration //请求时间允许的偏差
+	signKey   string
+	encoding  string
+}
+
+// NewServer
+//
+//	@Description: 创建并启动模拟服务，请求签名使用appKey与appSecret校验
+//	@Author zzh 2026-10-19 11:05:12
+//	@param appKey
+//	@param appSecret
+//	@return *Server
+func NewServer(appKey, appSecret string) *Server {
+	s := &Server{
+		AppKey:    appKey,
+		AppSecret: appSecret,
+		handlers:  make(map[string]HandlerFunc),
+		downloads: make(map[string]DownloadFunc),
+		faults:    make(map[string]*Fault),
+		window:    sapiclient.DEFAULT_TIME_WINDOW,
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		signKey, encoding := s.signKey, s.encoding
+		s.mu.Unlock()
+		handler := http.Handler(http.HandlerFunc(s.serveHTTP))
+		if signKey != "" {
+			handler = sapiclient.ResponseSignMiddleware(nil, signKey)(handler)
+		}
+		if encoding != "" && strings.Contains(strings.ToLower(r.Header.Get("Accept-Encoding")), encoding) {
+			handler = compressResponse(handler, encoding)
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	s.URL = s.server.URL
+	return s
+}
+
+// Close
+//
+//	@Description: 关闭模拟服务
+//	@receiver s
+//	@Author zzh 2026-10-19 11:06:40
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// Client
+//
+//	@Description: 返回已指向模拟服务并配置好appKey、appSecret的客户端
+//	@receiver s
+//	@Author zzh 2026-10-19 11:07:58
+//	@return sapiclient.Client
+//	@return error
+func (s *Server) Client() (sapiclient.Client, error) {
+	return sapiclient.NewClient(
+		sapiclient.WithCredentials(s.AppKey, s.AppSecret),
+		sapiclient.WithBaseURL(s.URL),
+	)
+}
+
+// Handle
+//
+//	@Description: 注册服务方法的处理函数
+//	@receiver s
+//	@Author zzh 2026-10-19 11:09:21
+//	@param service
+//	@param method
+//	@param handler
+//	@return *Server
+func (s *Server) Handle(service, method string, handler HandlerFunc) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[routeKey(service, method)] = handler
+	delete(s.downloads, routeKey(service, method))
+	return s
+}
+
+// HandleResponse
+//
+//	@Description: 注册服务方法的固定响应
+//	@receiver s
+//	@Author zzh 2026-10-19 11:10:35
+//	@param service
+//	@param method
+//	@param response
+//	@return *Server
+func (s *Server) HandleResponse(service, method string, response *sapiclient.ResponseData) *Server {
+	return s.Handle(service, method, func(call *Call) (*sapiclient.ResponseData, error) {
+		return response, nil
+	})
+}
+
+// HandleDownload
+//
+//	@Description: 注册服务方法的下载处理函数，按sapiclient.ServeDownload返回内容，支持Range续传
+//	@receiver s
+//	@Author zzh 2026-10-20 03:50:12
+//	@param service
+//	@param method
+//	@param handler
+//	@return *Server
+func (s *Server) HandleDownload(service, method string, handler DownloadFunc) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.downloads[routeKey(service, method)] = handler
+	delete(s.handlers, routeKey(service, method))
+	return s
+}
+
+// HandleContent
+//
+//	@Description: 注册服务方法的固定下载内容
+//	@receiver s
+//	@Author zzh 2026-10-20 03:50:58
+//	@param service
+//	@param method
+//	@param content
+//	@return *Server
+func (s *Server) HandleContent(service, method string, content []byte) *Server {
+	return s.HandleDownload(service, method, func(call *Call) ([]byte, error) {
+		return content, nil
+	})
+}
+
+// SetLatency
+//
+//	@Description: 设置所有请求的响应延迟
+//	@receiver s
+//	@Author zzh 2026-10-19 11:11:47
+//	@param latency
+//	@return *Server
+func (s *Server) SetLatency(latency time.Duration) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = latency
+	return s
+}
+
+// SetNonceStore
+//
+//	@Description: 设置nonce存储，设置后重复使用nonce的请求会被拒绝
+//	@receiver s
+//	@Author zzh 2026-10-19 13:48:20
+//	@param store
+//	@return *Server
+func (s *Server) SetNonceStore(store sapiclient.NonceStore) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nonces = store
+	return s
+}
+
+// SetTimeWindow
+//
+//	@Description: 设置请求时间允许的偏差，默认sapiclient.DEFAULT_TIME_WINDOW，请求的time超出时返回CODE_TIME_INVALID
+//	@receiver s
+//	@Author zzh 2026-10-20 11:45:10
+//	@param window
+//	@return *Server
+func (s *Server) SetTimeWindow(window time.Duration) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if window <= 0 {
+		window = sapiclient.DEFAULT_TIME_WINDOW
+	}
+	s.window = window
+	return s
+}
+
+// SetResponseSignKey
+//
+//	@Description: 设置响应签名密钥，设置后所有响应都携带响应签名
+//	@receiver s
+//	@Author zzh 2026-10-19 16:22:15
+//	@param key
+//	@return *Server
+func (s *Server) SetResponseSignKey(key string) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.signKey = key
+	return s
+}
+
+// SetResponseEncoding
+//
+//	@Description: 设置响应压缩方式，请求的Accept-Encoding包含该方式时压缩响应，响应签名针对压缩前的内容
+//	@receiver s
+//	@Author zzh 2026-10-20 00:15:20
+//	@param encoding gzip或deflate，为空时不压缩
+//	@return *Server
+func (s *Server) SetResponseEncoding(encoding string) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.encoding = strings.ToLower(encoding)
+	return s
+}
+
+// InjectFault
+//
+//	@Description: 对服务方法注入故障，service与method为"*"时对所有请求生效
+//	@receiver s
+//	@Author zzh 2026-10-19 11:13:02
+//	@param service
+//	@param method
+//	@param fault
+//	@return *Server
+func (s *Server) InjectFault(service, method string, fault Fault) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults[routeKey(service, method)] = &fault
+	return s
+}
+
+// Calls
+//
+//	@Description: 返回收到的所有调用
+//	@receiver s
+//	@Author zzh 2026-10-19 11:14:18
+//	@return []*Call
+func (s *Server) Calls() []*Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*Call(nil), s.calls...)
+}
+
+// CallsTo
+//
+//	@Description: 返回指定服务方法收到的调用
+//	@receiver s
+//	@Author zzh 2026-10-19 11:15:33
+//	@param service
+//	@param method
+//	@return []*Call
+func (s *Server) CallsTo(service, method string) []*Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	calls := make([]*Call, 0)
+	for _, call := range s.calls {
+		if call.Service == service && call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Reset
+//
+//	@Description: 清空调用记录与注入的故障
+//	@receiver s
+//	@Author zzh 2026-10-19 11:16:49
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = nil
+	s.faults = make(map[string]*Fault)
+	s.latency = 0
+}
+
+// serveHTTP
+//
+//	@Description: 路由sapi/<service>/<method>请求并校验签名
+//	@receiver s
+//	@Author zzh 2026-10-19 11:19:26
+//	@param w
+//	@param r
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	call, err := s.parseCall(r)
+	if err != nil {
+		writeResponse(w, http.StatusBadRequest, &sapiclient.ResponseData{Code: http.StatusBadRequest, Msg: err.Error()})
+		return
+	}
+	s.mu.Lock()
+	requestTime, timeErr := strconv.ParseInt(call.Header.Get("time"), 10, 64)
+	if skew := call.ReceivedAt.Sub(time.Unix(requestTime, 0)); timeErr == nil && skew <= s.window && skew >= -s.window {
+		call.TimeValid = true
+	}
+	if s.nonces != nil && call.SignValid && call.TimeValid {
+		fresh, nonceErr := s.nonces.Use(call.Header.Get("appkey")+":"+call.Header.Get("nonce"), time.Unix(requestTime, 0).Add(s.window))
+		call.Replayed = nonceErr == nil && !fresh
+	}
+	s.calls = append(s.calls, call)
+	latency := s.latency
+	fault := s.takeFault(call.Service, call.Method)
+	handler := s.handlers[routeKey(call.Service, call.Method)]
+	download := s.downloads[routeKey(call.Service, call.Method)]
+	s.mu.Unlock()
+
+	if fault != nil {
+		latency += fault.Latency
+	}
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if fault != nil && fault.StatusCode != 0 {
+		writeResponse(w, fault.StatusCode, &sapiclient.ResponseData{Code: fault.StatusCode, Msg: http.StatusText(fault.StatusCode)})
+		return
+	}
+	if fault != nil && fault.Malformed {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":0,"msg":"ok","data":`))
+		return
+	}
+	if !call.SignValid {
+		writeResponse(w, http.StatusUnauthorized, &sapiclient.ResponseData{Code: sapiclient.CODE_SIGN_INVALID, Msg: "签名校验失败"})
+		return
+	}
+	if !call.TimeValid {
+		writeResponse(w, http.StatusUnauthorized, &sapiclient.ResponseData{Code: sapiclient.CODE_TIME_INVALID, Msg: "请求时间超出允许范围"})
+		return
+	}
+	if call.Replayed {
+		writeResponse(w, http.StatusUnauthorized, &sapiclient.ResponseData{Code: sapiclient.CODE_NONCE_REPLAYED, Msg: "nonce已被使用"})
+		return
+	}
+	if download != nil {
+		content, err := download(call)
+		if err != nil {
+			writeResponse(w, http.StatusInternalServerError, &sapiclient.ResponseData{Code: http.StatusInternalServerError, Msg: err.Error()})
+			return
+		}
+		if fault != nil && fault.Truncate > 0 {
+			w = &truncatedWriter{ResponseWriter: w, remaining: fault.Truncate}
+		}
+		sapiclient.ServeDownload(w, r, bytes.NewReader(content), "")
+		return
+	}
+	if handler == nil {
+		writeResponse(w, http.StatusNotFound, &sapiclient.ResponseData{Code: http.StatusNotFound, Msg: "服务方法不存在: " + routeKey(call.Service, call.Method)})
+		return
+	}
+	response, err := handler(call)
+	if err != nil {
+		writeResponse(w, http.StatusInternalServerError, &sapiclient.ResponseData{Code: http.StatusInternalServerError, Msg: err.Error()})
+		return
+	}
+	if response == nil {
+		response = &sapiclient.ResponseData{}
+	}
+	writeResponse(w, http.StatusOK, response)
+}
+
+// parseCall
+//
+//	@Description: 解析请求为调用记录
+//	@receiver s
+//	@Author zzh 2026-10-19 11:22:08
+//	@param r
+//	@return *Call
+//	@return error
+func (s *Server) parseCall(r *http.Request) (*Call, error) {
+	raw, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	header := r.Header.Clone()
+	r.Body = ioutil.NopCloser(bytes.NewReader(raw))
+	if err = sapiclient.DecodeRequestBody(r, 0); err != nil {
+		return nil, err
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	pathUrl := strings.TrimLeft(r.URL.Path, "/")
+	parts := strings.Split(pathUrl, "/")
+	if len(parts) != 3 || parts[0] != "sapi" {
+		return nil, fmt.Errorf("请求路径不合法: %s", r.URL.Path)
+	}
+	call := &Call{
+		Service:    parts[1],
+		Method:     parts[2],
+		HttpMethod: r.Method,
+		Header:     header,
+		Params:     make(map[string]interface{}),
+		Body:       body,
+		ReceivedAt: time.Now(),
+	}
+	for key, values := range r.URL.Query() {
+		call.Params[key] = values[0]
+	}
+	if mediaType, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+		if err = parseMultipart(call, body, params["boundary"]); err != nil {
+			return nil, err
+		}
+	} else if len(bytes.TrimSpace(body)) > 0 {
+		data := make(map[string]interface{})
+		if err = json.Unmarshal(body, &data); err == nil {
+			for key, val := range data {
+				call.Params[key] = val
+			}
+		} else if form, formErr := url.ParseQuery(string(body)); formErr == nil {
+			for key, values := range form {
+				call.Params[key] = values[0]
+			}
+		}
+	}
+	signer, ok := sapiclient.SignerForVersion(r.Header.Get(sapiclient.SIGN_VERSION_HEADER))
+	call.SignValid = ok && r.Header.Get("appkey") == s.AppKey && r.Header.Get("sign") != "" && signer.Verify(&sapiclient.SignRequest{
+		AppKey:    s.AppKey,
+		AppSecret: s.AppSecret,
+		Method:    r.Method,
+		Path:      pathUrl,
+		Query:     r.URL.Query(),
+		Body:      raw,
+		Nonce:     r.Header.Get("nonce"),
+		Timestamp: r.Header.Get("time"),
+		Header:    header,
+	}) == nil
+	return call, nil
+}
+
+// parseMultipart
+//
+//	@Description: 解析multipart请求体，普通字段写入Params，文件写入Files
+//	@Author zzh 2026-10-20 02:08:40
+//	@param call
+//	@param body
+//	@param boundary
+//	@return error
+func parseMultipart(call *Call, body []byte, boundary string) error {
+	reader := multipart.NewReader(bytes.NewReader(body), boundary)
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("multipart请求体解析失败: %v", err)
+		}
+		content, err := ioutil.ReadAll(part)
+		if err != nil {
+			return fmt.Errorf("multipart请求体解析失败: %v", err)
+		}
+		if part.FileName() == "" {
+			call.Params[part.FormName()] = string(content)
+			continue
+		}
+		call.Files = append(call.Files, &File{
+			Field:       part.FormName(),
+			FileName:    part.FileName(),
+			ContentType: part.Header.Get("Content-Type"),
+			Content:     content,
+		})
+	}
+}
+
+// takeFault
+//
+//	@Description: 取出对当前调用生效的故障，需要在持有锁时调用
+//	@receiver s
+//	@Author zzh 2026-10-19 11:24:55
+//	@param service
+//	@param method
+//	@return *Fault
+func (s *Server) takeFault(service, method string) *Fault {
+	for _, key := range []string{routeKey(service, method), routeKey(service, "*"), routeKey("*", "*")} {
+		fault, ok := s.faults[key]
+		if !ok {
+			continue
+		}
+		if fault.Times > 0 {
+			fault.Times--
+			if fault.Times == 0 {
+				delete(s.faults, key)
+			}
+		}
+		return fault
+	}
+	return nil
+}
+
+// routeKey
+//
+//	@Description: 生成路由key
+//	@Author zzh 2026-10-19 11:26:10
+//	@param service
+//	@param method
+//	@return string
+func routeKey(service, method string) string {
+	return service + "/" + method
+}
+
+// writeResponse
+//
+//	@Description: 输出json响应
+//	@Author zzh 2026-10-19 11:27:31
+//	@param w
+//	@param statusCode
+//	@param response
+func writeResponse(w http.ResponseWriter, statusCode int, response *sapiclient.ResponseData) {
+	content, _ := json.Marshal(response)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_, _ = w.Write(content)
+}
+
+// truncatedWriter
+// @Description: 发送指定字节数的响应体后断开连接，模拟下载中断
+type truncatedWriter struct {
+	http.ResponseWriter
+	remaining int64
+}
+
+// Write
+//
+//	@Description: 写入响应体，超过剩余字节数时发送已写入的部分并中止连接
+//	@receiver w
+//	@Author zzh 2026-10-20 03:52:30
+//	@param p
+//	@return int
+//	@return error
+func (w *truncatedWriter) Write(p []byte) (int, error) {
+	if int64(len(p)) <= w.remaining {
+		w.remaining -= int64(len(p))
+		return w.ResponseWriter.Write(p)
+	}
+	_, _ = w.ResponseWriter.Write(p[:w.remaining])
+	w.remaining = 0
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	panic(http.ErrAbortHandler)
+}
+
+// compressResponse
+//
+//	@Description: 压缩next输出的响应
+//	@Author zzh 2026-10-20 00:17:45
+//	@param next
+//	@param encoding gzip或deflate
+//	@return http.Handler
+func compressResponse(next http.Handler, encoding string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder := httptest.NewRecorder()
+		next.ServeHTTP(recorder, r)
+		var buffer bytes.Buffer
+		var writer io.WriteCloser = gzip.NewWriter(&buffer)
+		if encoding == sapiclient.ENCODING_DEFLATE {
+			writer = zlib.NewWriter(&buffer)
+		}
+		_, _ = writer.Write(recorder.Body.Bytes())
+		_ = writer.Close()
+		for name, values := range recorder.Header() {
+			w.Header()[name] = values
+		}
+		w.Header().Set(sapiclient.CONTENT_ENCODING_HEADER, encoding)
+		w.Header().Del("Content-Length")
+		w.WriteHeader(recorder.Code)
+		_, _ = w.Write(buffer.Bytes())
+	})
+}
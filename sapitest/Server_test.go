@@ -0,0 +1,141 @@
+package sapitest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/zhenhua1/go-sapiclient/sapiclient"
+)
+
+// signedRequest 生成以requestTime签名的v2请求
+func signedRequest(t *testing.T, s *Server, nonce string, requestTime time.Time) *http.Request {
+	body := []byte(`{"name":"sapi"}`)
+	req, err := http.NewRequest(http.MethodPost, s.URL+"/sapi/demo/echo", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("appkey", s.AppKey)
+	req.Header.Set("nonce", nonce)
+	req.Header.Set("time", strconv.FormatInt(requestTime.Unix(), 10))
+	sign, err := sapiclient.HMACSHA256Signer{}.Sign(&sapiclient.SignRequest{
+		AppKey:    s.AppKey,
+		AppSecret: s.AppSecret,
+		Method:    http.MethodPost,
+		Path:      "sapi/demo/echo",
+		Body:      body,
+		Nonce:     nonce,
+		Timestamp: req.Header.Get("time"),
+		Header:    req.Header,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, val := range sign {
+		req.Header.Set(key, val)
+	}
+	return req
+}
+
+// doRequest 发送请求并解析响应
+func doRequest(t *testing.T, req *http.Request) (int, *sapiclient.ResponseData) {
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	data := &sapiclient.ResponseData{}
+	if err = json.NewDecoder(res.Body).Decode(data); err != nil {
+		t.Fatal(err)
+	}
+	return res.StatusCode, data
+}
+
+func TestServerClient(t *testing.T) {
+	s := NewServer("key", "secret")
+	defer s.Close()
+	s.Handle("demo", "echo", func(call *Call) (*sapiclient.ResponseData, error) {
+		return &sapiclient.ResponseData{Code: http.StatusOK, Msg: "success", Data: call.Params["name"]}, nil
+	})
+	c, err := s.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := c.Call("demo", "echo", map[string]interface{}{"name": "sapi"})
+	if err != nil || data.Data != "sapi" {
+		t.Fatalf("call = %+v, %v", data, err)
+	}
+	calls := s.CallsTo("demo", "echo")
+	if len(calls) != 1 || !calls[0].SignValid || !calls[0].TimeValid {
+		t.Fatalf("calls = %+v", calls)
+	}
+	if _, err = c.Call("demo", "missing", nil); err == nil {
+		t.Fatal("unregistered method succeeded")
+	}
+}
+
+func TestServerTimeWindow(t *testing.T) {
+	s := NewServer("key", "secret")
+	defer s.Close()
+	s.HandleResponse("demo", "echo", &sapiclient.ResponseData{Code: http.StatusOK, Msg: "success"})
+	statusCode, data := doRequest(t, signedRequest(t, s, "n1", time.Now().Add(-time.Hour)))
+	if statusCode != http.StatusUnauthorized || data.Code != sapiclient.CODE_TIME_INVALID {
+		t.Fatalf("stale request = %d, %+v", statusCode, data)
+	}
+	if calls := s.Calls(); len(calls) != 1 || !calls[0].SignValid || calls[0].TimeValid {
+		t.Fatalf("calls = %+v", calls)
+	}
+	s.SetTimeWindow(2 * time.Hour)
+	if statusCode, data = doRequest(t, signedRequest(t, s, "n2", time.Now().Add(-time.Hour))); statusCode != http.StatusOK {
+		t.Fatalf("request within window = %d, %+v", statusCode, data)
+	}
+}
+
+func TestServerNonceReplay(t *testing.T) {
+	s := NewServer("key", "secret")
+	defer s.Close()
+	s.SetNonceStore(sapiclient.NewMemoryNonceStore(sapiclient.MemoryNonceOptions{}))
+	s.HandleResponse("demo", "echo", &sapiclient.ResponseData{Code: http.StatusOK, Msg: "success"})
+	c, err := s.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := sapiclient.CallOptions{Nonce: "fixed-nonce"}
+	if _, err = c.CallWithOptions(context.Background(), "demo", "echo", nil, options); err != nil {
+		t.Fatal(err)
+	}
+	response, err := c.CallWithOptions(context.Background(), "demo", "echo", nil, options)
+	if err == nil || response == nil || response.ResponseData.Code != sapiclient.CODE_NONCE_REPLAYED {
+		t.Fatalf("replay = %+v, %v", response, err)
+	}
+	if calls := s.Calls(); len(calls) != 2 || calls[0].Replayed || !calls[1].Replayed {
+		t.Fatal("replay not recorded")
+	}
+}
+
+func TestServerInjectFault(t *testing.T) {
+	s := NewServer("key", "secret")
+	defer s.Close()
+	s.HandleResponse("demo", "echo", &sapiclient.ResponseData{Code: http.StatusOK, Msg: "success"})
+	s.InjectFault("demo", "*", Fault{StatusCode: http.StatusServiceUnavailable, Times: 1})
+	c, err := s.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := c.CallWithOptions(context.Background(), "demo", "echo", nil, sapiclient.CallOptions{})
+	if err == nil || response == nil || response.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("faulted call = %+v, %v", response, err)
+	}
+	if _, err = c.Call("demo", "echo", nil); err != nil {
+		t.Fatalf("call after fault = %v", err)
+	}
+	s.Reset()
+	if len(s.Calls()) != 0 {
+		t.Fatal("calls not reset")
+	}
+}